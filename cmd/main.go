@@ -1,9 +1,11 @@
 package main
 
 import (
+	"os"
+
 	"github.com/oddjob23/go-cli/internal/commands"
 )
 
 func main() {
-	commands.Execute()
+	os.Exit(commands.Execute())
 }
@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch",
+	Short: "Work with a feature branch across repositories",
+	Long: `Creates, switches to and lists branches in every repository chosen by --only, --exclude
+and --group, so a feature touching several services can use one branch name throughout.
+
+Repositories with uncommitted changes are skipped, as during sync.`,
+}
+
+var branchCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a branch from the updated default branch",
+	Long: `Fast-forwards the default branch from origin and creates the branch from it in every
+selected repository, leaving the new branch checked out.
+
+Example:
+  go-cli branch create feature/checkout-v2 --only orders,payments,gateway`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBranchCreate,
+}
+
+var branchSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Switch every repository that has the branch",
+	Long: `Checks out the branch in every selected repository that has it, locally or on origin,
+and reports the repositories that don't.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBranchSwitch,
+}
+
+var branchListCmd = &cobra.Command{
+	Use:   "list [pattern]",
+	Short: "List matching branches with their ahead/behind counts",
+	Long: `Lists the local branches matching the pattern in every selected repository, with the
+number of commits they are ahead of and behind their upstream. The pattern is a glob such as
+"feature/*"; without wildcards it matches branches containing it.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBranchList,
+}
+
+func runBranchCreate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	return runBranchOperation(cmd, fmt.Sprintf("Creating '%s'", name), "create the branch",
+		func(ops *git.Operations, repo git.Repository, cfg *config.Config) git.OperationResult {
+			return ops.CreateFeatureBranch(repo, name, cfg.GitBranch)
+		})
+}
+
+func runBranchSwitch(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	return runBranchOperation(cmd, fmt.Sprintf("Switching to '%s'", name), "switch",
+		func(ops *git.Operations, repo git.Repository, cfg *config.Config) git.OperationResult {
+			return ops.SwitchBranch(repo, name)
+		})
+}
+
+// runBranchOperation runs op on the selected repositories in parallel and reports the results.
+// Repositories without the branch (git.ErrBranchNotFound) are listed but do not count as failures.
+func runBranchOperation(cmd *cobra.Command, title, action string, op func(*git.Operations, git.Repository, *config.Config) git.OperationResult) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	output.Info("%s in %d repositories", title, len(repos))
+	output.Plain("")
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make([]git.OperationResult, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+
+			result := op(ops, git.Repository{Path: r.Path, Name: r.Name}, cfg)
+			results[index] = result
+			if errors.Is(result.Error, git.ErrBranchNotFound) {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			output.Plain("  📂 %s", r.Name)
+			if result.Success {
+				output.Plain("    ✅  %s", result.Message)
+			} else {
+				output.Plain("     ❌ %s", result.Message)
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	var missing []string
+	succeeded, failed := 0, 0
+	for _, result := range results {
+		switch {
+		case errors.Is(result.Error, git.ErrBranchNotFound):
+			missing = append(missing, result.Repository.Name)
+		case result.Success:
+			succeeded++
+		default:
+			failed++
+		}
+	}
+
+	output.Plain("")
+	if len(missing) > 0 {
+		output.Warning("Branch not found in %d repositories: %s", len(missing), strings.Join(missing, ", "))
+	}
+	if failed > 0 {
+		output.Warning("%d of %d repositories failed to %s", failed, succeeded+failed, action)
+		return newFailureError(failed, succeeded+failed, action)
+	}
+	if succeeded == 0 {
+		return fmt.Errorf("branch not found in any selected repository")
+	}
+
+	output.Success("Done in %d repositories", succeeded)
+	return nil
+}
+
+func runBranchList(cmd *cobra.Command, args []string) error {
+	pattern := ""
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	branches := make([][]git.BranchInfo, len(repos))
+	errs := make([]error, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			branches[index], errs[index] = ops.ListBranches(r.Path, pattern)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	var rows [][]string
+	var without []string
+	failed := 0
+	for i, repo := range repos {
+		if errs[i] != nil {
+			failed++
+			output.Error("%s: %v", repo.Name, errs[i])
+			continue
+		}
+		if len(branches[i]) == 0 {
+			without = append(without, repo.Name)
+			continue
+		}
+		for _, branch := range branches[i] {
+			name := "  " + branch.Name
+			if branch.Current {
+				name = "* " + branch.Name
+			}
+			rows = append(rows, []string{repo.Name, name, formatUpstream(branch), formatCount(branch.Ahead, branch), formatCount(branch.Behind, branch)})
+		}
+	}
+
+	if len(rows) > 0 {
+		output.Table([]string{"REPOSITORY", "BRANCH", "UPSTREAM", "AHEAD", "BEHIND"}, rows)
+	}
+	if len(without) > 0 {
+		output.Plain("")
+		output.Plain("No matching branches in: %s", strings.Join(without, ", "))
+	}
+
+	if failed > 0 {
+		return newFailureError(failed, len(repos), "list branches")
+	}
+	return nil
+}
+
+// formatUpstream describes the upstream of a branch for the list table
+func formatUpstream(branch git.BranchInfo) string {
+	switch {
+	case branch.Upstream == "":
+		return "-"
+	case branch.Gone:
+		return branch.Upstream + " (gone)"
+	default:
+		return branch.Upstream
+	}
+}
+
+// formatCount formats an ahead or behind count, which is meaningless without a live upstream
+func formatCount(n int, branch git.BranchInfo) string {
+	if branch.Upstream == "" || branch.Gone {
+		return "-"
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func init() {
+	branchSwitchCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeBranchNames(cmd, args, toComplete)
+	}
+
+	branchCmd.AddCommand(branchCreateCmd, branchSwitchCmd, branchListCmd)
+	rootCmd.AddCommand(branchCmd)
+}
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var commitCmd = &cobra.Command{
+	Use:   "commit -m <message>",
+	Short: "Commit the same change in every repository that has one",
+	Long: `Commits with the same message in every repository chosen by --only, --exclude and --group
+that has something to commit. Like git commit, only staged changes are committed unless --all
+is given, which also commits every change to tracked files; untracked files are never added.
+
+A diffstat of each commit is shown first, and the commits are made after confirmation, or
+right away with --yes.
+
+Example:
+  go-cli commit --all -m "Bump shared-lib to v1.8.0" --group backend`,
+	Args: cobra.NoArgs,
+	RunE: runCommit,
+}
+
+// pendingCommit is what would be committed in a repository
+type pendingCommit struct {
+	repo    config.Repository
+	pending *git.PendingCommit
+	err     error
+}
+
+func runCommit(cmd *cobra.Command, args []string) error {
+	message, _ := cmd.Flags().GetString("message")
+	all, _ := cmd.Flags().GetBool("all")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	if strings.TrimSpace(message) == "" {
+		return newUsageError("a commit message is required (-m)")
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	pending := make([]pendingCommit, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			changes, err := ops.PendingCommit(r.Path, all)
+			pending[index] = pendingCommit{repo: r, pending: changes, err: err}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	var clean []string
+	total, failed := 0, 0
+	for _, p := range pending {
+		switch {
+		case p.err != nil:
+			failed++
+			output.Plain("  📂 %s", p.repo.Name)
+			output.Plain("     ❌ %v", p.err)
+		case p.pending == nil:
+			clean = append(clean, p.repo.Name)
+		default:
+			total++
+			output.Plain("  📂 %s", p.repo.Name)
+			printDiffStat(output, p.pending)
+		}
+	}
+
+	if len(clean) > 0 {
+		output.Plain("")
+		output.Plain("%s", utils.Gray("Nothing to commit in: "+strings.Join(clean, ", ")))
+	}
+
+	if total == 0 {
+		if failed > 0 {
+			return newFailureError(failed, len(repos), "read changes")
+		}
+		if all {
+			output.Warning("Nothing to commit")
+		} else {
+			output.Warning("Nothing staged to commit; use --all to commit every change to tracked files")
+		}
+		return nil
+	}
+
+	output.Plain("")
+	if !yes {
+		if !confirm(cmd, "Commit in %d repositories?", total) {
+			output.Warning("Nothing committed")
+			if failed > 0 {
+				return newFailureError(failed, len(repos), "read changes")
+			}
+			return nil
+		}
+		output.Plain("")
+	}
+
+	committed := 0
+	for _, p := range pending {
+		if p.err != nil || p.pending == nil {
+			continue
+		}
+
+		result := ops.Commit(git.Repository{Path: p.repo.Path, Name: p.repo.Name}, message, all)
+		output.Plain("  📂 %s", p.repo.Name)
+		if !result.Success {
+			failed++
+			output.Plain("     ❌ %s", result.Message)
+			continue
+		}
+		committed++
+		output.Plain("    ✅  %s", result.Message)
+	}
+
+	output.Plain("")
+	if failed > 0 {
+		output.Warning("Committed in %d of %d repositories", committed, committed+failed)
+		return newFailureError(failed, committed+failed, "commit")
+	}
+
+	output.Success("Committed in %d repositories", committed)
+	return nil
+}
+
+// printDiffStat shows the files a commit would change and their totals
+func printDiffStat(output *utils.CliOutput, pending *git.PendingCommit) {
+	lines := strings.Split(pending.Stat, "\n")
+	// The last line of git diff --stat is the summary, which is printed colored below
+	for _, line := range lines[:len(lines)-1] {
+		output.Plain("       %s", strings.TrimSpace(line))
+	}
+	output.Plain("       %d files changed, %s, %s", pending.FilesChanged,
+		utils.Success(fmt.Sprintf("+%d", pending.Insertions)), utils.Error(fmt.Sprintf("-%d", pending.Deletions)))
+}
+
+func init() {
+	commitCmd.Flags().StringP("message", "m", "", "Commit message")
+	commitCmd.Flags().BoolP("all", "a", false, "Commit every change to tracked files, not only staged ones")
+	commitCmd.Flags().BoolP("yes", "y", false, "Commit without asking for confirmation")
+	_ = commitCmd.MarkFlagRequired("message")
+
+	rootCmd.AddCommand(commitCmd)
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate shell completion scripts",
+	Long: `Generates a completion script for the given shell.
+
+  bash:       source <(go-cli completion bash)
+  zsh:        go-cli completion zsh > "${fpath[1]}/_go-cli"
+  fish:       go-cli completion fish > ~/.config/fish/completions/go-cli.fish
+  powershell: go-cli completion powershell | Out-String | Invoke-Expression
+
+Repository names, groups and branches are completed from the loaded configuration.`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(out, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(out)
+	case "fish":
+		return rootCmd.GenFishCompletion(out, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(out)
+	default:
+		return newUsageError("unsupported shell %q", args[0])
+	}
+}
+
+// completeRepositoryNames completes the comma-separated --only and --exclude flags
+func completeRepositoryNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, ok := completionConfig(cmd)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeList(cfg.RepositoryNames(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeGroupNames completes the comma-separated --group flag
+func completeGroupNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, ok := completionConfig(cmd)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeList(cfg.GroupNames(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeRepositoryArgs completes repository names given as arguments
+func completeRepositoryArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, ok := completionConfig(cmd)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, name := range cfg.RepositoryNames() {
+		if strings.HasPrefix(name, toComplete) && !containsArg(args, name) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBranchNames completes --branch with the remote branches of the selected repositories
+func completeBranchNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, ok := completionConfig(cmd)
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(r config.Repository) {
+			defer wg.Done()
+
+			branches, err := git.RemoteBranches(r.Path, "origin")
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			for _, branch := range branches {
+				seen[branch] = true
+			}
+			mu.Unlock()
+		}(repo)
+	}
+	wg.Wait()
+
+	var branches []string
+	for branch := range seen {
+		if strings.HasPrefix(branch, toComplete) {
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+
+	return branches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionConfig loads the configuration without validating repository paths,
+// so completion stays fast and works even when some repositories are missing
+func completionConfig(cmd *cobra.Command) (*config.Config, bool) {
+	configFile, _ := cmd.Flags().GetString("config")
+
+	cfg, err := config.LoadFromFile(configFile)
+	if err != nil {
+		return nil, false
+	}
+	return cfg, true
+}
+
+// completeList completes the last element of a comma-separated list, skipping values already given
+func completeList(candidates []string, toComplete string) []string {
+	done := ""
+	current := toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done = toComplete[:i+1]
+		current = toComplete[i+1:]
+	}
+
+	used := make(map[string]bool)
+	for _, value := range strings.Split(done, ",") {
+		used[value] = true
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if !used[candidate] && strings.HasPrefix(candidate, current) {
+			completions = append(completions, done+candidate)
+		}
+	}
+	return completions
+}
+
+func init() {
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompleteList(t *testing.T) {
+	candidates := []string{"auth", "billing", "gateway", "notifications"}
+
+	tests := []struct {
+		name       string
+		toComplete string
+		want       []string
+	}{
+		{
+			name:       "should return every candidate for empty input",
+			toComplete: "",
+			want:       []string{"auth", "billing", "gateway", "notifications"},
+		},
+		{
+			name:       "should filter by prefix",
+			toComplete: "b",
+			want:       []string{"billing"},
+		},
+		{
+			name:       "should complete the last element of a list",
+			toComplete: "auth,g",
+			want:       []string{"auth,gateway"},
+		},
+		{
+			name:       "should skip values already in the list",
+			toComplete: "auth,billing,",
+			want:       []string{"auth,billing,gateway", "auth,billing,notifications"},
+		},
+		{
+			name:       "should return nothing when no candidate matches",
+			toComplete: "x",
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completeList(candidates, tt.toComplete); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completeList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCompletionWritesToCommandOutput(t *testing.T) {
+	for _, shell := range completionCmd.ValidArgs {
+		t.Run(shell, func(t *testing.T) {
+			var out bytes.Buffer
+			completionCmd.SetOut(&out)
+			t.Cleanup(func() { completionCmd.SetOut(nil) })
+
+			if err := runCompletion(completionCmd, []string{shell}); err != nil {
+				t.Fatalf("runCompletion(%s) error = %v", shell, err)
+			}
+			if !strings.Contains(out.String(), "go-cli") {
+				t.Errorf("runCompletion(%s) wrote %d bytes without the command name", shell, out.Len())
+			}
+		})
+	}
+}
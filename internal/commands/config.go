@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage config.json",
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import --from <format> <file>",
+	Short: "Add the repositories of another tool's manifest to config.json",
+	Long: `Reads the repositories described by a manifest of another multi-repository tool and merges
+them into config.json, which is created when missing. Supported formats:
+
+  repo-manifest  Android repo tool manifest XML (<project> elements)
+  gitmodules     .gitmodules of a superproject
+  mani           mani.yaml (projects; tags become groups)
+  mrconfig       myrepos .mrconfig (sections named after checkout paths)
+
+Paths in the manifest are resolved against --root and saved as absolute paths. --root
+defaults to the working directory for repo-manifest, whose paths are relative to the top of
+the repo checkout, and to the directory of the manifest otherwise. Repositories already configured under the same name and path gain the imported
+groups, URL and revision. An imported repository whose name or path is configured
+differently is a conflict: it is reported and config.json keeps its entry.
+
+Example:
+  go-cli config import --from repo-manifest --root ~/src .repo/manifests/default.xml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigImport,
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) error {
+	file := args[0]
+	format, _ := cmd.Flags().GetString("from")
+	root, _ := cmd.Flags().GetString("root")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	configFile, _ := cmd.Flags().GetString("config")
+
+	if root == "" {
+		root = filepath.Dir(file)
+		if format == config.ImportRepoManifest {
+			// repo runs from the top of the checkout and keeps its manifests under .repo/manifests
+			root = "."
+		}
+	}
+
+	imported, err := config.ImportRepositories(format, file, root)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	cfg, err := config.ReadFromFile(configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		cfg, err = &config.Config{}, nil
+	}
+	if err != nil {
+		return newUsageError("failed to load configuration: %w", err)
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(imported) == 0 {
+		output.Warning("No repositories found in %s", file)
+		return nil
+	}
+
+	changes := cfg.MergeRepositories(imported)
+
+	counts := make(map[string]int)
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		counts[change.Kind]++
+		result := change.Kind
+		switch change.Kind {
+		case config.ImportAdded, config.ImportUpdated:
+			result = utils.Success(change.Kind)
+		case config.ImportConflict:
+			result = utils.Error(change.Kind)
+		}
+		rows = append(rows, []string{change.Repository.Name, change.Repository.Path, orDash(strings.Join(change.Repository.Groups, ", ")), result})
+	}
+	output.Table([]string{"REPOSITORY", "PATH", "GROUPS", "RESULT"}, rows)
+	output.Plain("")
+
+	for _, change := range changes {
+		if change.Kind == config.ImportConflict {
+			output.Warning("%s: %s", change.Repository.Name, change.Reason)
+		}
+	}
+
+	if counts[config.ImportAdded]+counts[config.ImportUpdated] == 0 {
+		output.Success("%s is already up to date", configFile)
+		return nil
+	}
+	if dryRun {
+		output.Info("Dry run: %d to add and %d to update; %s not written",
+			counts[config.ImportAdded], counts[config.ImportUpdated], configFile)
+		return nil
+	}
+
+	if err := cfg.SaveToFile(configFile); err != nil {
+		return err
+	}
+	output.Success("Added %d and updated %d repositories in %s", counts[config.ImportAdded], counts[config.ImportUpdated], configFile)
+	return nil
+}
+
+func init() {
+	configImportCmd.Flags().String("from", "", "Manifest format: "+strings.Join(config.ImportFormats, ", "))
+	configImportCmd.Flags().String("root", "", "Directory the manifest paths are relative to (default: the working directory for repo-manifest, the manifest's directory otherwise)")
+	configImportCmd.Flags().Bool("dry-run", false, "Show what would be imported without writing config.json")
+	_ = configImportCmd.MarkFlagRequired("from")
+	_ = configImportCmd.RegisterFlagCompletionFunc("from", cobra.FixedCompletions(config.ImportFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	configCmd.AddCommand(configImportCmd)
+	rootCmd.AddCommand(configCmd)
+}
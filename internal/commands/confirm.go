@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// confirm asks a yes/no question on the terminal. Anything but "y" or "yes" counts as no,
+// including a closed standard input.
+func confirm(cmd *cobra.Command, format string, args ...interface{}) bool {
+	fmt.Fprintf(cmd.OutOrStdout(), format+" [y/N] ", args...)
+
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(cmd.OutOrStdout())
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
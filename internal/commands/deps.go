@@ -0,0 +1,411 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/docker"
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var depsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "Manage the docker-compose dependency stack",
+	Long: `Manages the infrastructure containers (databases, Kafka, Redis, ...) described by the
+dependency compose file. The file defaults to docker-compose.dependencies.yml and can be set
+with "composeFile" in config.json or the --file flag.`,
+}
+
+var depsUpCmd = &cobra.Command{
+	Use:   "up [service...]",
+	Short: "Create and start dependency services",
+	RunE:  runDepsUp,
+}
+
+var depsDownCmd = &cobra.Command{
+	Use:   "down [service...]",
+	Short: "Stop and remove dependency services",
+	Long: `Stops and removes the given services, or the whole stack when no services are given.
+Named volumes are kept.`,
+	RunE: runDepsDown,
+}
+
+var depsRestartCmd = &cobra.Command{
+	Use:   "restart [service...]",
+	Short: "Restart dependency services",
+	RunE:  runDepsRestart,
+}
+
+var depsPsCmd = &cobra.Command{
+	Use:   "ps [service...]",
+	Short: "List dependency containers and their state",
+	RunE:  runDepsPs,
+}
+
+var depsListCmd = &cobra.Command{
+	Use:   "list [service...]",
+	Short: "List services defined in the dependency compose file",
+	Long: `Parses the dependency compose file and lists each service's image, container name,
+published ports, volumes, networks, depends_on conditions and health check.`,
+	RunE: runDepsList,
+}
+
+var depsWaitCmd = &cobra.Command{
+	Use:   "wait [service...]",
+	Short: "Wait until dependency services are ready",
+	Long: `Waits until the given services (or all services) are ready. Services with a compose
+health check are ready once Docker reports them healthy; otherwise the published port is probed
+with a protocol-aware check (Postgres startup handshake, Redis PING, Kafka ApiVersions, or a
+plain TCP connect). Exits non-zero when the timeout expires first.`,
+	RunE: runDepsWait,
+}
+
+func runDepsUp(cmd *cobra.Command, args []string) error {
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Compose also starts the dependencies of the requested services
+	services, err := project.WithDependencies(args)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	if err := ensurePortsFree(cmd, project, services); err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	stack := newStack(cmd, project.Path)
+	output.Info("Starting %s from %s", describeServices(args), stack.File())
+
+	if err := stack.Up(cmd.Context(), args); err != nil {
+		return err
+	}
+
+	output.Success("Started %s", describeServices(args))
+	return nil
+}
+
+func runDepsDown(cmd *cobra.Command, args []string) error {
+	stack, err := newCompose(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	output.Info("Stopping %s from %s", describeServices(args), stack.File())
+
+	if err := stack.Down(cmd.Context(), args); err != nil {
+		return err
+	}
+
+	output.Success("Stopped %s", describeServices(args))
+	return nil
+}
+
+func runDepsRestart(cmd *cobra.Command, args []string) error {
+	stack, err := newCompose(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	output.Info("Restarting %s from %s", describeServices(args), stack.File())
+
+	if err := stack.Restart(cmd.Context(), args); err != nil {
+		return err
+	}
+
+	output.Success("Restarted %s", describeServices(args))
+	return nil
+}
+
+func runDepsPs(cmd *cobra.Command, args []string) error {
+	stack, err := newCompose(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+
+	statuses, err := stack.Ps(cmd.Context(), args)
+	if err != nil {
+		return err
+	}
+
+	if len(statuses) == 0 {
+		output.Warning("No dependency containers found")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(statuses))
+	for _, status := range statuses {
+		health := status.Health
+		if health == "" {
+			health = "-"
+		}
+		rows = append(rows, []string{status.Service, status.Name, status.State, health, status.Ports()})
+	}
+	output.Table([]string{"SERVICE", "CONTAINER", "STATE", "HEALTH", "PORTS"}, rows)
+
+	return nil
+}
+
+func runDepsWait(cmd *cobra.Command, args []string) error {
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := project.SelectServices(args)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	if err := waitForServices(cmd, project, services); err != nil {
+		return err
+	}
+
+	utils.NewCliOutput(false).Success("All %d services are ready", len(services))
+	return nil
+}
+
+// waitForServices waits for services to become ready using the --timeout and --interval flags
+func waitForServices(cmd *cobra.Command, project *compose.Project, services []*compose.Service) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+
+	output := utils.NewCliOutput(false)
+	output.Info("Waiting up to %s for %s", timeout, describeServices(names))
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	defer cancel()
+
+	waiter := docker.NewWaiter(newStack(cmd, project.Path), interval)
+	err := waiter.Wait(ctx, services, func(state docker.ServiceState) {
+		if state.Ready {
+			output.Plain("  ✅ %s ready after %s (%s)", state.Service, state.Elapsed.Round(100*time.Millisecond), state.Detail)
+		} else {
+			output.Plain("  ⏳ %s waiting (%s)", state.Service, state.Detail)
+		}
+	})
+	if err != nil {
+		if cmd.Context().Err() != nil {
+			return newInterruptedError()
+		}
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+
+	return nil
+}
+
+func runDepsList(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := project.SelectServices(args)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(services)
+	}
+	if format != "table" {
+		return newUsageError("unknown format %q (expected table or json)", format)
+	}
+
+	rows := make([][]string, 0, len(services))
+	for _, service := range services {
+		var ports, volumes, deps []string
+		for _, port := range service.PublishedPorts() {
+			ports = append(ports, port.String())
+		}
+		for _, mount := range service.Volumes {
+			volumes = append(volumes, mount.String())
+		}
+		for _, dep := range service.DependsOn {
+			deps = append(deps, fmt.Sprintf("%s (%s)", dep.Service, dep.Condition))
+		}
+
+		rows = append(rows, []string{
+			service.Name,
+			service.Image,
+			orDash(service.ContainerName),
+			orDash(strings.Join(ports, ", ")),
+			orDash(strings.Join(volumes, ", ")),
+			orDash(strings.Join(service.Networks, ", ")),
+			orDash(strings.Join(deps, ", ")),
+			orDash(describeHealthcheck(service.Healthcheck)),
+		})
+	}
+
+	utils.NewCliOutput(false).Table(
+		[]string{"SERVICE", "IMAGE", "CONTAINER", "PORTS", "VOLUMES", "NETWORKS", "DEPENDS ON", "HEALTHCHECK"}, rows)
+	return nil
+}
+
+// newCompose resolves the compose file from --file or the configuration and returns a runner
+// for it. Service names given on the command line are checked against the compose file.
+func newCompose(cmd *cobra.Command, services []string) (*docker.Compose, error) {
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := project.SelectServices(services); err != nil {
+		return nil, newUsageError("%w", err)
+	}
+
+	return newStack(cmd, project.Path), nil
+}
+
+// newStack returns a runner for the compose file. Containers are read through the Docker
+// Engine API when the daemon answers on DOCKER_HOST, and through the docker CLI otherwise,
+// such as for remote contexts and TLS connections.
+func newStack(cmd *cobra.Command, file string) *docker.Compose {
+	stack := docker.NewCompose(file)
+	if client, err := docker.NewAPIClient(); err == nil && client.Ping(cmd.Context()) == nil {
+		return stack.WithEngine(client)
+	}
+	return stack.WithEngine(docker.NewCLIEngine())
+}
+
+// loadComposeProject parses the dependency compose file, with the ports of its override file
+func loadComposeProject(cmd *cobra.Command) (*compose.Project, error) {
+	file, err := composeFile(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := compose.LoadWithOverride(file)
+	if err != nil {
+		return nil, newUsageError("%w", err)
+	}
+
+	return project, nil
+}
+
+// completeServiceNames completes service arguments from the dependency compose file
+func completeServiceNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, name := range project.ServiceNames() {
+		if strings.HasPrefix(name, toComplete) && !containsArg(args, name) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// composeFile returns the dependency compose file from --file or the configuration
+func composeFile(cmd *cobra.Command) (string, error) {
+	file, _ := cmd.Flags().GetString("file")
+
+	if file == "" {
+		cfg, err := depsConfig(cmd)
+		if err != nil {
+			return "", err
+		}
+		file = cfg.ComposeFile
+	}
+
+	if _, err := os.Stat(file); err != nil {
+		return "", newUsageError("compose file %s not found", file)
+	}
+
+	return file, nil
+}
+
+// depsConfig loads the configuration for deps commands without validating repositories.
+// A missing config.json is fine as long as it was not requested explicitly; the defaults
+// are used instead.
+func depsConfig(cmd *cobra.Command) (*config.Config, error) {
+	configFile, _ := cmd.Flags().GetString("config")
+
+	cfg, err := config.LoadFromFile(configFile)
+	switch {
+	case err == nil:
+		return cfg, nil
+	case errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("config"):
+		return &config.Config{ComposeFile: config.DefaultComposeFile, StateDir: config.DefaultStateDir()}, nil
+	default:
+		return nil, newUsageError("failed to load configuration: %w", err)
+	}
+}
+
+// describeHealthcheck summarizes a health check, e.g. "pg_isready -U postgres (every 10s)"
+func describeHealthcheck(healthcheck *compose.Healthcheck) string {
+	test := healthcheck.String()
+	if test == "" || healthcheck.Interval == "" {
+		return test
+	}
+	return fmt.Sprintf("%s (every %s)", test, healthcheck.Interval)
+}
+
+// orDash renders empty table cells as "-"
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// containsArg reports whether value was already given as an argument
+func containsArg(args []string, value string) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+// describeServices names the services a command acts on for progress messages
+func describeServices(services []string) string {
+	if len(services) == 0 {
+		return "all dependency services"
+	}
+	return strings.Join(services, ", ")
+}
+
+func init() {
+	depsCmd.PersistentFlags().StringP("file", "f", "", "Path to the dependency compose file (overrides composeFile in config)")
+
+	depsUpCmd.Flags().Bool("skip-preflight", false, "Start even if published ports are already in use")
+	depsListCmd.Flags().String("format", "table", "Output format: table or json")
+	depsWaitCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for all services")
+	depsWaitCmd.Flags().Duration("interval", time.Second, "How often to check readiness")
+
+	for _, cmd := range []*cobra.Command{depsUpCmd, depsDownCmd, depsRestartCmd, depsPsCmd, depsListCmd, depsWaitCmd} {
+		cmd.ValidArgsFunction = completeServiceNames
+		depsCmd.AddCommand(cmd)
+	}
+	rootCmd.AddCommand(depsCmd)
+}
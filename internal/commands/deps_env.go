@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oddjob23/go-cli/internal/connection"
+	"github.com/oddjob23/go-cli/internal/dotenv"
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var depsEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print or write connection settings for dependency services",
+	Long: `Derives connection settings (DATABASE_URL, MONGO_URI, KAFKA_BROKERS, REDIS_ADDR) from the
+service definitions in the dependency compose file: credentials from the environment and host
+addresses from the published ports.
+
+For a repository, the variables come from its "env" mapping in config.json, which maps
+variable names to services:
+  "env": {"DATABASE_URL": "postgres-auth", "CACHE_ADDR": "redis"}
+Without a mapping the conventional names are used for the services in its "dependsOn", or for
+every service when that is empty too.
+
+With --write the variables are merged into .env.local (see --env-file) of the repository given
+by --repo, or of every repository selected by --only, --exclude and --group. Existing variables
+are updated in place and other lines are kept.`,
+	Args: cobra.NoArgs,
+	RunE: runDepsEnv,
+}
+
+func runDepsEnv(cmd *cobra.Command, args []string) error {
+	repoName, _ := cmd.Flags().GetString("repo")
+	format, _ := cmd.Flags().GetString("format")
+	write, _ := cmd.Flags().GetBool("write")
+	envFile, _ := cmd.Flags().GetString("env-file")
+
+	if format != "dotenv" && format != "shell" && format != "json" {
+		return newUsageError("unknown format %q (expected dotenv, shell or json)", format)
+	}
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	if repoName == "" && !write {
+		return printSettings(connection.Defaults(project.Services), format)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var repos []config.Repository
+	if repoName != "" {
+		repos, err = cfg.SelectRepositories([]string{repoName}, nil, nil)
+		if err != nil {
+			return newUsageError("%w", err)
+		}
+	} else {
+		repos, err = selectRepositories(cmd, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !write {
+		settings, err := repositorySettings(project, repos[0])
+		if err != nil {
+			return err
+		}
+		return printSettings(settings, format)
+	}
+
+	output := utils.NewCliOutput(false)
+	for _, repo := range repos {
+		settings, err := repositorySettings(project, repo)
+		if err != nil {
+			return err
+		}
+
+		variables := make([]dotenv.Variable, 0, len(settings))
+		for _, setting := range settings {
+			variables = append(variables, dotenv.Variable{Name: setting.Name, Value: setting.Value})
+		}
+
+		path := filepath.Join(repo.Path, envFile)
+		result, err := dotenv.Merge(path, variables)
+		if err != nil {
+			return err
+		}
+
+		if result.Changed() {
+			output.Success("%s: %s (%d added, %d updated)", repo.Name, path, result.Added, result.Updated)
+		} else {
+			output.Info("%s: %s already up to date", repo.Name, path)
+		}
+	}
+
+	return nil
+}
+
+// repositorySettings returns the connection settings a repository receives
+func repositorySettings(project *compose.Project, repo config.Repository) ([]connection.Setting, error) {
+	if len(repo.Env) > 0 {
+		settings, err := connection.Mapped(project, repo.Env)
+		if err != nil {
+			return nil, newUsageError("repository %s: %w", repo.Name, err)
+		}
+		return settings, nil
+	}
+
+	if len(repo.DependsOn) == 0 {
+		return connection.Defaults(project.Services), nil
+	}
+
+	services, err := project.SelectServices(repo.DependsOn)
+	if err != nil {
+		return nil, newUsageError("repository %s: %w", repo.Name, err)
+	}
+	return connection.Defaults(services), nil
+}
+
+func printSettings(settings []connection.Setting, format string) error {
+	switch format {
+	case "json":
+		values := make(map[string]string, len(settings))
+		for _, setting := range settings {
+			values[setting.Name] = setting.Value
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+
+	case "shell":
+		output := utils.NewCliOutput(false)
+		for _, setting := range settings {
+			output.Plain("export %s='%s'", setting.Name, strings.ReplaceAll(setting.Value, "'", `'\''`))
+		}
+
+	default:
+		output := utils.NewCliOutput(false)
+		for _, setting := range settings {
+			output.Plain("%s", dotenv.Format(dotenv.Variable{Name: setting.Name, Value: setting.Value}))
+		}
+	}
+	return nil
+}
+
+func init() {
+	depsEnvCmd.Flags().String("repo", "", "Repository whose variable mapping to use")
+	depsEnvCmd.Flags().String("format", "dotenv", "Output format: dotenv, shell or json")
+	depsEnvCmd.Flags().Bool("write", false, "Merge the variables into each repository's env file instead of printing them")
+	depsEnvCmd.Flags().String("env-file", ".env.local", "Env file to write, relative to the repository")
+
+	_ = depsEnvCmd.RegisterFlagCompletionFunc("repo", completeRepositoryNames)
+	_ = depsEnvCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"dotenv", "shell", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	depsCmd.AddCommand(depsEnvCmd)
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/docker"
+	"github.com/oddjob23/go-cli/internal/logs"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// logReorderWindow is how long a line is held back waiting for earlier lines from other services
+const logReorderWindow = 250 * time.Millisecond
+
+var depsLogsCmd = &cobra.Command{
+	Use:   "logs [service...]",
+	Short: "Show merged logs of dependency services",
+	Long: `Shows the logs of the given services (or all services) merged in timestamp order, each
+line prefixed with the service name in a color that stays the same across runs.
+
+Lines can be filtered grep-style with --grep, --ignore-case and --invert-match. With --json
+every line is printed as a JSON object; lines that are JSON themselves (structured logging)
+are parsed, with their level and message lifted into top-level fields.
+
+With --follow new lines are streamed until Ctrl-C.`,
+	RunE: runDepsLogs,
+}
+
+func runDepsLogs(cmd *cobra.Command, args []string) error {
+	follow, _ := cmd.Flags().GetBool("follow")
+	since, _ := cmd.Flags().GetString("since")
+	tail, _ := cmd.Flags().GetString("tail")
+	pattern, _ := cmd.Flags().GetString("grep")
+	ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+	invert, _ := cmd.Flags().GetBool("invert-match")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+
+	filter, err := logs.NewFilter(pattern, ignoreCase, invert)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := project.SelectServices(args)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+
+	// Cancelled as well when writing a line fails, e.g. because stdout was closed
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	stack := newStack(cmd, project.Path)
+	opts := docker.LogOptions{Follow: follow, Since: since, Tail: tail}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(names))
+	streams := make([]<-chan logs.Line, len(names))
+	for i, name := range names {
+		lines := make(chan logs.Line, 256)
+		streams[i] = lines
+
+		wg.Add(1)
+		go func(index int, service string) {
+			defer wg.Done()
+			defer close(lines)
+			errs[index] = stack.StreamLogs(ctx, service, opts, func(raw string) {
+				lines <- logs.ParseTimestamped(service, raw)
+			})
+		}(i, name)
+	}
+
+	formatter := logs.NewFormatter(names, timestamps)
+	encoder := json.NewEncoder(os.Stdout)
+	var writeErr error
+	logs.NewMerger(logReorderWindow).Merge(ctx, streams, func(line logs.Line) {
+		if writeErr != nil || !filter.Match(line) {
+			return
+		}
+		if asJSON {
+			if err := encoder.Encode(logs.ToRecord(line)); err != nil {
+				writeErr = err
+				cancel()
+			}
+			return
+		}
+		fmt.Println(formatter.Format(line))
+	})
+	wg.Wait()
+
+	if writeErr != nil {
+		return fmt.Errorf("failed to write log line: %w", writeErr)
+	}
+
+	// Ctrl-C is the normal way to stop following
+	if ctx.Err() != nil {
+		if follow {
+			return nil
+		}
+		return newInterruptedError()
+	}
+
+	output := utils.NewCliOutput(false)
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			output.Warning("%s: %v", names[i], err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to read logs of %d services", failed)
+	}
+	return nil
+}
+
+func init() {
+	depsLogsCmd.Flags().Bool("follow", false, "Keep streaming new log lines")
+	depsLogsCmd.Flags().String("since", "", "Only show lines since a timestamp or relative time (e.g. 10m)")
+	depsLogsCmd.Flags().String("tail", "all", "Number of lines to show from the end of each service's logs")
+	depsLogsCmd.Flags().StringP("grep", "g", "", "Only show lines matching this regular expression")
+	depsLogsCmd.Flags().BoolP("ignore-case", "i", false, "Match --grep case-insensitively")
+	depsLogsCmd.Flags().BoolP("invert-match", "v", false, "Only show lines not matching --grep")
+	depsLogsCmd.Flags().Bool("json", false, "Print lines as JSON objects, parsing structured log lines")
+	depsLogsCmd.Flags().BoolP("timestamps", "t", false, "Show the time of each line")
+
+	depsLogsCmd.ValidArgsFunction = completeServiceNames
+	depsCmd.AddCommand(depsLogsCmd)
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/oddjob23/go-cli/internal/preflight"
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var depsPreflightCmd = &cobra.Command{
+	Use:   "preflight [service...]",
+	Short: "Check that published ports are free before starting dependencies",
+	Long: `Checks that every host port published by the given services (and the services they
+depend on) can be bound. Taken ports are reported with the process holding them (on Linux) and
+a free replacement port. With --write-override the replacements are written to
+docker-compose.override.yml next to the compose file, which all deps commands pick up.
+
+Services that are already running are skipped since their ports are held by Docker.`,
+	RunE: runDepsPreflight,
+}
+
+func runDepsPreflight(cmd *cobra.Command, args []string) error {
+	writeOverride, _ := cmd.Flags().GetBool("write-override")
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := project.WithDependencies(args)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	output := utils.NewCliOutput(false)
+
+	conflicts := checkPorts(cmd, project, services)
+	if len(conflicts) == 0 {
+		output.Success("All published ports are free")
+		return nil
+	}
+
+	printConflicts(output, conflicts)
+
+	if !writeOverride {
+		output.Info("Run with --write-override to publish the services on the suggested ports")
+		return fmt.Errorf("%d published ports are already in use", len(conflicts))
+	}
+
+	overrides := preflight.OverridePorts(project, conflicts)
+	if len(overrides) == 0 {
+		return fmt.Errorf("no free replacement ports found")
+	}
+
+	path := compose.OverridePath(project.Path)
+	if err := compose.WritePortOverrides(path, overrides); err != nil {
+		return err
+	}
+	output.Success("Wrote remapped ports for %d services to %s", len(overrides), path)
+
+	for _, conflict := range conflicts {
+		if conflict.Suggested == 0 {
+			return fmt.Errorf("port %d of %s has no free replacement", conflict.Port.Published, conflict.Service)
+		}
+	}
+	return nil
+}
+
+// ensurePortsFree runs the port preflight for services about to be started, unless
+// --skip-preflight was given
+func ensurePortsFree(cmd *cobra.Command, project *compose.Project, services []*compose.Service) error {
+	if skip, _ := cmd.Flags().GetBool("skip-preflight"); skip {
+		return nil
+	}
+
+	conflicts := checkPorts(cmd, project, services)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	printConflicts(utils.NewCliOutput(false), conflicts)
+	return fmt.Errorf("%d published ports are already in use; run \"go-cli deps preflight --write-override\" to remap them or pass --skip-preflight", len(conflicts))
+}
+
+// checkPorts returns the port conflicts of services that are not already running
+func checkPorts(cmd *cobra.Command, project *compose.Project, services []*compose.Service) []preflight.Conflict {
+	// Without docker nothing can be running, so every service is checked
+	running := make(map[string]bool)
+	if statuses, err := newStack(cmd, project.Path).Ps(cmd.Context(), nil); err == nil {
+		for _, status := range statuses {
+			if status.State == "running" {
+				running[status.Service] = true
+			}
+		}
+	}
+
+	var pending []*compose.Service
+	for _, service := range services {
+		if !running[service.Name] {
+			pending = append(pending, service)
+		}
+	}
+
+	return preflight.NewPortChecker().Check(project, pending)
+}
+
+func printConflicts(output *utils.CliOutput, conflicts []preflight.Conflict) {
+	for _, conflict := range conflicts {
+		owner := "an unknown process"
+		if conflict.Owner != nil {
+			owner = fmt.Sprintf("%s (pid %d)", conflict.Owner.Name, conflict.Owner.PID)
+		}
+		output.Warning("%s: port %s is in use by %s", conflict.Service, conflict.Port, owner)
+
+		if conflict.Owner != nil && conflict.Owner.Command != "" {
+			output.Plain("     command: %s", conflict.Owner.Command)
+		}
+		if conflict.Suggested != 0 {
+			output.Plain("     suggested: %s", conflict.Override())
+		} else {
+			output.Plain("     no free port found near %d", conflict.Port.Published)
+		}
+	}
+}
+
+func init() {
+	depsPreflightCmd.Flags().Bool("write-override", false, "Write the suggested ports to docker-compose.override.yml")
+
+	depsPreflightCmd.ValidArgsFunction = completeServiceNames
+	depsCmd.AddCommand(depsPreflightCmd)
+}
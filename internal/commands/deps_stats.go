@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var depsStatsCmd = &cobra.Command{
+	Use:   "stats [service...]",
+	Short: "Show CPU and memory usage of running dependency containers",
+	RunE:  runDepsStats,
+}
+
+func runDepsStats(cmd *cobra.Command, args []string) error {
+	stack, err := newCompose(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+
+	samples, err := stack.Stats(cmd.Context(), args)
+	if err != nil {
+		return err
+	}
+
+	if len(samples) == 0 {
+		output.Warning("No running dependency containers found")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(samples))
+	for _, sample := range samples {
+		rows = append(rows, []string{
+			sample.Service,
+			sample.Container,
+			fmt.Sprintf("%.2f%%", sample.CPUPercent),
+			formatSize(int64(sample.MemoryUsage)) + " / " + formatSize(int64(sample.MemoryLimit)),
+			fmt.Sprintf("%.2f%%", sample.MemoryPercent()),
+		})
+	}
+	output.Table([]string{"SERVICE", "CONTAINER", "CPU", "MEMORY", "MEM %"}, rows)
+
+	return nil
+}
+
+func init() {
+	depsStatsCmd.ValidArgsFunction = completeServiceNames
+	depsCmd.AddCommand(depsStatsCmd)
+}
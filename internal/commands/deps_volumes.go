@@ -0,0 +1,431 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/docker"
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var depsResetCmd = &cobra.Command{
+	Use:   "reset <service...>",
+	Short: "Wipe the data volumes of dependency services",
+	Long: `Stops and removes the given services, deletes their named volumes and starts them again
+with empty volumes. Asks for confirmation unless --yes is given.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runDepsReset,
+}
+
+var depsSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and restore the contents of dependency volumes",
+	Long: `Archives the named volumes of the dependency services to tarballs under the state
+directory ("stateDir" in config.json, defaulting to $XDG_STATE_HOME/go-cli), so a seeded
+dataset can be restored in seconds. Services are stopped while their volumes are copied and
+started again afterwards.`,
+}
+
+var depsSnapshotSaveCmd = &cobra.Command{
+	Use:   "save <name> [service...]",
+	Short: "Archive the volumes of the given services (or all services)",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDepsSnapshotSave,
+}
+
+var depsSnapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Replace volume contents with a saved snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDepsSnapshotRestore,
+}
+
+var depsSnapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved snapshots",
+	Args:  cobra.NoArgs,
+	RunE:  runDepsSnapshotList,
+}
+
+func runDepsReset(cmd *cobra.Command, args []string) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := project.SelectServices(args)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	stack := newStack(cmd, project.Path)
+	volumes, err := stack.Volumes(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	keys, err := serviceVolumes(project, services, volumes)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(keys) == 0 {
+		output.Warning("%s has no named volumes to reset", strings.Join(args, ", "))
+		return nil
+	}
+
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, volumes[key].Name)
+	}
+
+	if !yes && !confirm(cmd, "Delete volumes %s? All data in them is lost.", strings.Join(names, ", ")) {
+		output.Warning("Reset cancelled")
+		return nil
+	}
+
+	output.Info("Removing %s", strings.Join(args, ", "))
+	if err := stack.Down(cmd.Context(), args); err != nil {
+		return err
+	}
+
+	output.Info("Deleting volumes %s", strings.Join(names, ", "))
+	if err := stack.RemoveVolumes(cmd.Context(), names); err != nil {
+		return err
+	}
+
+	output.Info("Starting %s", strings.Join(args, ", "))
+	if err := stack.Up(cmd.Context(), args); err != nil {
+		return err
+	}
+
+	output.Success("Reset %s", strings.Join(args, ", "))
+	return nil
+}
+
+func runDepsSnapshotSave(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	name := args[0]
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := project.SelectServices(args[1:])
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	store, err := snapshotStore(cmd, project)
+	if err != nil {
+		return err
+	}
+	dir, err := store.Dir(name)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+	if store.Exists(name) && !force {
+		return newUsageError("snapshot %q already exists (use --force to replace it)", name)
+	}
+
+	stack := newStack(cmd, project.Path)
+	volumes, err := stack.Volumes(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	keys, err := serviceVolumes(project, services, volumes)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return newUsageError("the selected services have no named volumes")
+	}
+
+	// Archive into a staging directory so that an existing snapshot survives a failed save
+	staging, err := store.Stage(name)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	output := utils.NewCliOutput(false)
+	snapshot := &docker.Snapshot{Name: name, Created: time.Now(), ComposeFile: project.Path}
+
+	err = withServicesStopped(cmd.Context(), stack, volumeUsers(project, keys), func() error {
+		for _, key := range keys {
+			output.Info("Archiving %s", volumes[key].Name)
+			archive := key + ".tar.gz"
+			if err := stack.ArchiveVolume(cmd.Context(), volumes[key].Name, filepath.Join(staging, archive)); err != nil {
+				return err
+			}
+			snapshot.Volumes = append(snapshot.Volumes, docker.SnapshotVolume{Key: key, Archive: archive})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := store.Save(snapshot, staging); err != nil {
+		return err
+	}
+
+	output.Success("Saved snapshot %s (%d volumes, %s) to %s", name, len(snapshot.Volumes), formatSize(snapshot.Size()), dir)
+	return nil
+}
+
+func runDepsSnapshotRestore(cmd *cobra.Command, args []string) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+	name := args[0]
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	store, err := snapshotStore(cmd, project)
+	if err != nil {
+		return err
+	}
+	snapshot, err := store.Load(name)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+	dir, _ := store.Dir(name)
+
+	stack := newStack(cmd, project.Path)
+	volumes, err := stack.Volumes(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(snapshot.Volumes))
+	names := make([]string, 0, len(snapshot.Volumes))
+	for _, volume := range snapshot.Volumes {
+		if _, ok := volumes[volume.Key]; !ok {
+			return fmt.Errorf("snapshot %q contains volume %s, which is no longer defined in %s", name, volume.Key, project.Path)
+		}
+		keys = append(keys, volume.Key)
+		names = append(names, volumes[volume.Key].Name)
+	}
+
+	output := utils.NewCliOutput(false)
+	if !yes && !confirm(cmd, "Replace the contents of %s with snapshot %s from %s?",
+		strings.Join(names, ", "), name, snapshot.Created.Format("2006-01-02 15:04")) {
+		output.Warning("Restore cancelled")
+		return nil
+	}
+
+	users := volumeUsers(project, keys)
+
+	// Creating the containers also creates missing volumes with the labels Compose expects
+	if err := stack.Create(cmd.Context(), users); err != nil {
+		return err
+	}
+
+	err = withServicesStopped(cmd.Context(), stack, users, func() error {
+		for _, volume := range snapshot.Volumes {
+			output.Info("Restoring %s", volumes[volume.Key].Name)
+			if err := stack.RestoreVolume(cmd.Context(), volumes[volume.Key].Name, filepath.Join(dir, volume.Archive)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	output.Success("Restored snapshot %s (%d volumes)", name, len(snapshot.Volumes))
+	return nil
+}
+
+func runDepsSnapshotList(cmd *cobra.Command, args []string) error {
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	store, err := snapshotStore(cmd, project)
+	if err != nil {
+		return err
+	}
+	snapshots, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(snapshots) == 0 {
+		output.Warning("No snapshots saved")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		keys := make([]string, 0, len(snapshot.Volumes))
+		for _, volume := range snapshot.Volumes {
+			keys = append(keys, volume.Key)
+		}
+		rows = append(rows, []string{
+			snapshot.Name,
+			snapshot.Created.Format("2006-01-02 15:04"),
+			formatSize(snapshot.Size()),
+			strings.Join(keys, ", "),
+		})
+	}
+	output.Table([]string{"NAME", "CREATED", "SIZE", "VOLUMES"}, rows)
+	return nil
+}
+
+// serviceVolumes returns the keys of the named volumes mounted by services. Volumes shared
+// with other services cannot be removed while those run, so sharing is an error.
+func serviceVolumes(project *compose.Project, services []*compose.Service, volumes map[string]docker.Volume) ([]string, error) {
+	selected := make(map[string]bool, len(services))
+	for _, service := range services {
+		selected[service.Name] = true
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, service := range services {
+		for _, key := range service.NamedVolumes() {
+			volume, ok := volumes[key]
+			if seen[key] || !ok || volume.External {
+				continue
+			}
+			seen[key] = true
+
+			for _, user := range volumeUsers(project, []string{key}) {
+				if !selected[user] {
+					return nil, newUsageError("volume %s is also used by %s; include it as well", key, user)
+				}
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// volumeUsers returns the services mounting any of the volumes
+func volumeUsers(project *compose.Project, keys []string) []string {
+	var users []string
+	for _, service := range project.Services {
+		for _, key := range service.NamedVolumes() {
+			if containsArg(keys, key) {
+				users = append(users, service.Name)
+				break
+			}
+		}
+	}
+	return users
+}
+
+// withServicesStopped stops the running services among services, runs fn and starts them
+// again, even when fn fails
+func withServicesStopped(ctx context.Context, stack *docker.Compose, services []string, fn func() error) error {
+	statuses, err := stack.Ps(ctx, services)
+	if err != nil {
+		return err
+	}
+
+	var running []string
+	for _, status := range statuses {
+		if status.State == "running" && !containsArg(running, status.Service) {
+			running = append(running, status.Service)
+		}
+	}
+
+	if len(running) > 0 {
+		if err := stack.Stop(ctx, running); err != nil {
+			return err
+		}
+	}
+
+	err = fn()
+
+	if len(running) > 0 {
+		// Restart even after cancellation so the stack is not left stopped
+		if startErr := stack.Up(context.WithoutCancel(ctx), running); startErr != nil && err == nil {
+			err = startErr
+		}
+	}
+	return err
+}
+
+// snapshotStore returns the store for snapshots of the compose project
+func snapshotStore(cmd *cobra.Command, project *compose.Project) (*docker.SnapshotStore, error) {
+	cfg, err := depsConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots are kept per project directory so stacks of different checkouts don't mix
+	dir, err := filepath.Abs(filepath.Dir(project.Path))
+	if err != nil {
+		return nil, err
+	}
+	return docker.NewSnapshotStore(filepath.Join(cfg.StateDir, "snapshots", filepath.Base(dir))), nil
+}
+
+// formatSize renders a byte count, e.g. "12.4 MB"
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// completeSnapshotNames completes the names of saved snapshots
+func completeSnapshotNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	store, err := snapshotStore(cmd, project)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshots, _ := store.List()
+
+	var names []string
+	for _, snapshot := range snapshots {
+		if strings.HasPrefix(snapshot.Name, toComplete) {
+			names = append(names, snapshot.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	depsResetCmd.Flags().BoolP("yes", "y", false, "Delete the volumes without asking")
+	depsSnapshotSaveCmd.Flags().Bool("force", false, "Replace an existing snapshot with the same name")
+	depsSnapshotRestoreCmd.Flags().BoolP("yes", "y", false, "Restore without asking")
+
+	depsResetCmd.ValidArgsFunction = completeServiceNames
+	depsSnapshotRestoreCmd.ValidArgsFunction = completeSnapshotNames
+
+	depsSnapshotCmd.AddCommand(depsSnapshotSaveCmd, depsSnapshotRestoreCmd, depsSnapshotListCmd)
+	depsCmd.AddCommand(depsResetCmd, depsSnapshotCmd)
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/oddjob23/go-cli/internal/docker"
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/spf13/cobra"
+)
+
+func TestServiceVolumes(t *testing.T) {
+	project := &compose.Project{
+		Services: []*compose.Service{
+			{Name: "postgres", Volumes: []compose.VolumeMount{
+				{Type: compose.VolumeTypeVolume, Source: "pg_data", Target: "/var/lib/postgresql/data"},
+				{Type: compose.VolumeTypeBind, Source: "./init", Target: "/docker-entrypoint-initdb.d"},
+				{Type: compose.VolumeTypeVolume, Source: "seed", Target: "/seed"},
+			}},
+			{Name: "zookeeper", Volumes: []compose.VolumeMount{{Type: compose.VolumeTypeVolume, Source: "zk_data", Target: "/data"}}},
+			{Name: "kafka", Volumes: []compose.VolumeMount{{Type: compose.VolumeTypeVolume, Source: "zk_data", Target: "/zk"}}},
+		},
+	}
+	volumes := map[string]docker.Volume{
+		"pg_data": {Key: "pg_data", Name: "app_pg_data"},
+		"seed":    {Key: "seed", Name: "seed", External: true},
+		"zk_data": {Key: "zk_data", Name: "app_zk_data"},
+	}
+
+	keys, err := serviceVolumes(project, project.Services[:1], volumes)
+	if err != nil || strings.Join(keys, ",") != "pg_data" {
+		t.Errorf("serviceVolumes(postgres) = %v, %v; want [pg_data] without the external volume", keys, err)
+	}
+
+	if _, err := serviceVolumes(project, project.Services[1:2], volumes); err == nil || !strings.Contains(err.Error(), "also used by kafka") {
+		t.Errorf("serviceVolumes(zookeeper) error = %v, want shared volume error", err)
+	}
+
+	keys, err = serviceVolumes(project, project.Services[1:], volumes)
+	if err != nil || strings.Join(keys, ",") != "zk_data" {
+		t.Errorf("serviceVolumes(zookeeper, kafka) = %v, %v; want [zk_data]", keys, err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:              "512 B",
+		2048:             "2.0 KB",
+		13 * 1024 * 1024: "13.0 MB",
+		3 << 30:          "3.0 GB",
+	}
+	for size, want := range tests {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		var out bytes.Buffer
+		cmd.SetIn(strings.NewReader(tt.input))
+		cmd.SetOut(&out)
+
+		if got := confirm(cmd, "Delete %s?", "volumes"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if !strings.HasPrefix(out.String(), "Delete volumes? [y/N] ") {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
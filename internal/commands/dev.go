@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Prepare the local environment for working on repositories",
+}
+
+var devUpCmd = &cobra.Command{
+	Use:   "up [repository...]",
+	Short: "Start the dependency services the given repositories need",
+	Long: `Starts the compose services listed in "dependsOn" of the given repositories, together
+with the services those depend on, and waits until they are ready. Without arguments the
+repositories chosen by --only, --exclude and --group are used.
+
+Example config.json entry:
+  {"name": "auth-service", "path": "../auth-service", "dependsOn": ["postgres-auth", "redis"]}`,
+	RunE: runDevUp,
+}
+
+func runDevUp(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := devRepositories(cmd, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	project, err := loadComposeProject(cmd)
+	if err != nil {
+		return err
+	}
+
+	services, err := repositoryServices(project, repos)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(services) == 0 {
+		output.Warning("The selected repositories do not depend on any services")
+		return nil
+	}
+
+	if err := ensurePortsFree(cmd, project, services); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+
+	stack := newStack(cmd, project.Path)
+	output.Info("Starting %s from %s", describeServices(names), stack.File())
+	if err := stack.Up(cmd.Context(), names); err != nil {
+		return err
+	}
+
+	if err := waitForServices(cmd, project, services); err != nil {
+		return err
+	}
+
+	output.Success("Dependencies of %d repositories are ready", len(repos))
+	return nil
+}
+
+// devRepositories returns the repositories named as arguments, or the --only, --exclude and
+// --group selection when none are given
+func devRepositories(cmd *cobra.Command, cfg *config.Config, names []string) ([]config.Repository, error) {
+	if len(names) == 0 {
+		return selectRepositories(cmd, cfg)
+	}
+
+	repos, err := cfg.SelectRepositories(names, nil, nil)
+	if err != nil {
+		return nil, newUsageError("%w", err)
+	}
+	return repos, nil
+}
+
+// repositoryServices resolves the services the repositories depend on, including the
+// services those depend on through depends_on in the compose file
+func repositoryServices(project *compose.Project, repos []config.Repository) ([]*compose.Service, error) {
+	for _, repo := range repos {
+		for _, name := range repo.DependsOn {
+			if _, ok := project.Service(name); !ok {
+				return nil, newUsageError("repository %s depends on unknown service %q (available: %s)",
+					repo.Name, name, strings.Join(project.ServiceNames(), ", "))
+			}
+		}
+	}
+
+	names := config.DependencyServices(repos)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	services, err := project.WithDependencies(names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+	return services, nil
+}
+
+func init() {
+	devCmd.PersistentFlags().StringP("file", "f", "", "Path to the dependency compose file (overrides composeFile in config)")
+
+	devUpCmd.Flags().Bool("skip-preflight", false, "Start even if published ports are already in use")
+	devUpCmd.Flags().Duration("timeout", 2*time.Minute, "How long to wait for the services to become ready")
+	devUpCmd.Flags().Duration("interval", time.Second, "How often to check readiness")
+	devUpCmd.ValidArgsFunction = completeRepositoryArgs
+
+	devCmd.AddCommand(devUpCmd)
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+	"github.com/oddjob23/go-cli/pkg/config"
+)
+
+func TestRepositoryServices(t *testing.T) {
+	project := &compose.Project{
+		Services: []*compose.Service{
+			{Name: "postgres-auth"},
+			{Name: "zookeeper"},
+			{Name: "kafka", DependsOn: []compose.Dependency{{Service: "zookeeper", Condition: compose.ConditionHealthy}}},
+			{Name: "mongodb-main"},
+			{Name: "redis"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		repos   []config.Repository
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "should start only the services a repository needs",
+			repos: []config.Repository{{Name: "auth", DependsOn: []string{"redis", "postgres-auth"}}},
+			want:  "postgres-auth,redis",
+		},
+		{
+			name: "should take the union including transitive dependencies",
+			repos: []config.Repository{
+				{Name: "auth", DependsOn: []string{"postgres-auth", "redis"}},
+				{Name: "orders", DependsOn: []string{"kafka", "mongodb-main", "redis"}},
+			},
+			want: "postgres-auth,zookeeper,kafka,mongodb-main,redis",
+		},
+		{
+			name:  "should return nothing for repositories without dependencies",
+			repos: []config.Repository{{Name: "docs"}},
+			want:  "",
+		},
+		{
+			name:    "should reject unknown services",
+			repos:   []config.Repository{{Name: "auth", DependsOn: []string{"mysql"}}},
+			wantErr: `repository auth depends on unknown service "mysql"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, err := repositoryServices(project, tt.repos)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("repositoryServices() error = %v, want %q", err, tt.wantErr)
+				}
+				if exitCode(err) != ExitUsageError {
+					t.Errorf("exitCode() = %d, want %d", exitCode(err), ExitUsageError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("repositoryServices() unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, service := range services {
+				names = append(names, service.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("repositoryServices() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
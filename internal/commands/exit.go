@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes returned by go-cli
+const (
+	ExitOK             = 0   // Every repository was processed successfully
+	ExitPartialFailure = 1   // Some repositories failed
+	ExitUsageError     = 2   // Invalid flags, arguments or configuration
+	ExitAllFailed      = 3   // Every repository failed
+	ExitInterrupted    = 130 // Interrupted by SIGINT/SIGTERM
+)
+
+// ExitError is an error that carries the process exit code for the command that returned it
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// newUsageError wraps a configuration or usage problem
+func newUsageError(format string, args ...interface{}) error {
+	return &ExitError{Code: ExitUsageError, Err: fmt.Errorf(format, args...)}
+}
+
+// usageArgs wraps a positional argument validator so that the errors it reports exit with
+// ExitUsageError
+func usageArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		err := validate(cmd, args)
+		var exitErr *ExitError
+		if err == nil || errors.As(err, &exitErr) {
+			return err
+		}
+		return &ExitError{Code: ExitUsageError, Err: err}
+	}
+}
+
+// markUsageErrors wraps the argument validators of cmd and all of its subcommands with usageArgs
+func markUsageErrors(cmd *cobra.Command) {
+	if cmd.Args != nil {
+		cmd.Args = usageArgs(cmd.Args)
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// wrapUnknownCommand turns the error cobra reports for an unknown subcommand of the root
+// command, before any argument validator runs, into a usage error
+func wrapUnknownCommand(err error) error {
+	var exitErr *ExitError
+	if err == nil || errors.As(err, &exitErr) || !strings.HasPrefix(err.Error(), "unknown command ") {
+		return err
+	}
+	return &ExitError{Code: ExitUsageError, Err: err}
+}
+
+// newInterruptedError reports that the command was cancelled before it could finish
+func newInterruptedError() error {
+	return &ExitError{Code: ExitInterrupted, Err: errors.New("interrupted")}
+}
+
+// newFailureError reports failed repositories, picking the exit code from how many failed
+func newFailureError(failed, total int, action string) error {
+	if failed >= total {
+		return &ExitError{Code: ExitAllFailed, Err: fmt.Errorf("all %d repositories failed to %s", total, action)}
+	}
+	return &ExitError{Code: ExitPartialFailure, Err: fmt.Errorf("%d of %d repositories failed to %s", failed, total, action)}
+}
+
+// exitCode maps an error returned by a command to the process exit code
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitPartialFailure
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "should return success when there is no error",
+			err:  nil,
+			want: ExitOK,
+		},
+		{
+			name: "should return usage code for configuration errors",
+			err:  newUsageError("invalid configuration: %w", errors.New("no repositories configured")),
+			want: ExitUsageError,
+		},
+		{
+			name: "should return partial failure when some repositories failed",
+			err:  newFailureError(2, 5, "sync"),
+			want: ExitPartialFailure,
+		},
+		{
+			name: "should return all failed when every repository failed",
+			err:  newFailureError(5, 5, "sync"),
+			want: ExitAllFailed,
+		},
+		{
+			name: "should return interrupted code when cancelled",
+			err:  newInterruptedError(),
+			want: ExitInterrupted,
+		},
+		{
+			name: "should find exit code through wrapped errors",
+			err:  fmt.Errorf("sync: %w", newUsageError("bad flag")),
+			want: ExitUsageError,
+		},
+		{
+			name: "should treat untyped errors as a general failure",
+			err:  errors.New("something went wrong"),
+			want: ExitPartialFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFailureErrorMessage(t *testing.T) {
+	err := newFailureError(2, 5, "sync")
+	if err.Error() != "2 of 5 repositories failed to sync" {
+		t.Errorf("newFailureError() message = %q", err.Error())
+	}
+
+	err = newFailureError(3, 3, "sync")
+	if err.Error() != "all 3 repositories failed to sync" {
+		t.Errorf("newFailureError() message = %q", err.Error())
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	// newTree builds a root command with a subcommand taking one argument, like "branch create"
+	newTree := func() *cobra.Command {
+		root := &cobra.Command{Use: "go-cli", SilenceUsage: true, SilenceErrors: true}
+		create := &cobra.Command{
+			Use:  "create",
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error { return errors.New("create failed") },
+		}
+		check := &cobra.Command{
+			Use:  "check",
+			Args: func(cmd *cobra.Command, args []string) error { return newUsageError("bad pattern") },
+			RunE: func(cmd *cobra.Command, args []string) error { return nil },
+		}
+		parent := &cobra.Command{Use: "branch"}
+		parent.AddCommand(create, check)
+		root.AddCommand(parent)
+		root.SetOut(io.Discard)
+		markUsageErrors(root)
+		return root
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "should return usage code for a missing argument", args: []string{"branch", "create"}, want: ExitUsageError},
+		{name: "should return usage code for too many arguments", args: []string{"branch", "create", "a", "b"}, want: ExitUsageError},
+		{name: "should keep usage errors returned by a validator", args: []string{"branch", "check"}, want: ExitUsageError},
+		{name: "should return usage code for an unknown command", args: []string{"nosuchcmd"}, want: ExitUsageError},
+		{name: "should keep the exit code of a command that ran", args: []string{"branch", "create", "feature"}, want: ExitPartialFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTree()
+			root.SetArgs(tt.args)
+
+			err := wrapUnknownCommand(root.Execute())
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
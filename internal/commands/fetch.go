@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetch remote references without touching working trees",
+	Long: `Fetches origin (or every remote with --all-remotes) in every selected repository in
+parallel and lists the remote-tracking branches and tags that were created, updated or
+deleted. Nothing is checked out or merged, so it is safe during a rebase or with local changes.`,
+	Args: cobra.NoArgs,
+	RunE: runFetch,
+}
+
+func runFetch(cmd *cobra.Command, args []string) error {
+	opts := git.FetchOptions{}
+	opts.Prune, _ = cmd.Flags().GetBool("prune")
+	opts.Tags, _ = cmd.Flags().GetBool("tags")
+	opts.AllRemotes, _ = cmd.Flags().GetBool("all-remotes")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	output.Info("Fetching %d repositories", len(repos))
+	output.Plain("")
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make([]git.OperationResult, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+
+			result := ops.Fetch(git.Repository{Path: r.Path, Name: r.Name}, opts)
+			results[index] = result
+
+			mu.Lock()
+			defer mu.Unlock()
+			output.Plain("  📂 %s", r.Name)
+			if result.Success {
+				output.Plain("    ✅  %s", result.Message)
+			} else {
+				output.Plain("     ❌ Failed to fetch - %s", result.Message)
+			}
+			printRefUpdates(output, result.RefUpdates)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	fetchResult := git.NewSyncResult(results)
+	if fetchResult.FailureCount == 0 {
+		output.Success("All %d repositories fetched successfully!", fetchResult.SuccessCount)
+		return nil
+	}
+
+	output.Warning("Fetched %d/%d repositories successfully. %d failed.",
+		fetchResult.SuccessCount, fetchResult.TotalRepositories, fetchResult.FailureCount)
+	return newFailureError(fetchResult.FailureCount, fetchResult.TotalRepositories, "fetch")
+}
+
+// printRefUpdates lists the references a fetch changed
+func printRefUpdates(output *utils.CliOutput, updates []git.RefUpdate) {
+	for _, update := range updates {
+		switch update.Kind {
+		case git.RefCreated:
+			output.Plain("       %s %s", utils.Success("+"), update.Ref)
+		case git.RefDeleted:
+			output.Plain("       %s %s", utils.Error("-"), update.Ref)
+		case git.RefRejected:
+			output.Plain("       %s %s %s", utils.Error("!"), update.Ref, utils.Gray("(rejected)"))
+		case git.RefForced:
+			output.Plain("       %s %s %s", utils.Warning("±"), update.Ref, utils.Gray(shortRange(update)+" (forced)"))
+		default:
+			output.Plain("       %s %s %s", utils.Info("~"), update.Ref, utils.Gray(shortRange(update)))
+		}
+	}
+}
+
+// shortRange shows an updated reference as old..new with abbreviated object IDs
+func shortRange(update git.RefUpdate) string {
+	if update.Old == "" || update.New == "" {
+		return ""
+	}
+	abbreviate := func(id string) string {
+		if len(id) > 7 {
+			return id[:7]
+		}
+		return id
+	}
+	return abbreviate(update.Old) + ".." + abbreviate(update.New)
+}
+
+func init() {
+	fetchCmd.Flags().Bool("prune", false, "Delete remote-tracking branches that no longer exist on the remote")
+	fetchCmd.Flags().Bool("tags", false, "Fetch all tags")
+	fetchCmd.Flags().Bool("all-remotes", false, "Fetch every remote instead of only origin")
+	rootCmd.AddCommand(fetchCmd)
+}
@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern> [-- pathspec...]",
+	Short: "Search all repositories with git grep",
+	Long: `Runs git grep in every repository chosen by --only, --exclude and --group in parallel and
+prints the matches with paths prefixed by the repository name, in config order.
+
+The pattern is a POSIX regular expression, as for git grep. Paths after "--" limit the search.
+With --ref a revision such as origin/main is searched instead of the working tree; repositories
+without that revision are reported as failures. Binary files are skipped.
+
+Examples:
+  go-cli grep -w LegacyCheckout
+  go-cli grep -l "/v1/orders" -- '*.go'
+  go-cli grep --count --ref origin/main "TODO" --json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 || cmd.ArgsLenAtDash() == 0 {
+			return newUsageError("a pattern is required")
+		}
+		if dash := cmd.ArgsLenAtDash(); dash > 1 || (dash < 0 && len(args) > 1) {
+			return newUsageError("expected one pattern; put paths after \"--\"")
+		}
+		return nil
+	},
+	RunE: runGrep,
+}
+
+// grepRecord is a match printed with --json
+type grepRecord struct {
+	Repository string `json:"repository"`
+	git.GrepMatch
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	opts := git.GrepOptions{Pattern: args[0], Pathspecs: args[1:]}
+	opts.IgnoreCase, _ = cmd.Flags().GetBool("ignore-case")
+	opts.WordRegexp, _ = cmd.Flags().GetBool("word-regexp")
+	opts.FilesWithMatches, _ = cmd.Flags().GetBool("files-with-matches")
+	opts.Count, _ = cmd.Flags().GetBool("count")
+	opts.Ref, _ = cmd.Flags().GetString("ref")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	if opts.FilesWithMatches && opts.Count {
+		return newUsageError("--files-with-matches and --count cannot be combined")
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	matches := make([][]git.GrepMatch, len(repos))
+	errs := make([]error, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			matches[index], errs[index] = ops.Grep(r.Path, opts)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	pathColor := color.New(color.FgMagenta).SprintFunc()
+	lineColor := color.New(color.FgGreen).SprintFunc()
+	separator := color.New(color.FgCyan).Sprint(":")
+
+	failed, found := 0, 0
+	for i, repo := range repos {
+		if errs[i] != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "❌ %s\n", utils.Error(fmt.Sprintf("%s: %v", repo.Name, errs[i])))
+			continue
+		}
+
+		for _, match := range matches[i] {
+			found++
+			if asJSON {
+				if err := encoder.Encode(grepRecord{Repository: repo.Name, GrepMatch: match}); err != nil {
+					return fmt.Errorf("failed to write match: %w", err)
+				}
+				continue
+			}
+
+			file := pathColor(path.Join(repo.Name, match.Path))
+			switch {
+			case opts.FilesWithMatches:
+				fmt.Println(file)
+			case opts.Count:
+				fmt.Println(file + separator + fmt.Sprint(match.Count))
+			default:
+				fmt.Println(file + separator + lineColor(match.Line) + separator + match.Text)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return newFailureError(failed, len(repos), "search")
+	}
+	if found == 0 && !asJSON {
+		output.Warning("No matches in %d repositories", len(repos))
+	}
+	return nil
+}
+
+func init() {
+	grepCmd.Flags().BoolP("ignore-case", "i", false, "Match case-insensitively")
+	grepCmd.Flags().BoolP("word-regexp", "w", false, "Only match whole words")
+	grepCmd.Flags().BoolP("files-with-matches", "l", false, "Only print the names of matching files")
+	grepCmd.Flags().Bool("count", false, "Print the number of matching lines per file")
+	grepCmd.Flags().String("ref", "", "Search this revision (e.g. origin/main) instead of the working tree")
+	grepCmd.Flags().Bool("json", false, "Print matches as JSON objects, one per line")
+
+	rootCmd.AddCommand(grepCmd)
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete merged and orphaned local branches",
+	Long: `Fetches every selected repository with --prune and lists its local branches that are
+merged into the default branch or whose upstream branch is gone. The branches are deleted after
+confirmation, or right away with --yes.
+
+The default branch, the checked-out branch and branches matching "protectedBranches" in
+config.json (glob patterns such as "release/*") are never deleted.`,
+	RunE: runPrune,
+}
+
+// pruneCandidates are the stale branches found in a repository
+type pruneCandidates struct {
+	repo     config.Repository
+	branches []git.StaleBranch
+	err      error
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	ops := newOperations(cfg)
+	output.Info("Looking for stale branches in %d repositories", len(repos))
+	output.Plain("")
+
+	var wg sync.WaitGroup
+	candidates := make([]pruneCandidates, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			branches, err := ops.FindStaleBranches(git.Repository{Path: r.Path, Name: r.Name}, cfg.GitBranch, cfg.ProtectedBranches)
+			candidates[index] = pruneCandidates{repo: r, branches: branches, err: err}
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	total, failed := 0, 0
+	for _, c := range candidates {
+		switch {
+		case c.err != nil:
+			failed++
+			output.Plain("  📂 %s", c.repo.Name)
+			output.Plain("     ❌ %v", c.err)
+		case len(c.branches) > 0:
+			total += len(c.branches)
+			output.Plain("  📂 %s", c.repo.Name)
+			for _, branch := range c.branches {
+				output.Plain("     • %s %s", branch.Name, utils.Gray("("+branch.Reason+")"))
+			}
+		}
+	}
+
+	if total == 0 {
+		if failed > 0 {
+			return newFailureError(failed, len(repos), "fetch")
+		}
+		output.Success("No stale branches found")
+		return nil
+	}
+
+	output.Plain("")
+	if !yes && !confirm(cmd, "Delete %d branches?", total) {
+		output.Warning("Nothing deleted")
+		if failed > 0 {
+			return newFailureError(failed, len(repos), "fetch")
+		}
+		return nil
+	}
+
+	var results []git.OperationResult
+	for _, c := range candidates {
+		if c.err != nil || len(c.branches) == 0 {
+			continue
+		}
+		result := ops.DeleteBranches(git.Repository{Path: c.repo.Path, Name: c.repo.Name}, c.branches)
+		results = append(results, result)
+		if !result.Success {
+			failed++
+			output.Plain("  ❌ %s: %s", c.repo.Name, result.Message)
+		}
+	}
+
+	deleted := 0
+	for _, result := range results {
+		deleted += len(result.DeletedBranches)
+	}
+
+	if failed > 0 {
+		output.Warning("Deleted %d of %d branches", deleted, total)
+		return newFailureError(failed, len(repos), "prune")
+	}
+
+	output.Success("Deleted %d branches in %d repositories", deleted, len(results))
+	return nil
+}
+
+func init() {
+	pruneCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	rootCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push the current branch wherever it has unpushed commits",
+	Long: `Pushes the checked-out branch of every repository chosen by --only, --exclude and --group
+that is ahead of its upstream branch. Branches without an upstream are skipped unless
+--set-upstream is given, which pushes them to origin under the same name and tracks them.`,
+	Args: cobra.NoArgs,
+	RunE: runPush,
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	setUpstream, _ := cmd.Flags().GetBool("set-upstream")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	output.Info("Pushing %d repositories", len(repos))
+	output.Plain("")
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	results := make([]git.OperationResult, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			results[index] = ops.Push(git.Repository{Path: r.Path, Name: r.Name}, setUpstream)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	// Report in config order once everything is pushed
+	var upToDate []string
+	pushed, commits, skipped, failed := 0, 0, 0, 0
+	for _, result := range results {
+		if result.UpToDate {
+			upToDate = append(upToDate, result.Repository.Name)
+			continue
+		}
+
+		output.Plain("  📂 %s", result.Repository.Name)
+		switch {
+		case result.Success:
+			pushed++
+			commits += result.PushedCommits
+			output.Plain("    ✅  %s", result.Message)
+		case errors.Is(result.Error, git.ErrNoUpstream):
+			skipped++
+			output.Plain("    ⚠️  %s", result.Message)
+		default:
+			failed++
+			output.Plain("     ❌ %s", result.Message)
+		}
+	}
+
+	if len(upToDate) > 0 {
+		if pushed+skipped+failed > 0 {
+			output.Plain("")
+		}
+		output.Plain("%s", utils.Gray("Nothing to push in: "+strings.Join(upToDate, ", ")))
+	}
+
+	output.Plain("")
+	if skipped > 0 {
+		output.Warning("Skipped %d repositories without a remote upstream branch", skipped)
+	}
+	if failed > 0 {
+		output.Warning("Pushed %d of %d repositories", pushed, pushed+failed)
+		return newFailureError(failed, pushed+failed, "push")
+	}
+
+	if pushed == 0 {
+		output.Success("Nothing to push")
+		return nil
+	}
+	output.Success("Pushed %d commits in %d repositories", commits, pushed)
+	return nil
+}
+
+func init() {
+	pushCmd.Flags().BoolP("set-upstream", "u", false, "Push branches without an upstream to origin and track them")
+	rootCmd.AddCommand(pushCmd)
+}
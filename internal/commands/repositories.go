@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// loadConfig loads and validates the configuration named by the --config flag,
+// applying the --branch override when it was given on the command line
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	configFile, _ := cmd.Flags().GetString("config")
+
+	cfg, err := config.LoadFromFile(configFile)
+	if err != nil {
+		return nil, newUsageError("failed to load configuration: %w", err)
+	}
+
+	// Override branch if provided via command line
+	if cmd.Flags().Changed("branch") {
+		cfg.GitBranch, _ = cmd.Flags().GetString("branch")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, newUsageError("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// newOperations creates the git operations, running them through the
+// backend selected by gitBackend in config.json
+func newOperations(cfg *config.Config) *git.Operations {
+	backend, err := git.NewBackend(cfg.GitBackend)
+	if err != nil {
+		// Validate rejects unknown backends, so only configs that were never validated get here
+		backend = git.ExecBackend{}
+	}
+	return git.NewOperationsWithBackend(backend)
+}
+
+// selectRepositories applies the --only, --exclude and --group flags to the configured repositories
+func selectRepositories(cmd *cobra.Command, cfg *config.Config) ([]config.Repository, error) {
+	only, _ := cmd.Flags().GetStringSlice("only")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	groups, _ := cmd.Flags().GetStringSlice("group")
+
+	repos, err := cfg.SelectRepositories(only, exclude, groups)
+	if err != nil {
+		return nil, newUsageError("%w", err)
+	}
+
+	return repos, nil
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func TestLoadConfigBranch(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		gitBranch  string
+		args       []string
+		wantBranch string
+	}{
+		{name: "should default to main", wantBranch: "main"},
+		{name: "should use gitBranch from config.json", gitBranch: "develop", wantBranch: "develop"},
+		{name: "should let --branch override gitBranch", gitBranch: "develop", args: []string{"--branch", "release"}, wantBranch: "release"},
+		{name: "should let an explicit --branch main override gitBranch", gitBranch: "develop", args: []string{"-b", "main"}, wantBranch: "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.json")
+			cfg := config.Config{GitBranch: tt.gitBranch, Repositories: []config.Repository{{Name: "repo", Path: repo}}}
+			data, err := json.Marshal(cfg)
+			if err != nil {
+				t.Fatalf("failed to encode config: %v", err)
+			}
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			// The same flags the root command defines
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().StringP("config", "c", "config.json", "")
+			cmd.Flags().StringP("branch", "b", "main", "")
+			if err := cmd.Flags().Parse(append([]string{"--config", file}, tt.args...)); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			got, err := loadConfig(cmd)
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if got.GitBranch != tt.wantBranch {
+				t.Errorf("loadConfig() GitBranch = %q, want %q", got.GitBranch, tt.wantBranch)
+			}
+		})
+	}
+}
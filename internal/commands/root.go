@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/oddjob23/go-cli/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -11,17 +15,54 @@ var rootCmd = &cobra.Command{
 	Short: "A CLI tool for Git repositories and Docker management",
 	Long: `A CLI application that:
 - Scans directories for Git repositories, checks out main branch, and pulls the latest changes
-- Manages Docker containers and microservices using Docker Compose`,
+- Manages Docker containers and microservices using Docker Compose
+
+Exit codes:
+  0    success
+  1    partial failure (some repositories failed)
+  2    configuration or usage error
+  3    every repository failed
+  130  interrupted`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
-func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+// Execute runs the root command and returns the process exit code
+func Execute() int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	markUsageErrors(rootCmd)
+	err := wrapUnknownCommand(rootCmd.ExecuteContext(ctx))
+	if err == nil {
+		return ExitOK
+	}
+
+	if ctx.Err() != nil {
+		err = newInterruptedError()
 	}
+
+	utils.NewCliOutput(false).Error("%v", err)
+	return exitCode(err)
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringP("config", "c", "config.json", "Path to config.json file")
-	rootCmd.PersistentFlags().StringP("branch", "b", "main", "Git branch to checkout and pull")
+	rootCmd.PersistentFlags().StringP("branch", "b", "main", "Git branch to checkout and pull; overrides gitBranch in config.json when given")
+	rootCmd.PersistentFlags().StringSlice("only", nil, "Only process the named repositories (comma-separated)")
+	rootCmd.PersistentFlags().StringSlice("exclude", nil, "Skip the named repositories (comma-separated)")
+	rootCmd.PersistentFlags().StringSlice("group", nil, "Only process repositories in these groups (comma-separated)")
+
+	// Dynamic completions, backed by the loaded configuration
+	_ = rootCmd.RegisterFlagCompletionFunc("only", completeRepositoryNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("exclude", completeRepositoryNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("group", completeGroupNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("branch", completeBranchNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("config", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &ExitError{Code: ExitUsageError, Err: err}
+	})
 }
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/oddjob23/go-cli/internal/logs"
+	"github.com/oddjob23/go-cli/internal/supervisor"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [repository...]",
+	Short: "Run the services of repositories as local processes",
+	Long: `Starts the service of each given repository (or the repositories chosen by --only,
+--exclude and --group) as described by its "run" section, together with the repositories it
+depends on. A repository starts once its dependencies are ready: their readiness URL answers,
+or their port accepts connections. Output is prefixed with the repository name, crashed
+processes are restarted with a growing delay, and Ctrl-C stops everything.
+
+Example config.json entry:
+  {"name": "orders", "path": "../orders", "run": {
+    "command": "go run ./cmd/server", "port": 8081, "env": {"LOG_LEVEL": "debug"},
+    "readinessUrl": "http://localhost:8081/healthz", "dependsOn": ["auth-service"]}}`,
+	RunE: runRun,
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	readyTimeout, _ := cmd.Flags().GetDuration("ready-timeout")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	names, err := runRepositoryNames(cmd, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(names) == 0 {
+		output.Warning("None of the selected repositories has a run section")
+		return nil
+	}
+
+	repos, err := cfg.WithRunDependencies(names)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	processes := make([]supervisor.Process, 0, len(repos))
+	prefixes := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		processes = append(processes, runProcess(repo))
+		prefixes = append(prefixes, repo.Name)
+	}
+
+	formatter := logs.NewFormatter(prefixes, false)
+	system := color.New(color.Faint)
+	output.Info("Running %d services; press Ctrl-C to stop", len(processes))
+
+	err = supervisor.New(processes, readyTimeout, func(event supervisor.Event) {
+		text := event.Text
+		if event.System {
+			text = system.Sprint(text)
+		}
+		fmt.Println(formatter.Format(logs.Line{Service: event.Process, Text: text}))
+	}).Run(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	output.Success("Stopped %d services", len(processes))
+	return nil
+}
+
+// runRepositoryNames returns the repositories named as arguments, or those with a run section
+// among the --only, --exclude and --group selection when none are given
+func runRepositoryNames(cmd *cobra.Command, cfg *config.Config, args []string) ([]string, error) {
+	if len(args) > 0 {
+		if _, err := cfg.SelectRepositories(args, nil, nil); err != nil {
+			return nil, newUsageError("%w", err)
+		}
+		return args, nil
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, repo := range repos {
+		if repo.Run != nil {
+			names = append(names, repo.Name)
+		}
+	}
+	return names, nil
+}
+
+// runProcess converts the run section of a repository
+func runProcess(repo config.Repository) supervisor.Process {
+	run := repo.Run
+
+	dir := repo.Path
+	if run.WorkingDir != "" {
+		dir = run.WorkingDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(repo.Path, dir)
+		}
+	}
+
+	env := make([]string, 0, len(run.Env))
+	for key, value := range run.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+
+	return supervisor.Process{
+		Name:         repo.Name,
+		Command:      run.Command,
+		Dir:          dir,
+		Env:          env,
+		Port:         run.Port,
+		ReadinessURL: run.ReadinessURL,
+		After:        run.DependsOn,
+	}
+}
+
+func init() {
+	runCmd.Flags().Duration("ready-timeout", 2*time.Minute, "How long dependents wait for a service to become ready before starting anyway")
+	runCmd.ValidArgsFunction = completeRepositoryArgs
+
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,271 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+	"github.com/oddjob23/go-cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Pin and restore the revisions of all repositories",
+	Long: `Records the checked-out branch and commit of every repository in a lockfile, so the same
+workspace can be restored later or on another machine, e.g. while bisecting a bug that spans
+several services. Uncommitted changes are not recorded; repositories that had them are flagged.`,
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save <file>",
+	Short: "Write the branch and HEAD of the selected repositories to a lockfile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotSave,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Check out the commits recorded in a lockfile",
+	Long: `Checks out the recorded commit in every selected repository listed in the lockfile,
+fetching from origin when the commit is missing. The recorded branch is checked out when it
+still points at the commit; otherwise HEAD is detached at the commit, or with --temp-branch
+the commit is checked out on the branch "snapshot/<lockfile name>". Repositories with
+uncommitted changes are left alone and reported as failures.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotRestore,
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff <file-a> <file-b>",
+	Short: "Show which repositories moved between two lockfiles",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runSnapshotDiff,
+}
+
+func runSnapshotSave(cmd *cobra.Command, args []string) error {
+	file := args[0]
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
+		return nil
+	}
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	locked := make([]git.LockedRepository, len(repos))
+	errs := make([]error, len(repos))
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			locked[index], errs[index] = ops.LockRepository(git.Repository{Path: r.Path, Name: r.Name})
+		}(i, repo)
+	}
+	wg.Wait()
+
+	failed := 0
+	var dirty []string
+	for i, repo := range repos {
+		if errs[i] != nil {
+			failed++
+			output.Error("%s: %v", repo.Name, errs[i])
+			continue
+		}
+		if locked[i].Dirty {
+			dirty = append(dirty, repo.Name)
+		}
+	}
+	// A partial lockfile would silently leave repositories out of a later restore
+	if failed > 0 {
+		return newFailureError(failed, len(repos), "read state; lockfile not written")
+	}
+
+	lockfile := git.NewLockfile()
+	lockfile.Repositories = locked
+	if err := lockfile.Save(file); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(locked))
+	for _, repo := range locked {
+		rows = append(rows, []string{repo.Name, formatLockedRevision(&repo), formatDirty(repo.Dirty)})
+	}
+	output.Table([]string{"REPOSITORY", "REVISION", "DIRTY"}, rows)
+	output.Plain("")
+
+	if len(dirty) > 0 {
+		output.Warning("Uncommitted changes are not recorded: %s", strings.Join(dirty, ", "))
+	}
+	output.Success("Saved %d repositories to %s", len(locked), file)
+	return nil
+}
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	file := args[0]
+	useTempBranch, _ := cmd.Flags().GetBool("temp-branch")
+
+	lockfile, err := git.LoadLockfile(file)
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output := utils.NewCliOutput(false)
+
+	var unlocked []string
+	var restore []config.Repository
+	for _, repo := range repos {
+		if lockfile.Find(repo.Name) == nil {
+			unlocked = append(unlocked, repo.Name)
+			continue
+		}
+		restore = append(restore, repo)
+	}
+
+	configured := make(map[string]bool)
+	for _, name := range cfg.RepositoryNames() {
+		configured[name] = true
+	}
+	var unknown []string
+	for _, locked := range lockfile.Repositories {
+		if !configured[locked.Name] {
+			unknown = append(unknown, locked.Name)
+		}
+	}
+	if len(unknown) > 0 {
+		output.Warning("Not in config.json, skipped: %s", strings.Join(unknown, ", "))
+	}
+	if len(unlocked) > 0 {
+		output.Warning("Not in the lockfile, left as they are: %s", strings.Join(unlocked, ", "))
+	}
+	if len(restore) == 0 {
+		output.Warning("No selected repository is in the lockfile")
+		return nil
+	}
+
+	tempBranch := ""
+	if useTempBranch {
+		tempBranch = "snapshot/" + strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	output.Info("Restoring %d repositories from %s (saved %s)", len(restore), file, lockfile.Created.Local().Format("2006-01-02 15:04"))
+	output.Plain("")
+
+	ops := newOperations(cfg)
+	var wg sync.WaitGroup
+	results := make([]git.OperationResult, len(restore))
+	for i, repo := range restore {
+		wg.Add(1)
+		go func(index int, r config.Repository) {
+			defer wg.Done()
+			results[index] = ops.CheckoutLocked(git.Repository{Path: r.Path, Name: r.Name}, *lockfile.Find(r.Name), tempBranch)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
+	failed := 0
+	for i, result := range results {
+		output.Plain("  📂 %s", restore[i].Name)
+		if !result.Success {
+			failed++
+			output.Plain("     ❌ %s", result.Message)
+			continue
+		}
+		output.Plain("    ✅  %s", result.Message)
+		if lockfile.Find(restore[i].Name).Dirty {
+			output.Plain("    ⚠️  Had uncommitted changes when the lockfile was saved")
+		}
+	}
+
+	output.Plain("")
+	if failed > 0 {
+		output.Warning("Restored %d/%d repositories. %d failed.", len(restore)-failed, len(restore), failed)
+		return newFailureError(failed, len(restore), "restore")
+	}
+
+	output.Success("Restored %d repositories", len(restore))
+	return nil
+}
+
+func runSnapshotDiff(cmd *cobra.Command, args []string) error {
+	a, err := git.LoadLockfile(args[0])
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+	b, err := git.LoadLockfile(args[1])
+	if err != nil {
+		return newUsageError("%w", err)
+	}
+
+	output := utils.NewCliOutput(false)
+	changes := git.DiffLockfiles(a, b)
+	if len(changes) == 0 {
+		output.Success("No repository moved")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		rows = append(rows, []string{change.Name, change.Kind, formatLockedRevision(change.From), formatLockedRevision(change.To)})
+	}
+	output.Table([]string{"REPOSITORY", "CHANGE", "FROM", "TO"}, rows)
+	return nil
+}
+
+// formatLockedRevision shows a locked repository as branch@sha
+func formatLockedRevision(repo *git.LockedRepository) string {
+	if repo == nil {
+		return "-"
+	}
+	branch := repo.Branch
+	if branch == "" {
+		branch = "(detached)"
+	}
+	sha := repo.Head
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return branch + "@" + sha
+}
+
+// formatDirty marks repositories that had uncommitted changes
+func formatDirty(dirty bool) string {
+	if dirty {
+		return utils.Warning("yes")
+	}
+	return "no"
+}
+
+func init() {
+	snapshotRestoreCmd.Flags().Bool("temp-branch", false, "Check out commits on a temporary branch instead of a detached HEAD")
+
+	snapshotCmd.AddCommand(snapshotSaveCmd, snapshotRestoreCmd, snapshotDiffCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
@@ -1,11 +1,14 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/internal/notify"
 	"github.com/oddjob23/go-cli/pkg/config"
 	"github.com/oddjob23/go-cli/pkg/utils"
 	"github.com/spf13/cobra"
@@ -15,89 +18,225 @@ var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Sync Git repositories in a directory",
 	Long: `Scans a directory for Git repositories and syncs them by checking out
-the main branch and pulling the latest changes. Processes repositories in parallel.`,
+the main branch and pulling the latest changes. Processes repositories in parallel.
+
+When the local branch has commits origin does not have, sync does not merge. It lists the
+local commits and applies a divergence policy, chosen with --on-diverged or per repository
+with "divergencePolicy" in config.json:
+
+  skip                           Leave the repository alone and report it as failed (default)
+  reset-hard-with-backup-branch  Keep the commits on backup/<branch>-<date>, then reset to origin
+  rebase                         Replay the commits on top of origin, skipping on conflicts
+
+After updating a checked out branch, repositories with a .gitmodules file get their
+submodules initialized and updated, recursively unless --submodules or "submodules" in
+config.json says top-level or off. Repositories whose .gitattributes use the lfs filter get
+"git lfs pull" when git-lfs is installed.`,
 	RunE: runSync,
 }
 
 func runSync(cmd *cobra.Command, args []string) error {
 	// Get flags
-	configFile, _ := cmd.Flags().GetString("config")
-	branch, _ := cmd.Flags().GetString("branch")
-
-	// Load configuration
-	cfg, err := config.LoadFromFile(configFile)
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+	showChanges, _ := cmd.Flags().GetBool("show-changes")
+	maxChanges, _ := cmd.Flags().GetInt("max-changes")
+	keepBranch, _ := cmd.Flags().GetBool("keep-branch")
+	rebaseCurrent, _ := cmd.Flags().GetBool("rebase-current")
+	onDiverged, _ := cmd.Flags().GetString("on-diverged")
+	submodules, _ := cmd.Flags().GetString("submodules")
+	opts := git.SyncOptions{KeepBranch: keepBranch, RebaseCurrent: rebaseCurrent}
+
+	if onDiverged != "" && !slices.Contains(git.DivergencePolicies, onDiverged) {
+		return newUsageError("unknown --on-diverged policy %q (expected one of %s)",
+			onDiverged, strings.Join(git.DivergencePolicies, ", "))
+	}
+	if submodules != "" && !slices.Contains(git.SubmoduleModes, submodules) {
+		return newUsageError("unknown --submodules mode %q (expected one of %s)",
+			submodules, strings.Join(git.SubmoduleModes, ", "))
 	}
 
-	// Override branch if provided via command line
-	if branch != "" {
-		cfg.GitBranch = branch
+	// Load and validate configuration
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
 	}
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+	repos, err := selectRepositories(cmd, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Create output handler
 	output := utils.NewCliOutput(false) // Set to true for verbose mode if needed
 
 	// Create syncer
-	syncer := git.NewSyncer(output)
+	syncer := git.NewSyncer(output, newOperations(cfg))
 
-	output.Info("Starting Git repository sync for %d configured repositories", len(cfg.Repositories))
+	output.Info("Starting Git repository sync for %d configured repositories", len(repos))
 	output.Info("Target branch: %s", cfg.GitBranch)
 
 	// Sync each configured repository in parallel
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	var successCount, failureCount int
+	results := make([]git.OperationResult, len(repos))
 
 	output.Plain("")
 
-	for _, repo := range cfg.Repositories {
+	for i, repo := range repos {
 		wg.Add(1)
-		go func(r config.Repository) {
+		go func(index int, r config.Repository) {
 			defer wg.Done()
 
-			output.Plain("  📂 %s", r.Name)
-			err := syncer.SyncSingleRepository(r.Path, cfg.GitBranch)
+			repoOpts := opts
+			repoOpts.DivergencePolicy = divergencePolicy(onDiverged, r)
+			repoOpts.Submodules = submoduleMode(submodules, r)
+			result := syncer.SyncRepository(git.Repository{Path: r.Path, Name: r.Name}, cfg.GitBranch, repoOpts)
+			results[index] = result
+
+			var changes *git.ChangeSummary
+			if showChanges && result.Success && !result.UpToDate {
+				var err error
+				changes, err = syncer.DescribeChanges(result, maxChanges)
+				if err != nil {
+					output.Debug("Failed to describe changes for %s: %v", r.Name, err)
+				}
+			}
 
 			mu.Lock()
-			if err != nil {
-				output.Plain("     ❌ Failed to sync - %s", err.Error())
-				failureCount++
-			} else {
+			output.Plain("  📂 %s", r.Name)
+			switch {
+			case errors.Is(result.Error, git.ErrDiverged):
+				output.Plain("    ⚠️  %s", result.Message)
+				printDivergence(output, result.Divergence)
+			case !result.Success:
+				output.Plain("     ❌ Failed to sync - %s", result.Error.Error())
+			case opts.KeepBranch || opts.RebaseCurrent:
+				output.Plain("    ✅  %s", result.Message)
+				if changes != nil {
+					printChanges(output, changes)
+				}
+				switch result.RebaseStatus {
+				case git.RebaseDone, git.RebaseUpToDate:
+					output.Plain("    🔁 %s", result.RebaseMessage)
+				case git.RebaseSkipped, git.RebaseConflict:
+					output.Plain("    ⚠️  %s", result.RebaseMessage)
+				}
+			case result.UpToDate:
+				output.Plain("    ✅  Already up to date on %s branch", cfg.GitBranch)
+			default:
 				output.Plain("    ✅  Successfully pulled %s branch", cfg.GitBranch)
-				successCount++
+				if changes != nil {
+					printChanges(output, changes)
+				}
+			}
+			if result.Divergence != nil && result.DivergenceMessage != "" {
+				output.Plain("    🔀 %s", result.DivergenceMessage)
+				printDivergence(output, result.Divergence)
 			}
+			printWorktreeUpdates(output, result)
 			mu.Unlock()
-		}(repo)
+		}(i, repo)
 	}
 
 	// Wait for all repositories to complete
 	wg.Wait()
 
+	if cmd.Context().Err() != nil {
+		return newInterruptedError()
+	}
+
 	// Print final summary
-	if len(cfg.Repositories) == 0 {
-		output.Warning("No repositories configured")
+	if len(repos) == 0 {
+		output.Warning("No repositories selected")
 		return nil
 	}
 
-	if failureCount == 0 {
-		output.Success("All %d repositories synced successfully!", successCount)
+	syncResult := git.NewSyncResult(results)
+	if syncResult.FailureCount == 0 {
+		output.Success("All %d repositories synced successfully!", syncResult.SuccessCount)
 	} else {
 		output.Warning("Synced %d/%d repositories successfully. %d failed.",
-			successCount, len(cfg.Repositories), failureCount)
+			syncResult.SuccessCount, syncResult.TotalRepositories, syncResult.FailureCount)
+	}
+
+	if len(cfg.Webhooks) > 0 {
+		notifier := notify.NewNotifier(cfg.Webhooks)
+		for _, err := range notifier.Notify(cmd.Context(), syncResult) {
+			output.Warning("Webhook notification failed: %v", err)
+		}
+	}
 
-		// Exit with error code if any repositories failed
-		os.Exit(1)
+	if syncResult.FailureCount > 0 {
+		return newFailureError(syncResult.FailureCount, syncResult.TotalRepositories, "sync")
 	}
 
 	return nil
 }
 
+// printChanges lists the incoming commits and the diffstat of a pulled repository
+func printChanges(output *utils.CliOutput, changes *git.ChangeSummary) {
+	for _, commit := range changes.Commits {
+		output.Plain("       • %s %s %s", utils.Gray(commit.SHA), commit.Subject, utils.Gray("("+commit.Author+")"))
+	}
+	if remaining := changes.TotalCommits - len(changes.Commits); remaining > 0 {
+		output.Plain("       … and %d more", remaining)
+	}
+	output.Plain("       %d files changed, %s, %s", changes.FilesChanged,
+		utils.Success(fmt.Sprintf("+%d", changes.Insertions)), utils.Error(fmt.Sprintf("-%d", changes.Deletions)))
+}
+
+// divergencePolicy picks the policy for a repository: the --on-diverged flag, then the
+// repository's configured policy, then skip
+func divergencePolicy(flag string, repo config.Repository) string {
+	if flag != "" {
+		return flag
+	}
+	if repo.DivergencePolicy != "" {
+		return repo.DivergencePolicy
+	}
+	return git.DivergenceSkip
+}
+
+// submoduleMode picks the submodule mode for a repository: the --submodules flag, then the
+// repository's configured mode, then recursive
+func submoduleMode(flag string, repo config.Repository) string {
+	if flag != "" {
+		return flag
+	}
+	if repo.Submodules != "" {
+		return repo.Submodules
+	}
+	return git.SubmodulesRecursive
+}
+
+// printWorktreeUpdates reports the submodule update and LFS pull that followed a sync. Failures
+// are not repeated here: they fail the sync and are printed as its error.
+func printWorktreeUpdates(output *utils.CliOutput, result git.OperationResult) {
+	if result.SubmoduleStatus == git.SubmodulesUpdated {
+		output.Plain("    🧩 %s", result.SubmoduleMessage)
+	}
+	switch result.LFSStatus {
+	case git.LFSPulled:
+		output.Plain("    📦 %s", result.LFSMessage)
+	case git.LFSSkipped:
+		output.Plain("    ⚠️  %s", result.LFSMessage)
+	}
+}
+
+// printDivergence lists the commits that only exist on the local branch
+func printDivergence(output *utils.CliOutput, divergence *git.Divergence) {
+	for _, commit := range divergence.LocalCommits {
+		output.Plain("       • %s %s %s", utils.Gray(commit.SHA), commit.Subject, utils.Gray("("+commit.Author+")"))
+	}
+}
+
 func init() {
+	syncCmd.Flags().Bool("show-changes", false, "List incoming commits and a diffstat for each pulled repository")
+	syncCmd.Flags().Int("max-changes", 10, "Maximum number of incoming commits to list per repository")
+	syncCmd.Flags().Bool("keep-branch", false, "Update the target branch without switching away from the current branch")
+	syncCmd.Flags().Bool("rebase-current", false, "Rebase the current branch onto the updated target branch (implies --keep-branch)")
+	syncCmd.Flags().String("on-diverged", "", "Policy when the branch has local commits: "+strings.Join(git.DivergencePolicies, ", ")+" (default: per repository, else skip)")
+	_ = syncCmd.RegisterFlagCompletionFunc("on-diverged", cobra.FixedCompletions(git.DivergencePolicies, cobra.ShellCompDirectiveNoFileComp))
+	syncCmd.Flags().String("submodules", "", "How to update submodules: "+strings.Join(git.SubmoduleModes, ", ")+" (default: per repository, else recursive)")
+	_ = syncCmd.RegisterFlagCompletionFunc("submodules", cobra.FixedCompletions(git.SubmoduleModes, cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.AddCommand(syncCmd)
 }
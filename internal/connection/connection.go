@@ -0,0 +1,170 @@
+package connection
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// Setting is an environment variable holding the connection string of a service
+type Setting struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Service string `json:"service"`
+}
+
+// Default variable names for each kind of service
+const (
+	DatabaseURL  = "DATABASE_URL"
+	MongoURI     = "MONGO_URI"
+	KafkaBrokers = "KAFKA_BROKERS"
+	RedisAddr    = "REDIS_ADDR"
+)
+
+// ForService derives the connection string of a service from its image, environment and
+// published ports, along with the conventional variable name for it. ok is false for
+// services clients do not connect to directly (e.g. zookeeper) or that publish no ports.
+func ForService(service *compose.Service) (name, value string, ok bool) {
+	image := strings.ToLower(service.Image)
+	env := service.Environment
+
+	switch {
+	case strings.Contains(image, "postgres"):
+		addr, ok := service.PublishedAddress(5432)
+		if !ok {
+			return "", "", false
+		}
+		user := envOr(env, "POSTGRES_USER", "postgres")
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     userInfo(user, env["POSTGRES_PASSWORD"]),
+			Host:     addr,
+			Path:     "/" + envOr(env, "POSTGRES_DB", user),
+			RawQuery: "sslmode=disable",
+		}
+		return DatabaseURL, u.String(), true
+
+	case strings.Contains(image, "mongo"):
+		addr, ok := service.PublishedAddress(27017)
+		if !ok {
+			return "", "", false
+		}
+		u := url.URL{Scheme: "mongodb", Host: addr, Path: "/" + env["MONGO_INITDB_DATABASE"]}
+		if user := env["MONGO_INITDB_ROOT_USERNAME"]; user != "" {
+			// Root users are created in the admin database
+			u.User = userInfo(user, env["MONGO_INITDB_ROOT_PASSWORD"])
+			u.RawQuery = "authSource=admin"
+		}
+		return MongoURI, u.String(), true
+
+	case strings.Contains(image, "kafka"):
+		if addr, ok := advertisedHostListener(env["KAFKA_ADVERTISED_LISTENERS"]); ok {
+			return KafkaBrokers, addr, true
+		}
+		addr, ok := service.PublishedAddress(9092)
+		return KafkaBrokers, addr, ok
+
+	case strings.Contains(image, "redis"):
+		addr, ok := service.PublishedAddress(6379)
+		return RedisAddr, addr, ok
+	}
+
+	return "", "", false
+}
+
+// Defaults returns a setting for every service that has a connection string, named by
+// convention. When several services share a name (two Postgres instances), the later ones
+// are prefixed with the service name, e.g. POSTGRES_AUTH_DATABASE_URL.
+func Defaults(services []*compose.Service) []Setting {
+	var settings []Setting
+	used := make(map[string]bool)
+
+	for _, service := range services {
+		name, value, ok := ForService(service)
+		if !ok {
+			continue
+		}
+		if used[name] {
+			name = qualifiedName(service.Name, name)
+		}
+		used[name] = true
+		settings = append(settings, Setting{Name: name, Value: value, Service: service.Name})
+	}
+
+	return settings
+}
+
+// Mapped returns one setting per variable in mapping, which maps variable names to the
+// service whose connection string they receive. Settings are sorted by name.
+func Mapped(project *compose.Project, mapping map[string]string) ([]Setting, error) {
+	names := make([]string, 0, len(mapping))
+	for name := range mapping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	settings := make([]Setting, 0, len(names))
+	for _, name := range names {
+		service, ok := project.Service(mapping[name])
+		if !ok {
+			return nil, fmt.Errorf("variable %s refers to unknown service %q", name, mapping[name])
+		}
+		_, value, ok := ForService(service)
+		if !ok {
+			return nil, fmt.Errorf("variable %s: no connection string can be derived for service %s", name, service.Name)
+		}
+		settings = append(settings, Setting{Name: name, Value: value, Service: service.Name})
+	}
+
+	return settings, nil
+}
+
+// advertisedHostListener returns the address of the listener advertised to clients on the
+// host, e.g. localhost:9092 from "PLAINTEXT://kafka:29092,PLAINTEXT_HOST://localhost:9092"
+func advertisedHostListener(listeners string) (string, bool) {
+	for _, listener := range strings.Split(listeners, ",") {
+		i := strings.Index(listener, "://")
+		if i < 0 {
+			continue
+		}
+		addr := strings.TrimSpace(listener[i+3:])
+		host, _, err := net.SplitHostPort(addr)
+		if err == nil && (host == "localhost" || host == "127.0.0.1") {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
+// qualifiedName prefixes a variable name with a service name, e.g. POSTGRES_AUTH_DATABASE_URL
+func qualifiedName(service, name string) string {
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, service)
+	return prefix + "_" + name
+}
+
+func userInfo(user, password string) *url.Userinfo {
+	if password == "" {
+		return url.User(user)
+	}
+	return url.UserPassword(user, password)
+}
+
+func envOr(env map[string]string, key, fallback string) string {
+	if value := env[key]; value != "" {
+		return value
+	}
+	return fallback
+}
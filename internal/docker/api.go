@@ -0,0 +1,342 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHost is the daemon address used when DOCKER_HOST is not set
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// pingTimeout bounds how long Ping waits for the daemon to answer
+const pingTimeout = time.Second
+
+// APIClient talks to the Docker Engine API over its unix socket or plain TCP
+type APIClient struct {
+	client  *http.Client
+	baseURL string
+	now     func() time.Time
+}
+
+// NewAPIClient creates a client for DOCKER_HOST, or the default socket when it is not set.
+// TLS connections and docker contexts are left to the CLI and return an error.
+func NewAPIClient() (*APIClient, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		if name := os.Getenv("DOCKER_CONTEXT"); name != "" && name != "default" {
+			return nil, fmt.Errorf("docker context %q is only supported by the docker CLI", name)
+		}
+		host = DefaultHost
+	}
+	if host != DefaultHost && os.Getenv("DOCKER_TLS_VERIFY") != "" {
+		return nil, fmt.Errorf("TLS connections to %s are only supported by the docker CLI", host)
+	}
+	return NewAPIClientForHost(host)
+}
+
+// NewAPIClientForHost creates a client for a daemon address such as unix:///var/run/docker.sock
+// or tcp://127.0.0.1:2375
+func NewAPIClientForHost(host string) (*APIClient, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	baseURL := "http://docker"
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	case "tcp", "http":
+		baseURL = "http://" + u.Host
+	default:
+		return nil, fmt.Errorf("docker host %q is only supported by the docker CLI", host)
+	}
+
+	return &APIClient{
+		client:  &http.Client{Transport: transport},
+		baseURL: baseURL,
+		now:     time.Now,
+	}, nil
+}
+
+// Ping checks that the daemon answers
+func (a *APIClient) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	resp, err := a.get(ctx, "/_ping", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Containers implements Engine
+func (a *APIClient) Containers(ctx context.Context, labels map[string]string) ([]Container, error) {
+	filters := make([]string, 0, len(labels))
+	for key, value := range labels {
+		filters = append(filters, key+"="+value)
+	}
+	sort.Strings(filters)
+
+	encoded, err := json.Marshal(map[string][]string{"label": filters})
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []struct {
+		ID     string            `json:"Id"`
+		Names  []string          `json:"Names"`
+		Image  string            `json:"Image"`
+		State  string            `json:"State"`
+		Status string            `json:"Status"`
+		Labels map[string]string `json:"Labels"`
+		Ports  []struct {
+			IP          string `json:"IP"`
+			PrivatePort int    `json:"PrivatePort"`
+			PublicPort  int    `json:"PublicPort"`
+			Type        string `json:"Type"`
+		} `json:"Ports"`
+	}
+	query := url.Values{"all": {"1"}, "filters": {string(encoded)}}
+	if err := a.getJSON(ctx, "/containers/json", query, &summaries); err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(summaries))
+	for _, s := range summaries {
+		c := Container{
+			ID:     s.ID,
+			Image:  s.Image,
+			State:  s.State,
+			Status: s.Status,
+			Health: healthFromStatus(s.Status),
+			Labels: s.Labels,
+		}
+		if len(s.Names) > 0 {
+			c.Name = strings.TrimPrefix(s.Names[0], "/")
+		}
+		for _, p := range s.Ports {
+			c.Ports = append(c.Ports, Publisher{URL: p.IP, TargetPort: p.PrivatePort, PublishedPort: p.PublicPort, Protocol: p.Type})
+		}
+		sortPublishers(c.Ports)
+		containers = append(containers, c)
+	}
+
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+	return containers, nil
+}
+
+// Inspect implements Engine
+func (a *APIClient) Inspect(ctx context.Context, id string) (*Container, error) {
+	var response inspectResponse
+	if err := a.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.container(), nil
+}
+
+// Logs implements Engine
+func (a *APIClient) Logs(ctx context.Context, id string, opts LogOptions, fn func(line string)) error {
+	// Without a TTY the daemon multiplexes stdout and stderr into framed chunks
+	container, err := a.Inspect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}, "timestamps": {"1"}}
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+	if opts.Since != "" {
+		since, err := sinceParameter(opts.Since, a.now())
+		if err != nil {
+			return err
+		}
+		query.Set("since", since)
+	}
+	if opts.Tail != "" {
+		query.Set("tail", opts.Tail)
+	}
+
+	resp, err := a.get(ctx, "/containers/"+url.PathEscape(id)+"/logs", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	if !container.Tty {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(demultiplex(writer, resp.Body))
+		}()
+		defer reader.Close()
+		body = reader
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read logs of %s: %w", id, err)
+	}
+	return nil
+}
+
+// Stats implements Engine
+func (a *APIClient) Stats(ctx context.Context, id string) (*Stats, error) {
+	var response statsResponse
+	// With stream=false the daemon takes two samples, so the previous CPU usage is filled in
+	query := url.Values{"stream": {"false"}}
+	if err := a.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/stats", query, &response); err != nil {
+		return nil, err
+	}
+	return response.stats(), nil
+}
+
+// statsResponse is the subset of the stats document Stats uses
+type statsResponse struct {
+	CPUStats    cpuStats `json:"cpu_stats"`
+	PreCPUStats cpuStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+type cpuStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+// stats computes usage the way `docker stats` does
+func (r *statsResponse) stats() *Stats {
+	stats := &Stats{MemoryLimit: r.MemoryStats.Limit, MemoryUsage: r.MemoryStats.Usage}
+
+	// The page cache is reclaimable, so it is not counted (cgroup v1 and v2 names)
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if cache, ok := r.MemoryStats.Stats[key]; ok && cache < stats.MemoryUsage {
+			stats.MemoryUsage -= cache
+			break
+		}
+	}
+
+	cpus := float64(r.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(r.CPUStats.CPUUsage.PercpuUsage))
+	}
+	cpuDelta := float64(r.CPUStats.CPUUsage.TotalUsage) - float64(r.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(r.CPUStats.SystemUsage) - float64(r.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	return stats
+}
+
+// get performs a GET request and checks the response status
+func (a *APIClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	target := a.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker API request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("docker API %s: %s", path, apiErr.Message)
+		}
+		return nil, fmt.Errorf("docker API %s: unexpected response status %s", path, resp.Status)
+	}
+	return resp, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into v
+func (a *APIClient) getJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	resp, err := a.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse docker API %s response: %w", path, err)
+	}
+	return nil
+}
+
+// demultiplex copies the payloads of a multiplexed log stream, where every chunk is preceded
+// by an 8-byte header holding the stream type and the big-endian payload size
+func demultiplex(dst io.Writer, src io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(src, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(dst, src, size); err != nil {
+			return err
+		}
+	}
+}
+
+// sinceParameter converts a --since value to the unix timestamp the API expects. Durations
+// are relative to now; timestamps and plain unix times are accepted as well.
+func sinceParameter(since string, now time.Time) (string, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return strconv.FormatInt(now.Add(-d).Unix(), 10), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, since, time.Local); err == nil {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+	}
+	if _, err := strconv.ParseFloat(since, 64); err == nil {
+		return since, nil
+	}
+	return "", fmt.Errorf("invalid --since value %q", since)
+}
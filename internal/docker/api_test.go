@@ -0,0 +1,337 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const inspectDocument = `{
+	"Id": "abc123",
+	"Name": "/deps-redis-1",
+	"Config": {"Image": "redis:7", "Labels": {"com.docker.compose.service": "redis"}, "Tty": false},
+	"State": {"Status": "running", "ExitCode": 0, "StartedAt": "2024-05-01T12:00:00Z", "Health": {"Status": "healthy"}},
+	"RestartCount": 2,
+	"NetworkSettings": {"Ports": {
+		"6379/tcp": [{"HostIp": "0.0.0.0", "HostPort": "6380"}],
+		"16379/tcp": null
+	}}
+}`
+
+func TestAPIClientContainers(t *testing.T) {
+	var gotFilters string
+	client := newFakeAPI(t, map[string]http.HandlerFunc{
+		"/containers/json": func(w http.ResponseWriter, r *http.Request) {
+			gotFilters = r.URL.Query().Get("filters")
+			w.Write([]byte(`[
+				{"Id": "def456", "Names": ["/deps-redis-1"], "Image": "redis:7", "State": "running",
+				 "Status": "Up 5 minutes (healthy)", "Labels": {"com.docker.compose.service": "redis"},
+				 "Ports": [{"IP": "0.0.0.0", "PrivatePort": 6379, "PublicPort": 6380, "Type": "tcp"}]},
+				{"Id": "abc123", "Names": ["/deps-kafka-1"], "Image": "kafka", "State": "exited",
+				 "Status": "Exited (1) 2 minutes ago", "Labels": {"com.docker.compose.service": "kafka"}}
+			]`))
+		},
+	})
+
+	containers, err := client.Containers(context.Background(), map[string]string{labelWorkingDir: "/deps", labelOneOff: "False"})
+	if err != nil {
+		t.Fatalf("Containers() unexpected error: %v", err)
+	}
+
+	if want := `{"label":["com.docker.compose.oneoff=False","com.docker.compose.project.working_dir=/deps"]}`; gotFilters != want {
+		t.Errorf("filters = %s, want %s", gotFilters, want)
+	}
+
+	want := []Container{
+		{ID: "abc123", Name: "deps-kafka-1", Image: "kafka", State: "exited", Status: "Exited (1) 2 minutes ago",
+			Labels: map[string]string{labelService: "kafka"}},
+		{ID: "def456", Name: "deps-redis-1", Image: "redis:7", State: "running", Status: "Up 5 minutes (healthy)", Health: "healthy",
+			Labels: map[string]string{labelService: "redis"},
+			Ports:  []Publisher{{URL: "0.0.0.0", TargetPort: 6379, PublishedPort: 6380, Protocol: "tcp"}}},
+	}
+	if !reflect.DeepEqual(containers, want) {
+		t.Errorf("Containers() = %+v, want %+v", containers, want)
+	}
+}
+
+func TestAPIClientInspect(t *testing.T) {
+	client := newFakeAPI(t, map[string]http.HandlerFunc{
+		"/containers/abc123/json": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(inspectDocument))
+		},
+		"/containers/missing/json": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No such container: missing"}`))
+		},
+	})
+
+	container, err := client.Inspect(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Inspect() unexpected error: %v", err)
+	}
+	assertInspected(t, container)
+
+	_, err = client.Inspect(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "No such container: missing") {
+		t.Errorf("Inspect() error = %v, want the daemon's message", err)
+	}
+}
+
+func TestAPIClientLogs(t *testing.T) {
+	var gotQuery map[string][]string
+	client := newFakeAPI(t, map[string]http.HandlerFunc{
+		"/containers/abc123/json": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(inspectDocument))
+		},
+		"/containers/abc123/logs": func(w http.ResponseWriter, r *http.Request) {
+			gotQuery = r.URL.Query()
+			// A line split across two frames, followed by a line on stderr
+			w.Write(frame(1, "2024-05-01T12:00:00.000000000Z Ready to "))
+			w.Write(frame(1, "accept connections\n"))
+			w.Write(frame(2, "2024-05-01T12:00:01.000000000Z warning\n"))
+		},
+	})
+	client.now = func() time.Time { return time.Unix(1000, 0) }
+
+	var lines []string
+	err := client.Logs(context.Background(), "abc123", LogOptions{Follow: true, Since: "10s", Tail: "50"}, func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("Logs() unexpected error: %v", err)
+	}
+
+	wantLines := []string{
+		"2024-05-01T12:00:00.000000000Z Ready to accept connections",
+		"2024-05-01T12:00:01.000000000Z warning",
+	}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("Logs() lines = %q, want %q", lines, wantLines)
+	}
+
+	wantQuery := map[string][]string{
+		"stdout": {"1"}, "stderr": {"1"}, "timestamps": {"1"}, "follow": {"1"}, "since": {"990"}, "tail": {"50"},
+	}
+	if !reflect.DeepEqual(gotQuery, wantQuery) {
+		t.Errorf("logs query = %v, want %v", gotQuery, wantQuery)
+	}
+}
+
+func TestAPIClientStats(t *testing.T) {
+	client := newFakeAPI(t, map[string]http.HandlerFunc{
+		"/containers/abc123/stats": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("stream") != "false" {
+				t.Errorf("stats requested without stream=false")
+			}
+			w.Write([]byte(`{
+				"cpu_stats": {"cpu_usage": {"total_usage": 300}, "system_cpu_usage": 2000, "online_cpus": 2},
+				"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000},
+				"memory_stats": {"usage": 3000, "limit": 10000, "stats": {"inactive_file": 1000}}
+			}`))
+		},
+	})
+
+	stats, err := client.Stats(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Stats() unexpected error: %v", err)
+	}
+
+	want := &Stats{CPUPercent: 40, MemoryUsage: 2000, MemoryLimit: 10000}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+	if stats.MemoryPercent() != 20 {
+		t.Errorf("MemoryPercent() = %v, want 20", stats.MemoryPercent())
+	}
+}
+
+func TestNewAPIClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		context string
+		tls     string
+		wantErr bool
+	}{
+		{name: "should use the default socket", host: ""},
+		{name: "should accept a unix socket", host: "unix:///run/user/1000/docker.sock"},
+		{name: "should accept plain tcp", host: "tcp://127.0.0.1:2375"},
+		{name: "should leave ssh to the CLI", host: "ssh://user@host", wantErr: true},
+		{name: "should leave TLS to the CLI", host: "tcp://127.0.0.1:2376", tls: "1", wantErr: true},
+		{name: "should leave contexts to the CLI", host: "", context: "remote", wantErr: true},
+		{name: "should ignore the default context", host: "", context: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_HOST", tt.host)
+			t.Setenv("DOCKER_CONTEXT", tt.context)
+			t.Setenv("DOCKER_TLS_VERIFY", tt.tls)
+
+			_, err := NewAPIClient()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewAPIClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIClientPing(t *testing.T) {
+	client := newFakeAPI(t, map[string]http.HandlerFunc{
+		"/_ping": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("OK"))
+		},
+	})
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() unexpected error: %v", err)
+	}
+
+	unreachable, err := NewAPIClientForHost("unix://" + filepath.Join(t.TempDir(), "missing.sock"))
+	if err != nil {
+		t.Fatalf("NewAPIClientForHost() unexpected error: %v", err)
+	}
+	if err := unreachable.Ping(context.Background()); err == nil {
+		t.Errorf("Ping() expected error for a missing socket, got nil")
+	}
+}
+
+func TestComposeUsesEngine(t *testing.T) {
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotFilters string
+	client := newFakeAPI(t, map[string]http.HandlerFunc{
+		"/containers/json": func(w http.ResponseWriter, r *http.Request) {
+			gotFilters = r.URL.Query().Get("filters")
+			w.Write([]byte(`[
+				{"Id": "abc123", "Names": ["/deps-redis-1"], "State": "running", "Status": "Up (healthy)",
+				 "Labels": {"com.docker.compose.service": "redis"}},
+				{"Id": "def456", "Names": ["/deps-kafka-1"], "State": "running", "Status": "Up",
+				 "Labels": {"com.docker.compose.service": "kafka"}}
+			]`))
+		},
+	})
+
+	statuses, err := newTestCompose().WithEngine(client).Ps(context.Background(), []string{"redis"})
+	if err != nil {
+		t.Fatalf("Ps() unexpected error: %v", err)
+	}
+
+	want := []ServiceStatus{{Name: "deps-redis-1", Service: "redis", State: "running", Health: "healthy", Status: "Up (healthy)"}}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("Ps() = %+v, want %+v", statuses, want)
+	}
+
+	var filters map[string][]string
+	if err := json.Unmarshal([]byte(gotFilters), &filters); err != nil {
+		t.Fatalf("invalid filters %q: %v", gotFilters, err)
+	}
+	if label := labelWorkingDir + "=" + dir; filters["label"][1] != label {
+		t.Errorf("filters = %v, want the project directory label %s", filters, label)
+	}
+}
+
+func TestSinceParameter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		since   string
+		want    string
+		wantErr bool
+	}{
+		{name: "should subtract durations from now", since: "10m", want: "1714564200"},
+		{name: "should convert RFC 3339 timestamps", since: "2024-05-01T11:00:00Z", want: "1714561200"},
+		{name: "should pass unix timestamps through", since: "1714561200.5", want: "1714561200.5"},
+		{name: "should reject anything else", since: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sinceParameter(tt.since, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sinceParameter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("sinceParameter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Helper functions
+
+// newFakeAPI serves the handlers on a unix socket and returns a client connected to it
+// through DOCKER_HOST
+func newFakeAPI(t *testing.T, handlers map[string]http.HandlerFunc) *APIClient {
+	t.Helper()
+
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "docker")
+	if err != nil {
+		t.Fatalf("failed to create socket directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", socket, err)
+	}
+
+	mux := http.NewServeMux()
+	for path, handler := range handlers {
+		mux.HandleFunc(path, handler)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+	client, err := NewAPIClient()
+	if err != nil {
+		t.Fatalf("NewAPIClient() unexpected error: %v", err)
+	}
+	return client
+}
+
+// frame encodes a chunk of a multiplexed log stream
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+// assertInspected checks a container decoded from inspectDocument
+func assertInspected(t *testing.T, container *Container) {
+	t.Helper()
+
+	want := &Container{
+		ID:           "abc123",
+		Name:         "deps-redis-1",
+		Image:        "redis:7",
+		State:        "running",
+		Health:       "healthy",
+		Labels:       map[string]string{labelService: "redis"},
+		StartedAt:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		RestartCount: 2,
+		Ports: []Publisher{
+			{URL: "0.0.0.0", TargetPort: 6379, PublishedPort: 6380, Protocol: "tcp"},
+			{TargetPort: 16379, Protocol: "tcp"},
+		},
+	}
+	if !reflect.DeepEqual(container, want) {
+		t.Errorf("container = %+v, want %+v", container, want)
+	}
+}
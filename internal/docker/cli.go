@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CLIEngine implements Engine with the docker binary. It is the fallback for daemons the
+// API client cannot reach, such as remote contexts and TLS connections.
+type CLIEngine struct {
+	binary string
+}
+
+// NewCLIEngine creates a new CLIEngine using the docker binary on PATH
+func NewCLIEngine() *CLIEngine {
+	return &CLIEngine{binary: "docker"}
+}
+
+// Containers implements Engine
+func (e *CLIEngine) Containers(ctx context.Context, labels map[string]string) ([]Container, error) {
+	args := []string{"ps", "--all", "--no-trunc", "--quiet"}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--filter", "label="+key+"="+labels[key])
+	}
+
+	output, err := e.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	responses, err := e.inspect(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(responses))
+	for _, response := range responses {
+		containers = append(containers, *response.container())
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+	return containers, nil
+}
+
+// Inspect implements Engine
+func (e *CLIEngine) Inspect(ctx context.Context, id string) (*Container, error) {
+	responses, err := e.inspect(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no such container: %s", id)
+	}
+	return responses[0].container(), nil
+}
+
+// Logs implements Engine
+func (e *CLIEngine) Logs(ctx context.Context, id string, opts LogOptions, fn func(line string)) error {
+	args := append([]string{"logs", "--timestamps"}, opts.args()...)
+	cmd := exec.CommandContext(ctx, e.binary, append(args, id)...)
+	if err := streamLines(ctx, cmd, fn); err != nil {
+		return fmt.Errorf("docker logs %s failed: %w", id, err)
+	}
+	return nil
+}
+
+// Stats implements Engine
+func (e *CLIEngine) Stats(ctx context.Context, id string) (*Stats, error) {
+	output, err := e.output(ctx, "stats", "--no-stream", "--no-trunc", "--format", "{{json .}}", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sample struct {
+		CPUPerc  string `json:"CPUPerc"`
+		MemUsage string `json:"MemUsage"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(output), &sample); err != nil {
+		return nil, fmt.Errorf("failed to parse docker stats output: %w", err)
+	}
+
+	stats := &Stats{}
+	if cpu := strings.TrimSuffix(sample.CPUPerc, "%"); cpu != "" && cpu != "--" {
+		if stats.CPUPercent, err = strconv.ParseFloat(cpu, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse docker stats output: invalid CPU usage %q", sample.CPUPerc)
+		}
+	}
+	if usage, limit, ok := strings.Cut(sample.MemUsage, "/"); ok {
+		if stats.MemoryUsage, err = parseSize(usage); err != nil {
+			return nil, fmt.Errorf("failed to parse docker stats output: %w", err)
+		}
+		if stats.MemoryLimit, err = parseSize(limit); err != nil {
+			return nil, fmt.Errorf("failed to parse docker stats output: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// inspect runs `docker inspect` for the given containers
+func (e *CLIEngine) inspect(ctx context.Context, ids []string) ([]inspectResponse, error) {
+	output, err := e.output(ctx, append([]string{"inspect", "--type", "container"}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []inspectResponse
+	if err := json.Unmarshal(output, &responses); err != nil {
+		return nil, fmt.Errorf("failed to parse docker inspect output: %w", err)
+	}
+	return responses, nil
+}
+
+// output runs a docker subcommand and returns its standard output
+func (e *CLIEngine) output(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.binary, args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("docker %s failed: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("docker %s failed: %w", args[0], err)
+	}
+	return output, nil
+}
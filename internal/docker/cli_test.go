@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCLIEngineContainers(t *testing.T) {
+	logFile := installFakeDockerOutputs(t, map[string]string{
+		"ps":      "abc123\n",
+		"inspect": "[" + inspectDocument + "]",
+	}, 0)
+
+	containers, err := NewCLIEngine().Containers(context.Background(), map[string]string{labelWorkingDir: "/deps"})
+	if err != nil {
+		t.Fatalf("Containers() unexpected error: %v", err)
+	}
+	if len(containers) != 1 {
+		t.Fatalf("Containers() returned %d containers, want 1", len(containers))
+	}
+	assertInspected(t, &containers[0])
+
+	want := []string{
+		"ps --all --no-trunc --quiet --filter label=com.docker.compose.project.working_dir=/deps",
+		"inspect --type container abc123",
+	}
+	if got := readInvocations(t, logFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("docker invocations = %q, want %q", got, want)
+	}
+}
+
+func TestCLIEngineContainersWithoutMatches(t *testing.T) {
+	logFile := installFakeDockerOutputs(t, map[string]string{"ps": ""}, 0)
+
+	containers, err := NewCLIEngine().Containers(context.Background(), nil)
+	if err != nil || containers != nil {
+		t.Errorf("Containers() = %v, %v, want nothing", containers, err)
+	}
+	if got := readInvocations(t, logFile); len(got) != 1 {
+		t.Errorf("docker invocations = %q, want only ps", got)
+	}
+}
+
+func TestCLIEngineLogs(t *testing.T) {
+	logFile := installFakeDockerOutputs(t, map[string]string{
+		"logs": "2024-05-01T12:00:00.000000000Z first\n",
+	}, 0)
+
+	var lines []string
+	err := NewCLIEngine().Logs(context.Background(), "abc123", LogOptions{Since: "10m", Tail: "all"}, func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("Logs() unexpected error: %v", err)
+	}
+	if len(lines) != 1 || lines[0] != "2024-05-01T12:00:00.000000000Z first" {
+		t.Errorf("Logs() lines = %q", lines)
+	}
+
+	want := "logs --timestamps --since 10m --tail all abc123"
+	if got := readInvocations(t, logFile); len(got) != 1 || got[0] != want {
+		t.Errorf("docker invocations = %q, want %q", got, want)
+	}
+}
+
+func TestCLIEngineStats(t *testing.T) {
+	installFakeDockerOutputs(t, map[string]string{
+		"stats": `{"CPUPerc":"12.50%","MemUsage":"512MiB / 2GiB","MemPerc":"25.00%"}`,
+	}, 0)
+
+	stats, err := NewCLIEngine().Stats(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Stats() unexpected error: %v", err)
+	}
+
+	want := &Stats{CPUPercent: 12.5, MemoryUsage: 512 << 20, MemoryLimit: 2 << 30}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestCLIEngineFailure(t *testing.T) {
+	installFakeDocker(t, "", 1)
+
+	if _, err := NewCLIEngine().Inspect(context.Background(), "abc123"); err == nil {
+		t.Errorf("Inspect() expected error, got nil")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{value: "0B", want: 0},
+		{value: "10.5KiB", want: 10752},
+		{value: "1.5GB", want: 1500000000},
+		{value: " 512MiB ", want: 512 << 20},
+		{value: "12 parsecs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// ServiceStatus describes a compose service container as reported by `docker compose ps`
+type ServiceStatus struct {
+	Name       string      `json:"Name"`
+	Service    string      `json:"Service"`
+	State      string      `json:"State"`
+	Health     string      `json:"Health"`
+	Status     string      `json:"Status"`
+	Publishers []Publisher `json:"Publishers"`
+}
+
+// Publisher describes a port published by a service container
+type Publisher struct {
+	URL           string `json:"URL"`
+	TargetPort    int    `json:"TargetPort"`
+	PublishedPort int    `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+// Compose drives `docker compose` for a single compose file. The override file next to it
+// (see compose.OverridePath) is passed along when it exists. With an Engine attached,
+// containers are listed and read through it instead of the compose CLI.
+type Compose struct {
+	file   string
+	binary string
+	engine Engine
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewCompose creates a new Compose instance for the given compose file
+func NewCompose(file string) *Compose {
+	return &Compose{
+		file:   file,
+		binary: "docker",
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+}
+
+// WithEngine makes Ps, StreamLogs and Stats use the given engine
+func (c *Compose) WithEngine(engine Engine) *Compose {
+	c.engine = engine
+	return c
+}
+
+// File returns the compose file this instance operates on
+func (c *Compose) File() string {
+	return c.file
+}
+
+// Up creates and starts the given services in the background, or every service when none are given
+func (c *Compose) Up(ctx context.Context, services []string) error {
+	return c.stream(ctx, append([]string{"up", "--detach"}, services...)...)
+}
+
+// Down stops and removes the given services. Without services the whole stack is taken down,
+// including its network; named volumes are always kept.
+func (c *Compose) Down(ctx context.Context, services []string) error {
+	if len(services) == 0 {
+		return c.stream(ctx, "down")
+	}
+
+	if err := c.stream(ctx, append([]string{"stop"}, services...)...); err != nil {
+		return err
+	}
+	return c.stream(ctx, append([]string{"rm", "--force"}, services...)...)
+}
+
+// Stop stops the given services, or every service when none are given, keeping their containers
+func (c *Compose) Stop(ctx context.Context, services []string) error {
+	return c.stream(ctx, append([]string{"stop"}, services...)...)
+}
+
+// Create creates the containers and volumes of the given services without starting them
+func (c *Compose) Create(ctx context.Context, services []string) error {
+	return c.stream(ctx, append([]string{"create"}, services...)...)
+}
+
+// Restart restarts the given services, or every service when none are given
+func (c *Compose) Restart(ctx context.Context, services []string) error {
+	return c.stream(ctx, append([]string{"restart"}, services...)...)
+}
+
+// Ps lists the containers of the given services, including stopped ones
+func (c *Compose) Ps(ctx context.Context, services []string) ([]ServiceStatus, error) {
+	if c.engine != nil {
+		containers, err := c.containers(ctx, services)
+		if err != nil {
+			return nil, err
+		}
+		statuses := make([]ServiceStatus, 0, len(containers))
+		for _, container := range containers {
+			statuses = append(statuses, containerStatus(container))
+		}
+		return statuses, nil
+	}
+
+	output, err := c.output(ctx, append([]string{"ps", "--all", "--format", "json"}, services...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parsePsOutput(output)
+}
+
+// ServiceStats is a resource usage sample of a service container
+type ServiceStats struct {
+	Service   string
+	Container string
+	Stats
+}
+
+// Stats samples the resource usage of the running containers of the given services, or of
+// every service when none are given. Without an engine the docker CLI is used.
+func (c *Compose) Stats(ctx context.Context, services []string) ([]ServiceStats, error) {
+	statuses, err := c.Ps(ctx, services)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := c.engine
+	if engine == nil {
+		engine = &CLIEngine{binary: c.binary}
+	}
+
+	var running []ServiceStatus
+	for _, status := range statuses {
+		if status.State == "running" {
+			running = append(running, status)
+		}
+	}
+
+	var wg sync.WaitGroup
+	samples := make([]ServiceStats, len(running))
+	errs := make([]error, len(running))
+	for i, status := range running {
+		wg.Add(1)
+		go func(index int, status ServiceStatus) {
+			defer wg.Done()
+			stats, err := engine.Stats(ctx, status.Name)
+			if err != nil {
+				errs[index] = err
+				return
+			}
+			samples[index] = ServiceStats{Service: status.Service, Container: status.Name, Stats: *stats}
+		}(i, status)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// containers lists the containers of the given services, or of every service when none are
+// given, through the engine. Compose labels its containers with the project directory.
+func (c *Compose) containers(ctx context.Context, services []string) ([]Container, error) {
+	dir, err := filepath.Abs(filepath.Dir(c.file))
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := c.engine.Containers(ctx, map[string]string{labelWorkingDir: dir, labelOneOff: "False"})
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return containers, nil
+	}
+
+	selected := make(map[string]bool, len(services))
+	for _, service := range services {
+		selected[service] = true
+	}
+	var filtered []Container
+	for _, container := range containers {
+		if selected[container.Service()] {
+			filtered = append(filtered, container)
+		}
+	}
+	return filtered, nil
+}
+
+// stream runs a compose subcommand with its output connected to the terminal
+func (c *Compose) stream(ctx context.Context, args ...string) error {
+	cmd := c.command(ctx, args...)
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker compose %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// streamDocker runs a plain docker subcommand with its output connected to the terminal
+func (c *Compose) streamDocker(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// output runs a compose subcommand and returns its standard output
+func (c *Compose) output(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := c.command(ctx, args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("docker compose %s failed: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("docker compose %s failed: %w", args[0], err)
+	}
+	return output, nil
+}
+
+func (c *Compose) command(ctx context.Context, args ...string) *exec.Cmd {
+	base := []string{"compose", "-f", c.file}
+	if override := compose.OverridePath(c.file); fileExists(override) {
+		base = append(base, "-f", override)
+	}
+	return exec.CommandContext(ctx, c.binary, append(base, args...)...)
+}
+
+// parsePsOutput parses `docker compose ps --format json`, which is a JSON array in
+// older Compose releases and one JSON object per line in newer ones
+func parsePsOutput(output []byte) ([]ServiceStatus, error) {
+	trimmed := bytes.TrimSpace(output)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	var statuses []ServiceStatus
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &statuses); err != nil {
+			return nil, fmt.Errorf("failed to parse docker compose ps output: %w", err)
+		}
+		return statuses, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var status ServiceStatus
+		if err := json.Unmarshal(line, &status); err != nil {
+			return nil, fmt.Errorf("failed to parse docker compose ps output: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read docker compose ps output: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// Ports formats the published ports of a service, e.g. "5432->5432/tcp"
+func (s ServiceStatus) Ports() string {
+	var ports []string
+	seen := make(map[string]bool)
+	for _, p := range s.Publishers {
+		if p.PublishedPort == 0 {
+			continue
+		}
+		port := fmt.Sprintf("%d->%d/%s", p.PublishedPort, p.TargetPort, p.Protocol)
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+	return strings.Join(ports, ", ")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// LogOptions selects the log lines StreamLogs returns
+type LogOptions struct {
+	Follow bool
+	Since  string // Duration ("10m") or timestamp, as accepted by docker
+	Tail   string // Number of lines from the end, or "all"
+}
+
+// args returns the options as docker logs flags
+func (o LogOptions) args() []string {
+	var args []string
+	if o.Follow {
+		args = append(args, "--follow")
+	}
+	if o.Since != "" {
+		args = append(args, "--since", o.Since)
+	}
+	if o.Tail != "" {
+		args = append(args, "--tail", o.Tail)
+	}
+	return args
+}
+
+// StreamLogs calls fn for every log line of a service, each starting with its RFC 3339
+// timestamp. It returns when the logs end or ctx is cancelled.
+func (c *Compose) StreamLogs(ctx context.Context, service string, opts LogOptions, fn func(line string)) error {
+	if c.engine != nil {
+		return c.streamEngineLogs(ctx, service, opts, fn)
+	}
+
+	args := append([]string{"logs", "--no-color", "--no-log-prefix", "--timestamps"}, opts.args()...)
+	if err := streamLines(ctx, c.command(ctx, append(args, service)...), fn); err != nil {
+		return fmt.Errorf("docker compose logs %s failed: %w", service, err)
+	}
+	return nil
+}
+
+// streamEngineLogs streams the logs of every container of a service through the engine
+func (c *Compose) streamEngineLogs(ctx context.Context, service string, opts LogOptions, fn func(line string)) error {
+	containers, err := c.containers(ctx, []string{service})
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(containers))
+	for i, container := range containers {
+		wg.Add(1)
+		go func(index int, id string) {
+			defer wg.Done()
+			errs[index] = c.engine.Logs(ctx, id, opts, func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				fn(line)
+			})
+		}(i, container.ID)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// streamLines runs cmd and calls fn for every line it writes to stdout or stderr.
+// Errors after ctx is cancelled are expected and not reported.
+func streamLines(ctx context.Context, cmd *exec.Cmd, fn func(line string)) error {
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	// Don't hang on output pipes held open by orphaned children after cancellation
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	waitErr := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		waitErr <- err
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	// Drain whatever is left so the process is never blocked writing
+	io.Copy(io.Discard, reader)
+
+	if err := <-waitErr; err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestComposeCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(c *Compose) error
+		wantArgs []string
+	}{
+		{
+			name:     "should start every service when none are given",
+			run:      func(c *Compose) error { return c.Up(context.Background(), nil) },
+			wantArgs: []string{"compose -f deps.yml up --detach"},
+		},
+		{
+			name:     "should start only the given services",
+			run:      func(c *Compose) error { return c.Up(context.Background(), []string{"redis", "kafka"}) },
+			wantArgs: []string{"compose -f deps.yml up --detach redis kafka"},
+		},
+		{
+			name:     "should take the whole stack down when no services are given",
+			run:      func(c *Compose) error { return c.Down(context.Background(), nil) },
+			wantArgs: []string{"compose -f deps.yml down"},
+		},
+		{
+			name: "should stop and remove only the given services",
+			run:  func(c *Compose) error { return c.Down(context.Background(), []string{"redis"}) },
+			wantArgs: []string{
+				"compose -f deps.yml stop redis",
+				"compose -f deps.yml rm --force redis",
+			},
+		},
+		{
+			name:     "should restart the given services",
+			run:      func(c *Compose) error { return c.Restart(context.Background(), []string{"postgres-main"}) },
+			wantArgs: []string{"compose -f deps.yml restart postgres-main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := installFakeDocker(t, "", 0)
+
+			compose := newTestCompose()
+			if err := tt.run(compose); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := readInvocations(t, logFile); strings.Join(got, "\n") != strings.Join(tt.wantArgs, "\n") {
+				t.Errorf("docker invocations = %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestComposeIncludesOverrideFile(t *testing.T) {
+	logFile := installFakeDocker(t, "", 0)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "deps.yml")
+	override := filepath.Join(dir, "docker-compose.override.yml")
+	if err := os.WriteFile(override, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatalf("failed to write override file: %v", err)
+	}
+
+	compose := NewCompose(file)
+	compose.stdout = &bytes.Buffer{}
+	if err := compose.Up(context.Background(), nil); err != nil {
+		t.Fatalf("Up() unexpected error: %v", err)
+	}
+
+	want := "compose -f " + file + " -f " + override + " up --detach"
+	if got := readInvocations(t, logFile); len(got) != 1 || got[0] != want {
+		t.Errorf("docker invocations = %q, want %q", got, want)
+	}
+}
+
+func TestComposeFailure(t *testing.T) {
+	installFakeDocker(t, "", 1)
+
+	compose := newTestCompose()
+	err := compose.Up(context.Background(), []string{"redis"})
+	if err == nil {
+		t.Fatalf("Up() expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "docker compose up failed") {
+		t.Errorf("Up() error = %q, want to mention the failed subcommand", err.Error())
+	}
+}
+
+func TestComposePs(t *testing.T) {
+	tests := []struct {
+		name      string
+		psOutput  string
+		wantCount int
+		wantPorts string
+	}{
+		{
+			name: "should parse newline-delimited json",
+			psOutput: `{"Name":"redis","Service":"redis","State":"running","Health":"healthy","Publishers":[{"URL":"0.0.0.0","TargetPort":6379,"PublishedPort":6379,"Protocol":"tcp"},{"URL":"::","TargetPort":6379,"PublishedPort":6379,"Protocol":"tcp"}]}
+{"Name":"kafka","Service":"kafka","State":"exited","Health":"","Publishers":[]}`,
+			wantCount: 2,
+			wantPorts: "6379->6379/tcp",
+		},
+		{
+			name:      "should parse a json array",
+			psOutput:  `[{"Name":"redis","Service":"redis","State":"running","Publishers":[{"TargetPort":6379,"PublishedPort":6379,"Protocol":"tcp"}]}]`,
+			wantCount: 1,
+			wantPorts: "6379->6379/tcp",
+		},
+		{
+			name:      "should return nothing for empty output",
+			psOutput:  "",
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := installFakeDocker(t, tt.psOutput, 0)
+
+			compose := newTestCompose()
+			statuses, err := compose.Ps(context.Background(), []string{"redis"})
+			if err != nil {
+				t.Fatalf("Ps() unexpected error: %v", err)
+			}
+			if len(statuses) != tt.wantCount {
+				t.Fatalf("Ps() returned %d statuses, want %d", len(statuses), tt.wantCount)
+			}
+			if tt.wantCount > 0 && statuses[0].Ports() != tt.wantPorts {
+				t.Errorf("Ports() = %q, want %q", statuses[0].Ports(), tt.wantPorts)
+			}
+
+			want := "compose -f deps.yml ps --all --format json redis"
+			if got := readInvocations(t, logFile); len(got) != 1 || got[0] != want {
+				t.Errorf("docker invocations = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestComposeStreamLogs(t *testing.T) {
+	logFile := installFakeDockerOutputs(t, map[string]string{
+		"logs": "2024-05-01T12:00:00.000000000Z first\n2024-05-01T12:00:01.000000000Z second\n",
+	}, 0)
+
+	var lines []string
+	opts := LogOptions{Follow: true, Since: "10m", Tail: "100"}
+	err := newTestCompose().StreamLogs(context.Background(), "redis", opts, func(line string) {
+		lines = append(lines, line)
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs() unexpected error: %v", err)
+	}
+
+	if len(lines) != 2 || lines[1] != "2024-05-01T12:00:01.000000000Z second" {
+		t.Errorf("StreamLogs() lines = %q", lines)
+	}
+
+	want := "compose -f deps.yml logs --no-color --no-log-prefix --timestamps --follow --since 10m --tail 100 redis"
+	if got := readInvocations(t, logFile); len(got) != 1 || got[0] != want {
+		t.Errorf("docker invocations = %q, want %q", got, want)
+	}
+}
+
+// Helper functions
+
+// installFakeDocker puts a fake docker script first on PATH. It records its arguments,
+// prints psOutput for "ps" and exits with exitCode. Returns the invocation log path.
+func installFakeDocker(t *testing.T, psOutput string, exitCode int) string {
+	t.Helper()
+	return installFakeDockerOutputs(t, map[string]string{"ps": psOutput}, exitCode)
+}
+
+// installFakeDockerOutputs is installFakeDocker with canned output for any compose
+// subcommand, keyed by subcommand name
+func installFakeDockerOutputs(t *testing.T, outputs map[string]string, exitCode int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "invocations.log")
+
+	var cases strings.Builder
+	for subcommand, output := range outputs {
+		outputFile := filepath.Join(dir, subcommand+".out")
+		if err := os.WriteFile(outputFile, []byte(output), 0644); err != nil {
+			t.Fatalf("failed to write %s output: %v", subcommand, err)
+		}
+		cases.WriteString(`  *" ` + subcommand + ` "*) cat "` + outputFile + `" ;;` + "\n")
+	}
+
+	script := `#!/bin/sh
+echo "$@" >> "` + logFile + `"
+case " $* " in
+` + cases.String() + `esac
+exit ` + strconv.Itoa(exitCode) + `
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake docker: %v", err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+// readInvocations returns the argument lists the fake docker binary was called with
+func readInvocations(t *testing.T, logFile string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read invocation log: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// newTestCompose returns a Compose for deps.yml with its output discarded
+func newTestCompose() *Compose {
+	compose := NewCompose("deps.yml")
+	compose.stdout = &bytes.Buffer{}
+	compose.stderr = &bytes.Buffer{}
+	return compose
+}
@@ -0,0 +1,202 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Labels set by Compose on the containers it creates
+const (
+	labelService    = "com.docker.compose.service"
+	labelWorkingDir = "com.docker.compose.project.working_dir"
+	labelOneOff     = "com.docker.compose.oneoff"
+)
+
+// Engine is the part of the Docker Engine the deps commands use. It is implemented by
+// APIClient, which talks to the daemon directly, and by CLIEngine, which shells out to the
+// docker binary when the API cannot be reached.
+type Engine interface {
+	// Containers lists the containers, including stopped ones, carrying every given label
+	Containers(ctx context.Context, labels map[string]string) ([]Container, error)
+	// Inspect returns the details of a single container
+	Inspect(ctx context.Context, id string) (*Container, error)
+	// Logs calls fn for every log line of a container, each starting with its RFC 3339
+	// timestamp. It returns when the logs end or ctx is cancelled.
+	Logs(ctx context.Context, id string, opts LogOptions, fn func(line string)) error
+	// Stats returns a single resource usage sample of a running container
+	Stats(ctx context.Context, id string) (*Stats, error)
+}
+
+// Container describes a container. Containers fills in what the listing provides;
+// Inspect fills in every field.
+type Container struct {
+	ID           string
+	Name         string
+	Image        string
+	State        string // created, running, exited, ...
+	Status       string // Human readable, e.g. "Up 5 minutes (healthy)"
+	Health       string // healthy, unhealthy, starting, or empty without a healthcheck
+	Labels       map[string]string
+	Ports        []Publisher
+	Tty          bool
+	ExitCode     int
+	StartedAt    time.Time
+	RestartCount int
+}
+
+// Service returns the compose service the container belongs to
+func (c Container) Service() string {
+	return c.Labels[labelService]
+}
+
+// Stats is a resource usage sample of a container
+type Stats struct {
+	CPUPercent  float64 // Relative to a single CPU, so it can exceed 100 on multi-core hosts
+	MemoryUsage uint64  // Bytes, excluding the page cache
+	MemoryLimit uint64  // Bytes
+}
+
+// MemoryPercent returns the memory usage relative to the limit
+func (s Stats) MemoryPercent() float64 {
+	if s.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(s.MemoryUsage) / float64(s.MemoryLimit) * 100
+}
+
+// healthFromStatus extracts the health from a status like "Up 5 minutes (health: starting)"
+func healthFromStatus(status string) string {
+	switch {
+	case strings.HasSuffix(status, "(healthy)"):
+		return "healthy"
+	case strings.HasSuffix(status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.HasSuffix(status, "(health: starting)"):
+		return "starting"
+	default:
+		return ""
+	}
+}
+
+// inspectResponse is the container document returned by the API and `docker inspect`
+type inspectResponse struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+		Tty    bool              `json:"Tty"`
+	} `json:"Config"`
+	State struct {
+		Status    string    `json:"Status"`
+		ExitCode  int       `json:"ExitCode"`
+		StartedAt time.Time `json:"StartedAt"`
+		Health    *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	RestartCount    int `json:"RestartCount"`
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// container converts the inspect document
+func (r *inspectResponse) container() *Container {
+	c := &Container{
+		ID:           r.ID,
+		Name:         strings.TrimPrefix(r.Name, "/"),
+		Image:        r.Config.Image,
+		State:        r.State.Status,
+		Labels:       r.Config.Labels,
+		Tty:          r.Config.Tty,
+		ExitCode:     r.State.ExitCode,
+		StartedAt:    r.State.StartedAt,
+		RestartCount: r.RestartCount,
+	}
+	if r.State.Health != nil {
+		c.Health = r.State.Health.Status
+	}
+
+	for key, bindings := range r.NetworkSettings.Ports {
+		port, protocol, _ := strings.Cut(key, "/")
+		target, err := strconv.Atoi(port)
+		if err != nil {
+			continue
+		}
+		if len(bindings) == 0 {
+			c.Ports = append(c.Ports, Publisher{TargetPort: target, Protocol: protocol})
+		}
+		for _, binding := range bindings {
+			published, _ := strconv.Atoi(binding.HostPort)
+			c.Ports = append(c.Ports, Publisher{URL: binding.HostIP, TargetPort: target, PublishedPort: published, Protocol: protocol})
+		}
+	}
+	sortPublishers(c.Ports)
+
+	return c
+}
+
+// sortPublishers orders ports by target port, keeping the listing stable
+func sortPublishers(ports []Publisher) {
+	sort.SliceStable(ports, func(i, j int) bool {
+		if ports[i].TargetPort != ports[j].TargetPort {
+			return ports[i].TargetPort < ports[j].TargetPort
+		}
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		return ports[i].URL < ports[j].URL
+	})
+}
+
+// containerStatus converts a compose container to the form `docker compose ps` reports
+func containerStatus(c Container) ServiceStatus {
+	return ServiceStatus{
+		Name:       c.Name,
+		Service:    c.Service(),
+		State:      c.State,
+		Health:     c.Health,
+		Status:     c.Status,
+		Publishers: c.Ports,
+	}
+}
+
+// parseSize parses sizes as printed by docker, e.g. "10.5MiB" or "1.2GB"
+func parseSize(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	number := strings.TrimRightFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	unit := strings.TrimSpace(value[len(number):])
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	multipliers := map[string]float64{
+		"B":   1,
+		"kB":  1e3,
+		"KB":  1e3,
+		"MB":  1e6,
+		"GB":  1e9,
+		"TB":  1e12,
+		"KiB": 1 << 10,
+		"MiB": 1 << 20,
+		"GiB": 1 << 30,
+		"TiB": 1 << 40,
+	}
+	multiplier, ok := multipliers[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return uint64(n * multiplier), nil
+}
@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const manifestFile = "manifest.json"
+
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Snapshot describes archived volume contents
+type Snapshot struct {
+	Name        string           `json:"name"`
+	Created     time.Time        `json:"created"`
+	ComposeFile string           `json:"composeFile"`
+	Volumes     []SnapshotVolume `json:"volumes"`
+}
+
+// SnapshotVolume is a single archived volume
+type SnapshotVolume struct {
+	Key     string `json:"key"`     // Volume name in the compose file
+	Archive string `json:"archive"` // Tarball file name inside the snapshot directory
+	Size    int64  `json:"size"`
+}
+
+// SnapshotStore keeps snapshots as directories holding a manifest and one tarball per volume
+type SnapshotStore struct {
+	dir string
+}
+
+// NewSnapshotStore creates a new SnapshotStore rooted at dir
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{dir: dir}
+}
+
+// Dir returns the directory of a snapshot, validating its name
+func (s *SnapshotStore) Dir(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Exists reports whether a snapshot with the given name was saved
+func (s *SnapshotStore) Exists(name string) bool {
+	dir, err := s.Dir(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, manifestFile))
+	return err == nil
+}
+
+// Load reads the manifest of a snapshot
+func (s *SnapshotStore) Load(name string) (*Snapshot, error) {
+	dir, err := s.Dir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot %q not found in %s", name, s.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %q: %w", name, err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %q: %w", name, err)
+	}
+	return &snapshot, nil
+}
+
+// Stage creates an empty directory next to the saved snapshots for the archives of a new
+// snapshot, which Save then moves into place. Its name is not a valid snapshot name, so List
+// skips it if it is left behind.
+func (s *SnapshotStore) Stage(name string) (string, error) {
+	if _, err := s.Dir(name); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(s.dir, "."+name+".tmp-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	return staging, nil
+}
+
+// Save writes the manifest of a snapshot whose archives are already in the staging directory,
+// recording their sizes, and then renames the staging directory to the snapshot's. A snapshot
+// with the same name is only removed once the new one is in place.
+func (s *SnapshotStore) Save(snapshot *Snapshot, staging string) error {
+	dir, err := s.Dir(snapshot.Name)
+	if err != nil {
+		return err
+	}
+
+	for i, volume := range snapshot.Volumes {
+		info, err := os.Stat(filepath.Join(staging, volume.Archive))
+		if err != nil {
+			return fmt.Errorf("archive of volume %s is missing: %w", volume.Key, err)
+		}
+		snapshot.Volumes[i].Size = info.Size()
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(staging, manifestFile), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	// A directory cannot be renamed over another one, so move the old snapshot aside first
+	previous := ""
+	if _, err := os.Stat(dir); err == nil {
+		previous = staging + ".old"
+		if err := os.Rename(dir, previous); err != nil {
+			return fmt.Errorf("failed to replace snapshot %q: %w", snapshot.Name, err)
+		}
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		if previous != "" {
+			_ = os.Rename(previous, dir)
+		}
+		return fmt.Errorf("failed to save snapshot %q: %w", snapshot.Name, err)
+	}
+	if previous != "" {
+		_ = os.RemoveAll(previous)
+	}
+	return nil
+}
+
+// List returns all saved snapshots, newest first
+func (s *SnapshotStore) List() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var snapshots []*Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() || !s.Exists(entry.Name()) {
+			continue
+		}
+		snapshot, err := s.Load(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// Size returns the total size of a snapshot's archives
+func (s *Snapshot) Size() int64 {
+	var total int64
+	for _, volume := range s.Volumes {
+		total += volume.Size
+	}
+	return total
+}
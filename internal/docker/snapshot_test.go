@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	store := NewSnapshotStore(filepath.Join(t.TempDir(), "snapshots"))
+
+	if snapshots, err := store.List(); err != nil || len(snapshots) != 0 {
+		t.Fatalf("List() on an empty store = %v, %v; want nothing", snapshots, err)
+	}
+
+	for i, name := range []string{"seeded", "before-migration"} {
+		saveTestSnapshot(t, store, name, time.Date(2024, 5, 1+i, 12, 0, 0, 0, time.UTC), "archive")
+	}
+
+	snapshot, err := store.Load("seeded")
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if snapshot.Size() != int64(len("archive")) || snapshot.Volumes[0].Key != "redis_data" {
+		t.Errorf("Load() = %+v, want one redis_data volume of 7 bytes", snapshot)
+	}
+
+	snapshots, err := store.List()
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "before-migration" {
+		t.Errorf("List() = %v, want newest first", snapshots)
+	}
+
+	if _, err := store.Load("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Load(missing) error = %v, want not found", err)
+	}
+	if store.Exists("missing") {
+		t.Errorf("Exists(missing) = true, want false")
+	}
+}
+
+// saveTestSnapshot saves a snapshot with a single redis_data archive holding content
+func saveTestSnapshot(t *testing.T, store *SnapshotStore, name string, created time.Time, content string) {
+	t.Helper()
+
+	staging, err := store.Stage(name)
+	if err != nil {
+		t.Fatalf("Stage(%q) unexpected error: %v", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(staging, "redis_data.tar.gz"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+
+	snapshot := &Snapshot{
+		Name:    name,
+		Created: created,
+		Volumes: []SnapshotVolume{{Key: "redis_data", Archive: "redis_data.tar.gz"}},
+	}
+	if err := store.Save(snapshot, staging); err != nil {
+		t.Fatalf("Save(%q) unexpected error: %v", name, err)
+	}
+}
+
+func TestSnapshotStoreReplace(t *testing.T) {
+	root := t.TempDir()
+	store := NewSnapshotStore(root)
+	saveTestSnapshot(t, store, "seeded", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), "old")
+
+	// A save that fails before the manifest is written leaves the existing snapshot alone
+	staging, err := store.Stage("seeded")
+	if err != nil {
+		t.Fatalf("Stage() unexpected error: %v", err)
+	}
+	failed := &Snapshot{Name: "seeded", Volumes: []SnapshotVolume{{Key: "data", Archive: "data.tar.gz"}}}
+	if err := store.Save(failed, staging); err == nil {
+		t.Fatalf("Save() expected error for a missing archive, got nil")
+	}
+	if snapshot, err := store.Load("seeded"); err != nil || snapshot.Volumes[0].Key != "redis_data" {
+		t.Fatalf("Load() after a failed save = %+v, %v; want the old snapshot", snapshot, err)
+	}
+	if snapshots, err := store.List(); err != nil || len(snapshots) != 1 {
+		t.Errorf("List() with a staging directory left behind = %v, %v; want one snapshot", snapshots, err)
+	}
+
+	saveTestSnapshot(t, store, "seeded", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), "replaced")
+	snapshot, err := store.Load("seeded")
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if snapshot.Size() != int64(len("replaced")) {
+		t.Errorf("Load() after replacing = %+v, want the new snapshot", snapshot)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("failed to read store: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("store holds %d entries, want the snapshot and the failed staging directory only", len(entries))
+	}
+}
+
+func TestSnapshotStoreRejectsInvalidNames(t *testing.T) {
+	store := NewSnapshotStore(t.TempDir())
+
+	for _, name := range []string{"", "..", "../etc", "a/b", "-rf"} {
+		if _, err := store.Dir(name); err == nil {
+			t.Errorf("Dir(%q) expected error, got nil", name)
+		}
+	}
+
+	for _, name := range []string{"..", "a/b"} {
+		if _, err := store.Stage(name); err == nil {
+			t.Errorf("Stage(%q) expected error, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// archiveImage is the image used to copy volume contents to and from tarballs
+const archiveImage = "alpine:3"
+
+// Volume is a named volume of the compose project
+type Volume struct {
+	Key      string // Name used in the compose file
+	Name     string // Name of the Docker volume, usually prefixed with the project name
+	External bool   // Managed outside of Compose
+}
+
+type composeConfig struct {
+	Name    string `json:"name"`
+	Volumes map[string]struct {
+		Name     string      `json:"name"`
+		External interface{} `json:"external"`
+	} `json:"volumes"`
+}
+
+// Volumes resolves the Docker names of the project's named volumes through `docker compose config`,
+// which applies the project name and any explicit volume names
+func (c *Compose) Volumes(ctx context.Context) (map[string]Volume, error) {
+	output, err := c.output(ctx, "config", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var config composeConfig
+	if err := json.Unmarshal(output, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse docker compose config output: %w", err)
+	}
+
+	volumes := make(map[string]Volume, len(config.Volumes))
+	for key, volume := range config.Volumes {
+		name := volume.Name
+		if name == "" {
+			name = config.Name + "_" + key
+		}
+		volumes[key] = Volume{Key: key, Name: name, External: isExternal(volume.External)}
+	}
+	return volumes, nil
+}
+
+// RemoveVolumes deletes Docker volumes. Volumes that do not exist are ignored.
+func (c *Compose) RemoveVolumes(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return c.streamDocker(ctx, append([]string{"volume", "rm", "--force"}, names...)...)
+}
+
+// ArchiveVolume writes the contents of a volume to a gzipped tarball
+func (c *Compose) ArchiveVolume(ctx context.Context, volume, archive string) error {
+	dir, err := filepath.Abs(filepath.Dir(archive))
+	if err != nil {
+		return err
+	}
+
+	return c.streamDocker(ctx, "run", "--rm",
+		"-v", volume+":/volume:ro",
+		"-v", dir+":/backup",
+		archiveImage, "tar", "-czf", "/backup/"+filepath.Base(archive), "-C", "/volume", ".")
+}
+
+// RestoreVolume replaces the contents of a volume with a tarball written by ArchiveVolume
+func (c *Compose) RestoreVolume(ctx context.Context, volume, archive string) error {
+	dir, err := filepath.Abs(filepath.Dir(archive))
+	if err != nil {
+		return err
+	}
+
+	script := "find /volume -mindepth 1 -delete && tar -xzf /backup/" + filepath.Base(archive) + " -C /volume"
+	return c.streamDocker(ctx, "run", "--rm",
+		"-v", volume+":/volume",
+		"-v", dir+":/backup:ro",
+		archiveImage, "sh", "-c", script)
+}
+
+// isExternal interprets the external key, which is a boolean or, in old files, a mapping
+func isExternal(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case map[string]interface{}:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComposeVolumes(t *testing.T) {
+	installFakeDockerOutputs(t, map[string]string{"config": `{
+		"name": "module",
+		"services": {},
+		"volumes": {
+			"postgres_main_data": {"name": "module_postgres_main_data"},
+			"seed": {"name": "shared-seed", "external": true},
+			"legacy": {}
+		}
+	}`}, 0)
+
+	volumes, err := newTestCompose().Volumes(context.Background())
+	if err != nil {
+		t.Fatalf("Volumes() unexpected error: %v", err)
+	}
+
+	want := map[string]Volume{
+		"postgres_main_data": {Key: "postgres_main_data", Name: "module_postgres_main_data"},
+		"seed":               {Key: "seed", Name: "shared-seed", External: true},
+		"legacy":             {Key: "legacy", Name: "module_legacy"},
+	}
+	if !reflect.DeepEqual(volumes, want) {
+		t.Errorf("Volumes() = %+v, want %+v", volumes, want)
+	}
+}
+
+func TestVolumeCommands(t *testing.T) {
+	logFile := installFakeDocker(t, "", 0)
+	dir := t.TempDir()
+
+	compose := newTestCompose()
+	if err := compose.RemoveVolumes(context.Background(), []string{"module_redis_data", "module_kafka_data"}); err != nil {
+		t.Fatalf("RemoveVolumes() unexpected error: %v", err)
+	}
+	if err := compose.RemoveVolumes(context.Background(), nil); err != nil {
+		t.Fatalf("RemoveVolumes(nil) unexpected error: %v", err)
+	}
+	if err := compose.ArchiveVolume(context.Background(), "module_redis_data", filepath.Join(dir, "redis_data.tar.gz")); err != nil {
+		t.Fatalf("ArchiveVolume() unexpected error: %v", err)
+	}
+	if err := compose.RestoreVolume(context.Background(), "module_redis_data", filepath.Join(dir, "redis_data.tar.gz")); err != nil {
+		t.Fatalf("RestoreVolume() unexpected error: %v", err)
+	}
+
+	want := []string{
+		"volume rm --force module_redis_data module_kafka_data",
+		"run --rm -v module_redis_data:/volume:ro -v " + dir + ":/backup alpine:3 tar -czf /backup/redis_data.tar.gz -C /volume .",
+		"run --rm -v module_redis_data:/volume -v " + dir + ":/backup:ro alpine:3 sh -c find /volume -mindepth 1 -delete && tar -xzf /backup/redis_data.tar.gz -C /volume",
+	}
+	if got := readInvocations(t, logFile); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("docker invocations =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,176 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/probe"
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// ServiceState is the readiness of a service as observed by a Waiter
+type ServiceState struct {
+	Service string
+	Ready   bool
+	Detail  string // How readiness was determined, or why the service is not ready yet
+	Elapsed time.Duration
+}
+
+// Waiter waits for compose services to become ready. It prefers the container health status
+// reported by Compose and falls back to protocol-aware probes on the published ports.
+type Waiter struct {
+	compose  *Compose
+	interval time.Duration
+	probeFor func(service *compose.Service) (probe.Probe, string, bool)
+}
+
+// NewWaiter creates a new Waiter polling every interval
+func NewWaiter(c *Compose, interval time.Duration) *Waiter {
+	return &Waiter{
+		compose:  c,
+		interval: interval,
+		probeFor: probe.ForService,
+	}
+}
+
+// Wait blocks until every service is ready or ctx is done. onChange is called whenever the
+// state of a service changes. The returned error lists the services that never became ready.
+func (w *Waiter) Wait(ctx context.Context, services []*compose.Service, onChange func(ServiceState)) error {
+	start := time.Now()
+	states := make(map[string]ServiceState, len(services))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		pending := pendingServices(services, states)
+		if len(pending) == 0 {
+			return nil
+		}
+
+		polled := w.poll(ctx, pending)
+		if ctx.Err() != nil {
+			// Checks cut short by the deadline say nothing about the service
+			return notReadyError(pending, ctx.Err())
+		}
+
+		for _, state := range polled {
+			state.Elapsed = time.Since(start)
+			if previous, ok := states[state.Service]; !ok || previous.Ready != state.Ready || previous.Detail != state.Detail {
+				if onChange != nil {
+					onChange(state)
+				}
+			}
+			states[state.Service] = state
+		}
+
+		if len(pendingServices(services, states)) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return notReadyError(pendingServices(services, states), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks every pending service once
+func (w *Waiter) poll(ctx context.Context, services []*compose.Service) []ServiceState {
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+
+	// Health status is unavailable when docker cannot be reached; probes still work then
+	statuses := make(map[string]ServiceStatus)
+	if list, err := w.compose.Ps(ctx, names); err == nil {
+		for _, status := range list {
+			statuses[status.Service] = status
+		}
+	}
+
+	var wg sync.WaitGroup
+	states := make([]ServiceState, len(services))
+	for i, service := range services {
+		wg.Add(1)
+		go func(index int, s *compose.Service) {
+			defer wg.Done()
+			states[index] = w.check(ctx, s, statuses[s.Name])
+		}(i, service)
+	}
+	wg.Wait()
+
+	return states
+}
+
+// check determines the readiness of a single service
+func (w *Waiter) check(ctx context.Context, service *compose.Service, status ServiceStatus) ServiceState {
+	state := ServiceState{Service: service.Name}
+
+	if status.Health != "" {
+		state.Ready = status.Health == "healthy"
+		state.Detail = "health: " + status.Health
+		return state
+	}
+
+	p, addr, ok := w.probeFor(service)
+	if !ok {
+		// Nothing to probe: a running container is the best signal available
+		state.Ready = status.State == "running"
+		state.Detail = "state: " + orUnknown(status.State)
+		return state
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, w.interval+2*time.Second)
+	defer cancel()
+
+	if err := p.Check(probeCtx, addr); err != nil {
+		state.Detail = fmt.Sprintf("%s probe on %s: %s", p.Name(), addr, shortError(err))
+		return state
+	}
+
+	state.Ready = true
+	state.Detail = fmt.Sprintf("%s probe on %s", p.Name(), addr)
+	return state
+}
+
+func pendingServices(services []*compose.Service, states map[string]ServiceState) []*compose.Service {
+	var pending []*compose.Service
+	for _, service := range services {
+		if !states[service.Name].Ready {
+			pending = append(pending, service)
+		}
+	}
+	return pending
+}
+
+func notReadyError(pending []*compose.Service, cause error) error {
+	names := make([]string, 0, len(pending))
+	for _, service := range pending {
+		names = append(names, service.Name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("services not ready: %s: %w", strings.Join(names, ", "), cause)
+}
+
+// shortError trims the "dial tcp ...:" noise from network errors
+func shortError(err error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, ": "); i >= 0 && strings.HasPrefix(msg, "dial ") {
+		return msg[i+2:]
+	}
+	return msg
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,134 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/probe"
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+func TestWaiterWait(t *testing.T) {
+	services := []*compose.Service{
+		{Name: "postgres", Image: "postgres:15"},
+		{Name: "redis", Image: "redis:7"},
+		{Name: "worker", Image: "busybox"},
+	}
+
+	tests := []struct {
+		name        string
+		psOutput    string
+		probeErrors map[string]error
+		wantErr     string
+		wantReady   []string
+	}{
+		{
+			name: "should use health status, probes and container state",
+			psOutput: `{"Service":"postgres","State":"running","Health":"healthy"}
+{"Service":"redis","State":"running","Health":""}
+{"Service":"worker","State":"running","Health":""}`,
+			wantReady: []string{"postgres", "redis", "worker"},
+		},
+		{
+			name: "should report services that never become ready",
+			psOutput: `{"Service":"postgres","State":"running","Health":"starting"}
+{"Service":"redis","State":"running","Health":""}
+{"Service":"worker","State":"exited","Health":""}`,
+			probeErrors: map[string]error{"redis": probe.ErrNotReady},
+			wantErr:     "services not ready: postgres, redis, worker",
+		},
+		{
+			name:        "should fall back to probes when docker is unavailable",
+			psOutput:    "",
+			probeErrors: map[string]error{},
+			wantErr:     "services not ready: worker",
+			wantReady:   []string{"postgres", "redis"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeDocker(t, tt.psOutput, 0)
+
+			waiter := NewWaiter(newTestCompose(), 10*time.Millisecond)
+			waiter.probeFor = func(service *compose.Service) (probe.Probe, string, bool) {
+				if service.Name == "worker" {
+					return nil, "", false
+				}
+				return &stubProbe{err: tt.probeErrors[service.Name]}, "localhost:1", true
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			var ready []string
+			err := waiter.Wait(ctx, services, func(state ServiceState) {
+				if state.Ready {
+					ready = append(ready, state.Service)
+				}
+			})
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Wait() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Wait() error = %v, want %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("Wait() error = %v, want it to wrap context.DeadlineExceeded", err)
+				}
+			}
+			if strings.Join(ready, ",") != strings.Join(tt.wantReady, ",") {
+				t.Errorf("ready services = %v, want %v", ready, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestWaiterWaitsUntilReady(t *testing.T) {
+	installFakeDocker(t, "", 1)
+
+	attempts := 0
+	waiter := NewWaiter(newTestCompose(), 5*time.Millisecond)
+	waiter.probeFor = func(service *compose.Service) (probe.Probe, string, bool) {
+		attempts++
+		if attempts < 3 {
+			return &stubProbe{err: probe.ErrNotReady}, "localhost:1", true
+		}
+		return &stubProbe{}, "localhost:1", true
+	}
+
+	var states []ServiceState
+	err := waiter.Wait(context.Background(), []*compose.Service{{Name: "kafka"}}, func(state ServiceState) {
+		states = append(states, state)
+	})
+	if err != nil {
+		t.Fatalf("Wait() unexpected error: %v", err)
+	}
+
+	// Repeated identical states are reported once
+	if len(states) != 2 || states[0].Ready || !states[1].Ready {
+		t.Errorf("Wait() reported %+v, want one waiting and one ready state", states)
+	}
+	if attempts != 3 {
+		t.Errorf("probe attempts = %d, want 3", attempts)
+	}
+}
+
+// Helper functions
+
+type stubProbe struct {
+	err error
+}
+
+func (p *stubProbe) Name() string {
+	return "stub"
+}
+
+func (p *stubProbe) Check(ctx context.Context, addr string) error {
+	return p.err
+}
@@ -0,0 +1,108 @@
+package dotenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Variable is a single KEY=value entry
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// MergeResult counts the changes Merge made to a file
+type MergeResult struct {
+	Added     int
+	Updated   int
+	Unchanged int
+}
+
+// Changed reports whether the file was modified
+func (r MergeResult) Changed() bool {
+	return r.Added > 0 || r.Updated > 0
+}
+
+// Format renders a variable as a dotenv line
+func Format(v Variable) string {
+	return v.Name + "=" + Quote(v.Value)
+}
+
+// Quote leaves simple values bare and double-quotes anything a dotenv parser could misread
+func Quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'`$#\\") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// Merge sets variables in a dotenv file. Existing assignments are rewritten in place, new
+// ones are appended, and comments and unrelated lines are kept. A missing file is created.
+func Merge(path string, variables []Variable) (MergeResult, error) {
+	var result MergeResult
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return result, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	content := string(data)
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	index := make(map[string]int)
+	for i, line := range lines {
+		if name, ok := assignedName(line); ok {
+			index[name] = i
+		}
+	}
+
+	for _, variable := range variables {
+		line := Format(variable)
+		i, ok := index[variable.Name]
+		switch {
+		case !ok:
+			lines = append(lines, line)
+			index[variable.Name] = len(lines) - 1
+			result.Added++
+		case strings.TrimPrefix(strings.TrimSpace(lines[i]), "export ") == line:
+			result.Unchanged++
+		default:
+			if strings.HasPrefix(strings.TrimSpace(lines[i]), "export ") {
+				line = "export " + line
+			}
+			lines[i] = line
+			result.Updated++
+		}
+	}
+
+	if !result.Changed() {
+		return result, nil
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// assignedName returns the variable assigned on a line, ignoring comments and blank lines
+func assignedName(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+
+	name, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,87 @@
+package dotenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "localhost:6379", want: "localhost:6379"},
+		{value: "postgres://u:p@localhost:5432/db?sslmode=disable", want: "postgres://u:p@localhost:5432/db?sslmode=disable"},
+		{value: "", want: `""`},
+		{value: "two words", want: `"two words"`},
+		{value: `p"a$s#`, want: `"p\"a\$s#"`},
+	}
+
+	for _, tt := range tests {
+		if got := Quote(tt.value); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string
+		variables  []Variable
+		want       string
+		wantResult MergeResult
+	}{
+		{
+			name:       "should create a missing file",
+			variables:  []Variable{{Name: "REDIS_ADDR", Value: "localhost:6379"}},
+			want:       "REDIS_ADDR=localhost:6379\n",
+			wantResult: MergeResult{Added: 1},
+		},
+		{
+			name:     "should update in place and keep other lines",
+			existing: "# local overrides\nexport REDIS_ADDR=old:1\n\nLOG_LEVEL=debug",
+			variables: []Variable{
+				{Name: "REDIS_ADDR", Value: "localhost:6380"},
+				{Name: "KAFKA_BROKERS", Value: "localhost:9092"},
+			},
+			want:       "# local overrides\nexport REDIS_ADDR=localhost:6380\n\nLOG_LEVEL=debug\nKAFKA_BROKERS=localhost:9092\n",
+			wantResult: MergeResult{Added: 1, Updated: 1},
+		},
+		{
+			name:       "should leave an up to date file untouched",
+			existing:   "REDIS_ADDR=localhost:6379\n#REDIS_ADDR=commented\n",
+			variables:  []Variable{{Name: "REDIS_ADDR", Value: "localhost:6379"}},
+			want:       "REDIS_ADDR=localhost:6379\n#REDIS_ADDR=commented\n",
+			wantResult: MergeResult{Unchanged: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env.local")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatalf("failed to write env file: %v", err)
+				}
+			}
+
+			result, err := Merge(path, tt.variables)
+			if err != nil {
+				t.Fatalf("Merge() unexpected error: %v", err)
+			}
+			if result != tt.wantResult {
+				t.Errorf("Merge() = %+v, want %+v", result, tt.wantResult)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read env file: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("file content = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
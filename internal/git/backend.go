@@ -0,0 +1,796 @@
+package git
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/oddjob23/go-cli/pkg/config"
+)
+
+// Names of the git backends selectable with "gitBackend" in config.json
+const (
+	BackendExec  = config.GitBackendExec
+	BackendGoGit = config.GitBackendGoGit
+)
+
+// Backends lists the selectable git backends
+var Backends = config.GitBackends
+
+// ErrUnknownRevision is returned by GitBackend.ResolveRevision for revisions that do not exist
+var ErrUnknownRevision = errors.New("unknown revision")
+
+// GitBackend carries out the git operations a sync runs against a repository. Errors of the
+// operations that change a repository carry git's output, which handleGitError explains.
+type GitBackend interface {
+	// CurrentBranch returns the checked out branch, or "" when HEAD is detached
+	CurrentBranch(repoPath string) (string, error)
+	// ResolveRevision returns the full commit ID of a branch, tag, full reference name or
+	// commit ID, or ErrUnknownRevision
+	ResolveRevision(repoPath, revision string) (string, error)
+	// AheadBehind counts the commits only reachable from local and only reachable from upstream
+	AheadBehind(repoPath, local, upstream string) (ahead, behind int, err error)
+	// CountCommits counts the commits reachable from revision but from none of exclude
+	CountCommits(repoPath, revision string, exclude ...string) (int, error)
+	// HasUncommittedChanges reports whether tracked files have staged or unstaged changes
+	HasUncommittedChanges(repoPath string) (bool, error)
+	// Log lists the commits in revRange newest first, at most limit of them when limit > 0
+	Log(repoPath, revRange string, limit int) ([]Commit, error)
+	// TrackedFiles lists the tracked files matching the pathspecs, relative to the repository root
+	TrackedFiles(repoPath string, pathspecs ...string) ([]string, error)
+	// DiffStat counts the files changed and the lines inserted and deleted between two revisions
+	DiffStat(repoPath, from, to string) (files, insertions, deletions int, err error)
+	// Branches lists the local branches sorted by name, with how they compare to their upstream
+	Branches(repoPath string) ([]Branch, error)
+	// MergedBranches lists the local branches whose tip is reachable from base
+	MergedBranches(repoPath, base string) ([]string, error)
+
+	// Fetch fetches from remote, or the default remote when remote is "", updating only the
+	// given refspecs when there are any
+	Fetch(repoPath, remote string, refspecs ...string) error
+	// Checkout switches the working tree to branch
+	Checkout(repoPath, branch string) error
+	// Pull merges the upstream of the checked out branch, or branch from remote when remote is not ""
+	Pull(repoPath, remote, branch string) error
+}
+
+// Branch is a local branch as GitBackend.Branches lists it
+type Branch struct {
+	Name     string // e.g. "feature/login"
+	SHA      string
+	Upstream string // Full name of the upstream reference, e.g. "refs/remotes/origin/main"; empty when the branch tracks nothing
+	Remote   string // Remote of the upstream, "." when it is a local branch
+	Merge    string // Upstream branch on Remote, e.g. "refs/heads/main"
+	Gone     bool   // The upstream reference no longer exists
+	Ahead    int
+	Behind   int
+}
+
+// NewBackend returns the backend with the given name; "" selects BackendExec
+func NewBackend(name string) (GitBackend, error) {
+	switch name {
+	case "", BackendExec:
+		return ExecBackend{}, nil
+	case BackendGoGit:
+		return GoGitBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown git backend %q (expected one of %s)", name, strings.Join(Backends, ", "))
+	}
+}
+
+// ExecBackend carries out every operation by running the git binary
+type ExecBackend struct{}
+
+// CurrentBranch implements GitBackend
+func (ExecBackend) CurrentBranch(repoPath string) (string, error) {
+	branch, err := gitCommandOutput(repoPath, "branch", "--show-current")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	return branch, nil
+}
+
+// ResolveRevision implements GitBackend
+func (ExecBackend) ResolveRevision(repoPath, revision string) (string, error) {
+	sha, err := gitCommandOutput(repoPath, "rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRevision, revision)
+	}
+	return sha, nil
+}
+
+// AheadBehind implements GitBackend
+func (ExecBackend) AheadBehind(repoPath, local, upstream string) (int, int, error) {
+	output, err := gitCommandOutput(repoPath, "rev-list", "--left-right", "--count", local+"..."+upstream)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q: %w", output, err)
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q: %w", output, err)
+	}
+	return ahead, behind, nil
+}
+
+// CountCommits implements GitBackend
+func (ExecBackend) CountCommits(repoPath, revision string, exclude ...string) (int, error) {
+	args := []string{"rev-list", "--count", revision}
+	if len(exclude) > 0 {
+		args = append(append(args, "--not"), exclude...)
+	}
+	output, err := gitCommandOutput(repoPath, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+
+	count, err := strconv.Atoi(output)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected rev-list output %q: %w", output, err)
+	}
+	return count, nil
+}
+
+// HasUncommittedChanges implements GitBackend
+func (ExecBackend) HasUncommittedChanges(repoPath string) (bool, error) {
+	status, err := gitCommandOutput(repoPath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return status != "", nil
+}
+
+// Log implements GitBackend
+func (ExecBackend) Log(repoPath, revRange string, limit int) ([]Commit, error) {
+	args := []string{"log", "--format=%h%x1f%an%x1f%s"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", limit))
+	}
+	output, err := gitCommandOutput(repoPath, append(args, revRange)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommitLog(output), nil
+}
+
+// TrackedFiles implements GitBackend
+func (ExecBackend) TrackedFiles(repoPath string, pathspecs ...string) ([]string, error) {
+	output, err := gitCommandOutput(repoPath, append([]string{"ls-files", "-z", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(output, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// DiffStat implements GitBackend
+func (ExecBackend) DiffStat(repoPath, from, to string) (int, int, int, error) {
+	stat, err := gitCommandOutput(repoPath, "diff", "--shortstat", from, to)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	files, insertions, deletions := parseShortStat(stat)
+	return files, insertions, deletions, nil
+}
+
+// Branches implements GitBackend
+func (ExecBackend) Branches(repoPath string) ([]Branch, error) {
+	output, err := gitCommandOutput(repoPath, "for-each-ref",
+		"--format=%(refname)%00%(objectname)%00%(upstream)%00%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream:track,nobracket)",
+		"refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 6 {
+			continue
+		}
+		branch := Branch{
+			Name:     strings.TrimPrefix(fields[0], "refs/heads/"),
+			SHA:      fields[1],
+			Upstream: fields[2],
+			Remote:   fields[3],
+			Merge:    fields[4],
+		}
+		branch.Ahead, branch.Behind, branch.Gone = parseTrack(fields[5])
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+// MergedBranches implements GitBackend
+func (ExecBackend) MergedBranches(repoPath, base string) ([]string, error) {
+	output, err := gitCommandOutput(repoPath, "for-each-ref", "--merged", base, "--format=%(refname)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []string
+	for _, name := range strings.Split(output, "\n") {
+		if name != "" {
+			branches = append(branches, strings.TrimPrefix(name, "refs/heads/"))
+		}
+	}
+	return branches, nil
+}
+
+// Fetch implements GitBackend
+func (ExecBackend) Fetch(repoPath, remote string, refspecs ...string) error {
+	args := []string{"fetch"}
+	if remote != "" {
+		args = append(append(args, remote), refspecs...)
+	}
+	return runGitCommand(repoPath, args...)
+}
+
+// Checkout implements GitBackend
+func (ExecBackend) Checkout(repoPath, branch string) error {
+	return runGitCommand(repoPath, "checkout", "--quiet", branch)
+}
+
+// Pull implements GitBackend
+func (ExecBackend) Pull(repoPath, remote, branch string) error {
+	if remote == "" {
+		return runGitCommand(repoPath, "pull")
+	}
+	return runGitCommand(repoPath, "pull", remote, branch)
+}
+
+// GoGitBackend answers the read-only queries about history, branches and the index in process
+// by reading the repository with go-git, which avoids forking a git process per query when
+// working with many repositories. Everything else runs the git binary through the embedded
+// ExecBackend.
+type GoGitBackend struct {
+	ExecBackend
+}
+
+// CurrentBranch implements GitBackend
+func (GoGitBackend) CurrentBranch(repoPath string) (string, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	// Read HEAD without resolving it, so that a branch without commits is still reported
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if head.Type() != plumbing.SymbolicReference || !head.Target().IsBranch() {
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
+
+// ResolveRevision implements GitBackend
+func (GoGitBackend) ResolveRevision(repoPath, revision string) (string, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := resolveCommit(repo, revision)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// AheadBehind implements GitBackend
+func (GoGitBackend) AheadBehind(repoPath, local, upstream string) (int, int, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	localHash, err := resolveCommit(repo, local)
+	if err != nil {
+		return 0, 0, err
+	}
+	upstreamHash, err := resolveCommit(repo, upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return aheadBehind(repo, localHash, upstreamHash)
+}
+
+// CountCommits implements GitBackend
+func (GoGitBackend) CountCommits(repoPath, revision string, exclude ...string) (int, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return 0, err
+	}
+
+	hash, err := resolveCommit(repo, revision)
+	if err != nil {
+		return 0, err
+	}
+	excluded := make([]plumbing.Hash, 0, len(exclude))
+	for _, revision := range exclude {
+		hash, err := resolveCommit(repo, revision)
+		if err != nil {
+			return 0, err
+		}
+		excluded = append(excluded, hash)
+	}
+
+	count, _, err := aheadBehind(repo, hash, excluded...)
+	return count, err
+}
+
+// HasUncommittedChanges implements GitBackend by running git status, as ExecBackend does.
+// go-git's worktree status hashes the content of every tracked file instead of trusting the
+// file stats recorded in the index, and it runs no clean filters such as git-lfs's, so it is
+// much slower than git and reports files git considers unchanged.
+func (b GoGitBackend) HasUncommittedChanges(repoPath string) (bool, error) {
+	return b.ExecBackend.HasUncommittedChanges(repoPath)
+}
+
+// Log implements GitBackend. A revRange without ".." lists the history of a single revision.
+func (GoGitBackend) Log(repoPath, revRange string, limit int) ([]Commit, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, isRange := strings.Cut(revRange, "..")
+	if !isRange {
+		from, to = "", revRange
+	}
+	toHash, err := resolveCommit(repo, to)
+	if err != nil {
+		return nil, err
+	}
+	var exclude []plumbing.Hash
+	if from != "" {
+		fromHash, err := resolveCommit(repo, from)
+		if err != nil {
+			return nil, err
+		}
+		exclude = append(exclude, fromHash)
+	}
+
+	_, commits, err := walkHistory(repo, toHash, exclude...)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(commits) > limit {
+		commits = commits[:limit]
+	}
+
+	var log []Commit
+	for _, commit := range commits {
+		log = append(log, Commit{
+			SHA:     shortSHA(commit.Hash.String()),
+			Author:  commit.Author.Name,
+			Subject: commitSubject(commit.Message),
+		})
+	}
+	return log, nil
+}
+
+// TrackedFiles implements GitBackend. Besides plain paths and wildcards, only the ":(glob)"
+// magic is supported in pathspecs.
+func (GoGitBackend) TrackedFiles(repoPath string, pathspecs ...string) ([]string, error) {
+	matchers := make([]*regexp.Regexp, 0, len(pathspecs))
+	for _, pathspec := range pathspecs {
+		matcher, err := compilePathspec(pathspec)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	var files []string
+	for _, entry := range index.Entries {
+		// A file with conflicts has an entry per side, next to each other
+		if len(files) > 0 && files[len(files)-1] == entry.Name {
+			continue
+		}
+		if matchesAny(matchers, entry.Name) {
+			files = append(files, entry.Name)
+		}
+	}
+	return files, nil
+}
+
+// DiffStat implements GitBackend
+func (GoGitBackend) DiffStat(repoPath, from, to string) (int, int, int, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	fromTree, err := commitTree(repo, from)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	toTree, err := commitTree(repo, to)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	// Renames are detected like git diff does by default
+	changes, err := fromTree.Diff(toTree)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to diff %s and %s: %w", from, to, err)
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to diff %s and %s: %w", from, to, err)
+	}
+
+	files, insertions, deletions := 0, 0, 0
+	for _, file := range patch.FilePatches() {
+		files++
+		for _, chunk := range file.Chunks() {
+			switch chunk.Type() {
+			case diff.Add:
+				insertions += countLines(chunk.Content())
+			case diff.Delete:
+				deletions += countLines(chunk.Content())
+			}
+		}
+	}
+	return files, insertions, deletions, nil
+}
+
+// Branches implements GitBackend
+func (GoGitBackend) Branches(repoPath string) ([]Branch, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branch := Branch{Name: ref.Name().Short(), SHA: ref.Hash().String()}
+		if tracking, ok := cfg.Branches[branch.Name]; ok {
+			if upstream := upstreamReference(cfg, tracking.Remote, tracking.Merge); upstream != "" {
+				branch.Upstream, branch.Remote, branch.Merge = upstream.String(), tracking.Remote, tracking.Merge.String()
+			}
+		}
+
+		if branch.Upstream != "" {
+			upstream, err := repo.Reference(plumbing.ReferenceName(branch.Upstream), true)
+			switch {
+			case errors.Is(err, plumbing.ErrReferenceNotFound):
+				branch.Gone = true
+			case err != nil:
+				return err
+			default:
+				if branch.Ahead, branch.Behind, err = aheadBehind(repo, ref.Hash(), upstream.Hash()); err != nil {
+					return err
+				}
+			}
+		}
+
+		branches = append(branches, branch)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches, nil
+}
+
+// MergedBranches implements GitBackend
+func (GoGitBackend) MergedBranches(repoPath, base string) ([]string, error) {
+	repo, err := openRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	baseHash, err := resolveCommit(repo, base)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	var merged []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		ahead, _, err := aheadBehind(repo, ref.Hash(), baseHash)
+		if err != nil {
+			return err
+		}
+		if ahead == 0 {
+			merged = append(merged, ref.Name().Short())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(merged)
+	return merged, nil
+}
+
+// openRepository opens the repository containing repoPath, including linked worktrees
+func openRepository(repoPath string) (*gogit.Repository, error) {
+	repo, err := gogit.PlainOpenWithOptions(repoPath, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository %s: %w", repoPath, err)
+	}
+	return repo, nil
+}
+
+// resolveCommit returns the commit a revision points at, or ErrUnknownRevision
+func resolveCommit(repo *gogit.Repository, revision string) (plumbing.Hash, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("%w: %s", ErrUnknownRevision, revision)
+	}
+	// Peel annotated tags to the commit they point at, like "^{commit}" does for git
+	if tag, err := repo.TagObject(*hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("%w: %s", ErrUnknownRevision, revision)
+		}
+		return commit.Hash, nil
+	}
+	return *hash, nil
+}
+
+// commitTree returns the tree of the commit a revision points at
+func commitTree(repo *gogit.Repository, revision string) (*object.Tree, error) {
+	hash, err := resolveCommit(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	return commit.Tree()
+}
+
+// upstreamReference maps the upstream branch configured for a branch to the reference holding
+// it locally, through the fetch refspecs of its remote like git does. It returns "" when the
+// remote is not configured or fetches no such branch.
+func upstreamReference(cfg *gitconfig.Config, remote string, merge plumbing.ReferenceName) plumbing.ReferenceName {
+	if remote == "." {
+		return merge
+	}
+	if r, ok := cfg.Remotes[remote]; ok {
+		for _, refspec := range r.Fetch {
+			if refspec.Match(merge) {
+				return refspec.Dst(merge)
+			}
+		}
+	}
+	return ""
+}
+
+// commitSubject returns the subject of a commit message like %s does for git log: its first
+// paragraph joined into a single line
+func commitSubject(message string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+	return strings.Join(strings.Fields(paragraph), " ")
+}
+
+// countLines counts the lines of a diff chunk, including a last line without a newline
+func countLines(content string) int {
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// Sides of the history a commit is reachable from, while walking it
+const (
+	reachableFromLocal = 1 << iota
+	reachableFromUpstream
+	reachableFromBoth = reachableFromLocal | reachableFromUpstream
+)
+
+// aheadBehind counts the commits reachable only from local and only from the upstreams
+func aheadBehind(repo *gogit.Repository, local plumbing.Hash, upstreams ...plumbing.Hash) (int, int, error) {
+	flags, _, err := walkHistory(repo, local, upstreams...)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ahead, behind := 0, 0
+	for _, flag := range flags {
+		switch flag {
+		case reachableFromLocal:
+			ahead++
+		case reachableFromUpstream:
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
+
+// walkHistory flags the commits reachable from local and from any of upstreams with the sides
+// they are reachable from, and returns the flags with the commits only reachable from local,
+// newest first. Like git rev-list, it walks the histories newest first and stops once every
+// commit left to visit is reachable from both sides, so only the commits down to the merge base
+// are read.
+func walkHistory(repo *gogit.Repository, local plumbing.Hash, upstreams ...plumbing.Hash) (map[plumbing.Hash]int, []*object.Commit, error) {
+	flags := make(map[plumbing.Hash]int)
+	queue := &commitQueue{}
+
+	visit := func(hash plumbing.Hash, flag int) error {
+		if flags[hash]|flag == flags[hash] {
+			return nil
+		}
+		flags[hash] |= flag
+
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		heap.Push(queue, commit)
+		return nil
+	}
+
+	if err := visit(local, reachableFromLocal); err != nil {
+		return nil, nil, err
+	}
+	for _, upstream := range upstreams {
+		if err := visit(upstream, reachableFromUpstream); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var walked []*object.Commit
+	for queue.Len() > 0 && !queue.allFlagged(flags, reachableFromBoth) {
+		commit := heap.Pop(queue).(*object.Commit)
+		walked = append(walked, commit)
+		for _, parent := range commit.ParentHashes {
+			if err := visit(parent, flags[commit.Hash]); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// A commit is only known to be reachable from both sides once the walk is over
+	var localOnly []*object.Commit
+	for _, commit := range walked {
+		if flags[commit.Hash] == reachableFromLocal {
+			localOnly = append(localOnly, commit)
+		}
+	}
+	return flags, localOnly, nil
+}
+
+// commitQueue is a heap of commits ordered newest first by committer date
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)   { *q = append(*q, x.(*object.Commit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	commit := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return commit
+}
+
+// allFlagged reports whether every queued commit carries flag, i.e. nothing left to visit can
+// change the counts
+func (q commitQueue) allFlagged(flags map[plumbing.Hash]int, flag int) bool {
+	for _, commit := range q {
+		if flags[commit.Hash] != flag {
+			return false
+		}
+	}
+	return true
+}
+
+// runGitCommand runs a git command and returns its combined output as the error when it fails
+func runGitCommand(repoPath string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", string(output))
+	}
+	return nil
+}
+
+// gitCommand runs a git command and returns its standard output and standard error
+// separately. The error is an *exec.ExitError when git exited with a non-zero code.
+func gitCommand(repoPath string, args ...string) (string, string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// gitCommandOutput runs a git command and returns its trimmed standard output, or its
+// standard error as the error
+func gitCommandOutput(repoPath string, args ...string) (string, error) {
+	stdout, stderr, err := gitCommand(repoPath, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s", strings.TrimSpace(stderr))
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout), nil
+}
+
+// gitCommandOutputCode runs a git command that reports its result through the exit code, such
+// as git grep, and returns its untrimmed standard output with the exit code. Only a failure to
+// run git or an exit with a message on standard error is an error.
+func gitCommandOutputCode(repoPath string, args ...string) (string, int, error) {
+	stdout, stderr, err := gitCommand(repoPath, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", 0, err
+		}
+		if message := strings.TrimSpace(stderr); message != "" {
+			return "", exitErr.ExitCode(), fmt.Errorf("%s", message)
+		}
+		return stdout, exitErr.ExitCode(), nil
+	}
+
+	return stdout, 0, nil
+}
@@ -0,0 +1,411 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// backends are the GitBackend implementations the conformance tests run against
+var backends = []struct {
+	name    string
+	backend GitBackend
+}{
+	{name: BackendExec, backend: ExecBackend{}},
+	{name: BackendGoGit, backend: GoGitBackend{}},
+}
+
+func TestBackendCurrentBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) string
+		want  string
+	}{
+		{
+			name:  "should return the checked out branch",
+			setup: func(t *testing.T) string { return createTestGitRepo(t, "main") },
+			want:  "main",
+		},
+		{
+			name: "should return a branch with a slash in its name",
+			setup: func(t *testing.T) string {
+				repo := createTestGitRepo(t, "main")
+				runGit(t, repo, "checkout", "--quiet", "-b", "feature/login")
+				return repo
+			},
+			want: "feature/login",
+		},
+		{
+			name: "should return empty string when HEAD is detached",
+			setup: func(t *testing.T) string {
+				repo := createTestGitRepo(t, "main")
+				runGit(t, repo, "checkout", "--quiet", "--detach")
+				return repo
+			},
+			want: "",
+		},
+		{
+			name: "should return the branch of a repository without commits",
+			setup: func(t *testing.T) string {
+				repo := filepath.Join(t.TempDir(), "empty")
+				runGit(t, "", "init", "--quiet", "--initial-branch", "trunk", repo)
+				return repo
+			},
+			want: "trunk",
+		},
+		{
+			name: "should return the branch of a linked worktree",
+			setup: func(t *testing.T) string {
+				repo := createTestGitRepo(t, "main")
+				worktree := filepath.Join(t.TempDir(), "worktree")
+				runGit(t, repo, "worktree", "add", "--quiet", "-b", "hotfix", worktree)
+				return worktree
+			},
+			want: "hotfix",
+		},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				repo := tt.setup(t)
+
+				got, err := b.backend.CurrentBranch(repo)
+				if err != nil {
+					t.Fatalf("CurrentBranch() error = %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("CurrentBranch() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestBackendResolveRevision(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	head := runGit(t, clone, "rev-parse", "HEAD")
+	commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+	runGit(t, clone, "fetch", "--quiet")
+	remoteHead := runGit(t, clone, "rev-parse", "origin/main")
+	runGit(t, clone, "tag", "v1.0.0")
+	runGit(t, clone, "tag", "--annotate", "--message", "Release", "v1.1.0", "origin/main")
+	runGit(t, clone, "branch", "packed", "origin/main")
+	runGit(t, clone, "pack-refs", "--all")
+
+	tests := []struct {
+		name     string
+		revision string
+		want     string
+		wantErr  bool
+	}{
+		{name: "should resolve HEAD", revision: "HEAD", want: head},
+		{name: "should resolve a branch name", revision: "main", want: head},
+		{name: "should resolve a full branch reference", revision: "refs/heads/main", want: head},
+		{name: "should resolve a remote-tracking branch", revision: "refs/remotes/origin/main", want: remoteHead},
+		{name: "should resolve a short remote-tracking branch", revision: "origin/main", want: remoteHead},
+		{name: "should resolve a packed branch", revision: "refs/heads/packed", want: remoteHead},
+		{name: "should resolve a lightweight tag", revision: "v1.0.0", want: head},
+		{name: "should peel an annotated tag to its commit", revision: "v1.1.0", want: remoteHead},
+		{name: "should resolve a full commit ID", revision: head, want: head},
+		{name: "should fail for a missing branch", revision: "refs/heads/missing", wantErr: true},
+		{name: "should fail for a missing commit ID", revision: strings.Repeat("1", 40), wantErr: true},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				got, err := b.backend.ResolveRevision(clone, tt.revision)
+				if tt.wantErr {
+					if !errors.Is(err, ErrUnknownRevision) {
+						t.Errorf("ResolveRevision(%q) error = %v, want ErrUnknownRevision", tt.revision, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("ResolveRevision(%q) error = %v", tt.revision, err)
+				}
+				if got != tt.want {
+					t.Errorf("ResolveRevision(%q) = %s, want %s", tt.revision, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestBackendAheadBehind(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+	runGit(t, clone, "fetch", "--quiet")
+	commitFile(t, clone, "one.txt", "one", "First local")
+	commitFile(t, clone, "two.txt", "two", "Second local")
+	runGit(t, clone, "branch", "merged", "origin/main")
+	runGit(t, clone, "checkout", "--quiet", "merged")
+	runGit(t, clone, "merge", "--quiet", "--no-edit", "main")
+	runGit(t, clone, "checkout", "--quiet", "main")
+
+	tests := []struct {
+		name       string
+		local      string
+		upstream   string
+		wantAhead  int
+		wantBehind int
+		wantErr    bool
+	}{
+		{name: "should count both sides of diverged branches", local: "main", upstream: "origin/main", wantAhead: 2, wantBehind: 1},
+		{name: "should count the other way around", local: "origin/main", upstream: "main", wantAhead: 1, wantBehind: 2},
+		{name: "should count nothing for the same revision", local: "main", upstream: "HEAD", wantAhead: 0, wantBehind: 0},
+		{name: "should count a merge commit and nothing behind", local: "merged", upstream: "main", wantAhead: 2, wantBehind: 0},
+		{name: "should fail for a missing revision", local: "main", upstream: "refs/heads/missing", wantErr: true},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ahead, behind, err := b.backend.AheadBehind(clone, tt.local, tt.upstream)
+				if tt.wantErr {
+					if err == nil {
+						t.Errorf("AheadBehind() = %d, %d, want error", ahead, behind)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("AheadBehind() error = %v", err)
+				}
+				if ahead != tt.wantAhead || behind != tt.wantBehind {
+					t.Errorf("AheadBehind(%s, %s) = %d, %d, want %d, %d",
+						tt.local, tt.upstream, ahead, behind, tt.wantAhead, tt.wantBehind)
+				}
+			})
+		}
+	}
+}
+
+func TestBackendAheadBehindShallowClone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	// Only the commits down to the merge base may be read: older ones are missing in a shallow clone
+	upstream := createTestGitRepo(t, "main")
+	for i := 0; i < 5; i++ {
+		commitFile(t, upstream, "test.txt", strings.Repeat("x", i+1), "Upstream change")
+	}
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, "", "clone", "--quiet", "--depth", "2", "file://"+upstream, clone)
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test User")
+	commitFile(t, clone, "local.txt", "local", "Local change")
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ahead, behind, err := b.backend.AheadBehind(clone, "main", "origin/main")
+			if err != nil {
+				t.Fatalf("AheadBehind() error = %v", err)
+			}
+			if ahead != 1 || behind != 0 {
+				t.Errorf("AheadBehind() = %d, %d, want 1, 0", ahead, behind)
+			}
+		})
+	}
+}
+
+func TestBackendQueries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	commitFile(t, repo, "README.md", "# Test\n", "Add readme")
+	base := runGit(t, repo, "rev-parse", "HEAD")
+	if err := os.MkdirAll(filepath.Join(repo, "docs"), 0755); err != nil {
+		t.Fatalf("failed to create docs: %v", err)
+	}
+	commitFile(t, repo, "docs/.gitattributes", "*.png binary\n", "Add docs attributes")
+	commitFile(t, repo, "docs/guide.md", "one\ntwo\n", "Add guide\n\nWith a body.")
+	commitFile(t, repo, "test.txt", "changed", "Change test file")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			commits, err := b.backend.Log(repo, base+"..HEAD", 0)
+			if err != nil {
+				t.Fatalf("Log() error = %v", err)
+			}
+			want := []Commit{
+				{SHA: shortSHA(head), Author: "Test User", Subject: "Change test file"},
+				{SHA: commits[1].SHA, Author: "Test User", Subject: "Add guide"},
+				{SHA: commits[2].SHA, Author: "Test User", Subject: "Add docs attributes"},
+			}
+			if !reflect.DeepEqual(commits, want) {
+				t.Errorf("Log() = %+v, want %+v", commits, want)
+			}
+			commits, err = b.backend.Log(repo, base+"..HEAD", 1)
+			if err != nil || len(commits) != 1 || commits[0].Subject != "Change test file" {
+				t.Errorf("Log() with a limit = %+v, %v, want the newest commit only", commits, err)
+			}
+
+			count, err := b.backend.CountCommits(repo, "HEAD", base)
+			if err != nil || count != 3 {
+				t.Errorf("CountCommits(HEAD, %s) = %d, %v, want 3", base, count, err)
+			}
+			count, err = b.backend.CountCommits(repo, "HEAD")
+			if err != nil || count != 5 {
+				t.Errorf("CountCommits(HEAD) = %d, %v, want 5", count, err)
+			}
+
+			files, insertions, deletions, err := b.backend.DiffStat(repo, base, "HEAD")
+			if err != nil || files != 3 || insertions != 4 || deletions != 1 {
+				t.Errorf("DiffStat() = %d, %d, %d, %v, want 3, 4, 1", files, insertions, deletions, err)
+			}
+
+			for _, tt := range []struct {
+				pathspecs []string
+				want      []string
+			}{
+				{pathspecs: nil, want: []string{"README.md", "docs/.gitattributes", "docs/guide.md", "test.txt"}},
+				{pathspecs: []string{":(glob)**/.gitattributes"}, want: []string{"docs/.gitattributes"}},
+				{pathspecs: []string{"docs"}, want: []string{"docs/.gitattributes", "docs/guide.md"}},
+				{pathspecs: []string{"*.md"}, want: []string{"README.md", "docs/guide.md"}},
+				{pathspecs: []string{":(glob)*.md"}, want: []string{"README.md"}},
+				{pathspecs: []string{"test.txt", "missing"}, want: []string{"test.txt"}},
+			} {
+				files, err := b.backend.TrackedFiles(repo, tt.pathspecs...)
+				if err != nil {
+					t.Fatalf("TrackedFiles(%q) error = %v", tt.pathspecs, err)
+				}
+				if !reflect.DeepEqual(files, tt.want) {
+					t.Errorf("TrackedFiles(%q) = %q, want %q", tt.pathspecs, files, tt.want)
+				}
+			}
+
+			dirty, err := b.backend.HasUncommittedChanges(repo)
+			if err != nil || dirty {
+				t.Errorf("HasUncommittedChanges() on a clean checkout = %v, %v", dirty, err)
+			}
+			writeFile(t, repo, "test.txt", "edited")
+			dirty, err = b.backend.HasUncommittedChanges(repo)
+			if err != nil || !dirty {
+				t.Errorf("HasUncommittedChanges() after an edit = %v, %v", dirty, err)
+			}
+			runGit(t, repo, "checkout", "--", "test.txt")
+		})
+	}
+}
+
+func TestBackendBranches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	base := runGit(t, clone, "rev-parse", "HEAD")
+	commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+	runGit(t, clone, "fetch", "--quiet")
+
+	runGit(t, clone, "checkout", "--quiet", "-b", "gone")
+	runGit(t, clone, "push", "--quiet", "-u", "origin", "gone")
+	runGit(t, upstream, "branch", "-D", "gone")
+	runGit(t, clone, "fetch", "--quiet", "--prune")
+
+	runGit(t, clone, "checkout", "--quiet", "--track", "-b", "local", "main")
+	commitFile(t, clone, "local.txt", "local", "Local change")
+	local := runGit(t, clone, "rev-parse", "HEAD")
+
+	runGit(t, clone, "branch", "unknown-remote", "main")
+	runGit(t, clone, "config", "branch.unknown-remote.remote", "missing")
+	runGit(t, clone, "config", "branch.unknown-remote.merge", "refs/heads/main")
+	runGit(t, clone, "checkout", "--quiet", "main")
+
+	wantBranches := []Branch{
+		{Name: "gone", SHA: base, Upstream: "refs/remotes/origin/gone", Remote: "origin", Merge: "refs/heads/gone", Gone: true},
+		{Name: "local", SHA: local, Upstream: "refs/heads/main", Remote: ".", Merge: "refs/heads/main", Ahead: 1},
+		{Name: "main", SHA: base, Upstream: "refs/remotes/origin/main", Remote: "origin", Merge: "refs/heads/main", Behind: 1},
+		{Name: "unknown-remote", SHA: base},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			branches, err := b.backend.Branches(clone)
+			if err != nil {
+				t.Fatalf("Branches() error = %v", err)
+			}
+			if !reflect.DeepEqual(branches, wantBranches) {
+				t.Errorf("Branches() = %+v, want %+v", branches, wantBranches)
+			}
+
+			merged, err := b.backend.MergedBranches(clone, "main")
+			if err != nil {
+				t.Fatalf("MergedBranches() error = %v", err)
+			}
+			if want := []string{"gone", "main", "unknown-remote"}; !reflect.DeepEqual(merged, want) {
+				t.Errorf("MergedBranches() = %q, want %q", merged, want)
+			}
+			if _, err := b.backend.MergedBranches(clone, "missing"); err == nil {
+				t.Error("MergedBranches() with a missing base succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestBackendOperations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	for _, b := range backends {
+		t.Run(b.name+"/should detect divergence through the backend", func(t *testing.T) {
+			_, clone := createDivergedClone(t)
+			ops := NewOperationsWithBackend(b.backend)
+
+			divergence, err := ops.CheckDivergence(clone, "main")
+			if err != nil {
+				t.Fatalf("CheckDivergence() error = %v", err)
+			}
+			if divergence == nil || len(divergence.LocalCommits) != 1 || divergence.RemoteCommits != 1 {
+				t.Errorf("CheckDivergence() = %v, want 1 local and 1 remote commit", divergence)
+			}
+		})
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		want    GitBackend
+		wantErr bool
+	}{
+		{name: "should default to exec", backend: "", want: ExecBackend{}},
+		{name: "should select exec", backend: BackendExec, want: ExecBackend{}},
+		{name: "should select go-git", backend: BackendGoGit, want: GoGitBackend{}},
+		{name: "should reject unknown backends", backend: "libgit2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBackend(tt.backend)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewBackend(%q) error = %v, wantErr %v", tt.backend, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NewBackend(%q) = %#v, want %#v", tt.backend, got, tt.want)
+			}
+		})
+	}
+}
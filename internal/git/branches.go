@@ -0,0 +1,290 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Reasons a local branch is considered stale
+const (
+	BranchMerged       = "merged"
+	BranchUpstreamGone = "upstream gone"
+)
+
+// StaleBranch is a local branch that prune can delete
+type StaleBranch struct {
+	Name   string
+	SHA    string
+	Reason string // BranchMerged or BranchUpstreamGone
+}
+
+// FindStaleBranches fetches from origin with --prune and lists the local branches that are
+// merged into the default branch or whose upstream branch no longer exists. The default
+// branch, the current branch and branches matching a protected pattern are never listed.
+func (o *Operations) FindStaleBranches(repo Repository, defaultBranch string, protected []string) ([]StaleBranch, error) {
+	if err := o.executeGitCommand(repo.Path, "fetch", "--prune", "origin"); err != nil {
+		_, message := o.handleGitError(err.Error(), "fetch", "")
+		return nil, fmt.Errorf("%s", message)
+	}
+
+	currentBranch, err := o.getCurrentBranch(repo.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merged into origin's default branch when it exists, so a stale local copy doesn't matter
+	base := "refs/remotes/origin/" + defaultBranch
+	if _, err := o.backend.ResolveRevision(repo.Path, base); err != nil {
+		base = "refs/heads/" + defaultBranch
+	}
+
+	mergedBranches, err := o.backend.MergedBranches(repo.Path, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merged branches: %w", err)
+	}
+	merged := make(map[string]bool)
+	for _, name := range mergedBranches {
+		merged[name] = true
+	}
+
+	branches, err := o.backend.Branches(repo.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	var stale []StaleBranch
+	for _, branch := range branches {
+		if branch.Name == defaultBranch || branch.Name == currentBranch || isProtectedBranch(branch.Name, protected) {
+			continue
+		}
+
+		switch {
+		case merged[branch.Name]:
+			stale = append(stale, StaleBranch{Name: branch.Name, SHA: branch.SHA, Reason: BranchMerged})
+		case branch.Gone:
+			stale = append(stale, StaleBranch{Name: branch.Name, SHA: branch.SHA, Reason: BranchUpstreamGone})
+		}
+	}
+
+	return stale, nil
+}
+
+// DeleteBranches force-deletes the given local branches. Branches whose upstream is gone may
+// hold commits that were squash-merged, which plain "git branch -d" would refuse.
+func (o *Operations) DeleteBranches(repo Repository, branches []StaleBranch) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	var deleted, failed []string
+	for _, branch := range branches {
+		if err := o.executeGitCommand(repo.Path, "branch", "-D", branch.Name); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", branch.Name, strings.TrimSpace(err.Error())))
+			continue
+		}
+		deleted = append(deleted, branch.Name)
+	}
+	result.DeletedBranches = deleted
+
+	if len(failed) > 0 {
+		result.Error = fmt.Errorf("failed to delete %d branches: %s", len(failed), strings.Join(failed, "; "))
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Deleted %d branches", len(deleted))
+	return result
+}
+
+// isProtectedBranch reports whether a branch matches one of the glob patterns, e.g. "release/*"
+func isProtectedBranch(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrBranchNotFound is returned by SwitchBranch for repositories without the branch
+var ErrBranchNotFound = errors.New("branch not found")
+
+// BranchInfo describes a local branch and how it compares to its upstream
+type BranchInfo struct {
+	Name     string
+	Current  bool
+	Upstream string // Empty when the branch tracks nothing
+	Gone     bool   // The upstream branch no longer exists
+	Ahead    int
+	Behind   int
+}
+
+// CreateFeatureBranch updates the default branch from origin and creates the branch from it,
+// leaving the new branch checked out. Repositories with uncommitted changes are skipped, as
+// during sync.
+func (o *Operations) CreateFeatureBranch(repo Repository, name, defaultBranch string) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	if _, err := o.backend.ResolveRevision(repo.Path, "refs/heads/"+name); err == nil {
+		result.Error = fmt.Errorf("branch '%s' already exists", name)
+		result.Message = fmt.Sprintf("Branch '%s' already exists", name)
+		return result
+	}
+
+	if dirty, err := o.hasUncommittedChanges(repo.Path); err != nil || dirty {
+		result.Error, result.Message = o.dirtyError(err)
+		return result
+	}
+
+	update := o.UpdateBranchInPlace(repo, defaultBranch, false)
+	if !update.Success {
+		return update
+	}
+
+	if err := o.executeGitCommand(repo.Path, "switch", "--quiet", "--no-track", "-c", name, defaultBranch); err != nil {
+		result.Error, result.Message = o.handleGitError(err.Error(), "switch", defaultBranch)
+		return result
+	}
+
+	result.Success = true
+	result.CurrentBranch = name
+	result.OldHead = update.OldHead
+	result.NewHead = update.NewHead
+	result.Message = fmt.Sprintf("Created '%s' from '%s' (%s)", name, defaultBranch, shortSHA(update.NewHead))
+	if !update.UpToDate && update.OldHead != "" {
+		result.Message += fmt.Sprintf(" after updating '%s'", defaultBranch)
+	}
+	return result
+}
+
+// SwitchBranch checks out an existing branch, creating it from origin's branch of the same
+// name when only that exists. The error is ErrBranchNotFound when neither exists.
+// Repositories with uncommitted changes are skipped, as during sync.
+func (o *Operations) SwitchBranch(repo Repository, name string) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	currentBranch, err := o.getCurrentBranch(repo.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to get current branch: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+	if currentBranch == name {
+		result.Success = true
+		result.UpToDate = true
+		result.CurrentBranch = name
+		result.Message = fmt.Sprintf("Already on '%s'", name)
+		return result
+	}
+
+	_, localErr := o.backend.ResolveRevision(repo.Path, "refs/heads/"+name)
+	_, remoteErr := o.backend.ResolveRevision(repo.Path, "refs/remotes/origin/"+name)
+	if localErr != nil && remoteErr != nil {
+		result.Error = ErrBranchNotFound
+		result.Message = fmt.Sprintf("Branch '%s' does not exist", name)
+		return result
+	}
+
+	if dirty, err := o.hasUncommittedChanges(repo.Path); err != nil || dirty {
+		result.Error, result.Message = o.dirtyError(err)
+		return result
+	}
+
+	if err := o.executeGitCommand(repo.Path, "switch", "--quiet", name); err != nil {
+		result.Error, result.Message = o.handleGitError(err.Error(), "switch", name)
+		return result
+	}
+
+	result.Success = true
+	result.CurrentBranch = name
+	if localErr != nil {
+		result.Message = fmt.Sprintf("Switched from '%s' to '%s', tracking 'origin/%s'", currentBranch, name, name)
+	} else {
+		result.Message = fmt.Sprintf("Switched from '%s' to '%s'", currentBranch, name)
+	}
+	return result
+}
+
+// ListBranches returns the local branches matching pattern, a glob such as "feature/*".
+// A pattern without wildcards matches branches containing it; an empty one matches all.
+func (o *Operations) ListBranches(repoPath, pattern string) ([]BranchInfo, error) {
+	currentBranch, err := o.getCurrentBranch(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	all, err := o.backend.Branches(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	var branches []BranchInfo
+	for _, branch := range all {
+		if !matchesBranchPattern(branch.Name, pattern) {
+			continue
+		}
+		branches = append(branches, BranchInfo{
+			Name:     branch.Name,
+			Current:  branch.Name == currentBranch,
+			Upstream: shortRefName(branch.Upstream),
+			Gone:     branch.Gone,
+			Ahead:    branch.Ahead,
+			Behind:   branch.Behind,
+		})
+	}
+	return branches, nil
+}
+
+// dirtyError describes why a repository with local changes, or an unreadable status, is skipped
+func (o *Operations) dirtyError(err error) (error, string) {
+	if err != nil {
+		return fmt.Errorf("failed to read status: %w", err), fmt.Sprintf("Failed to read status: %v", err)
+	}
+	return errors.New("repository has uncommitted changes"), uncommittedChangesMessage
+}
+
+// matchesBranchPattern applies a ListBranches pattern
+func matchesBranchPattern(name, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.Contains(name, pattern)
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// parseTrack parses %(upstream:track,nobracket), e.g. "ahead 2, behind 1" or "gone"
+func parseTrack(track string) (ahead, behind int, gone bool) {
+	if track == "gone" {
+		return 0, 0, true
+	}
+	for _, part := range strings.Split(track, ",") {
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "ahead":
+			ahead = n
+		case "behind":
+			behind = n
+		}
+	}
+	return ahead, behind, false
+}
@@ -0,0 +1,359 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindStaleBranches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+
+	runGit(t, clone, "branch", "merged-feature")
+	runGit(t, clone, "branch", "release/1.0")
+
+	runGit(t, clone, "checkout", "--quiet", "-b", "gone-feature")
+	commitFile(t, clone, "gone.txt", "gone", "Squash-merged elsewhere")
+	runGit(t, clone, "push", "--quiet", "-u", "origin", "gone-feature")
+	runGit(t, upstream, "branch", "-D", "gone-feature")
+
+	runGit(t, clone, "checkout", "--quiet", "-b", "active", "main")
+	commitFile(t, clone, "active.txt", "active", "Work in progress")
+
+	runGit(t, clone, "checkout", "--quiet", "-b", "current", "main")
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ops := NewOperationsWithBackend(b.backend)
+			stale, err := ops.FindStaleBranches(Repository{Path: clone, Name: "clone"}, "main", []string{"release/*"})
+			if err != nil {
+				t.Fatalf("FindStaleBranches() unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, branch := range stale {
+				got = append(got, branch.Name+" ("+branch.Reason+")")
+			}
+			want := []string{"gone-feature (upstream gone)", "merged-feature (merged)"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FindStaleBranches() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFindStaleBranchesWithoutRemote(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+
+	_, err := NewOperations().FindStaleBranches(Repository{Path: repo, Name: "repo"}, "main", nil)
+	if err == nil || !strings.Contains(err.Error(), "not accessible") {
+		t.Errorf("FindStaleBranches() error = %v, want the fetch failure", err)
+	}
+}
+
+func TestFindStaleBranchesUnknownDefaultBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, clone := createTestClone(t)
+	runGit(t, clone, "branch", "merged-feature")
+
+	_, err := NewOperations().FindStaleBranches(Repository{Path: clone, Name: "clone"}, "trunk", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to list merged branches") {
+		t.Errorf("FindStaleBranches() error = %v, want the merged branch listing failure", err)
+	}
+}
+
+func TestDeleteBranches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	runGit(t, repo, "branch", "stale")
+
+	ops := NewOperations()
+	result := ops.DeleteBranches(Repository{Path: repo, Name: "repo"}, []StaleBranch{{Name: "stale"}})
+	if !result.Success || !reflect.DeepEqual(result.DeletedBranches, []string{"stale"}) {
+		t.Errorf("DeleteBranches() = %+v, want stale deleted", result)
+	}
+	if branches := runGit(t, repo, "branch", "--list", "stale"); branches != "" {
+		t.Errorf("branch stale still exists")
+	}
+
+	result = ops.DeleteBranches(Repository{Path: repo, Name: "repo"}, []StaleBranch{{Name: "missing"}})
+	if result.Success || result.Error == nil {
+		t.Errorf("DeleteBranches() = %+v, want failure for a missing branch", result)
+	}
+}
+
+func TestIsProtectedBranch(t *testing.T) {
+	patterns := []string{"develop", "release/*"}
+
+	tests := []struct {
+		branch string
+		want   bool
+	}{
+		{branch: "develop", want: true},
+		{branch: "release/2.1", want: true},
+		{branch: "release/2.1/hotfix", want: false},
+		{branch: "feature/release", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := isProtectedBranch(tt.branch, patterns); got != tt.want {
+				t.Errorf("isProtectedBranch(%q) = %v, want %v", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateFeatureBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name           string
+		setup          func(t *testing.T, upstream, clone string)
+		wantSuccess    bool
+		wantMsgContain string
+		wantBranch     string
+	}{
+		{
+			name: "should create the branch from the updated default branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "other")
+				commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+			},
+			wantSuccess:    true,
+			wantMsgContain: "after updating 'main'",
+			wantBranch:     "feature/x",
+		},
+		{
+			name: "should skip repositories with uncommitted changes",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, clone, "tracked.txt", "one", "Add tracked file")
+				writeFile(t, clone, "tracked.txt", "two")
+			},
+			wantMsgContain: "uncommitted changes",
+			wantBranch:     "main",
+		},
+		{
+			name: "should refuse an existing branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "branch", "feature/x")
+			},
+			wantMsgContain: "already exists",
+			wantBranch:     "main",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, clone := createTestClone(t)
+			tt.setup(t, upstream, clone)
+
+			result := NewOperations().CreateFeatureBranch(Repository{Path: clone, Name: "clone"}, "feature/x", "main")
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("CreateFeatureBranch() Success = %v, want %v (%s)", result.Success, tt.wantSuccess, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMsgContain) {
+				t.Errorf("CreateFeatureBranch() Message = %q, want to contain %q", result.Message, tt.wantMsgContain)
+			}
+			if branch := runGit(t, clone, "branch", "--show-current"); branch != tt.wantBranch {
+				t.Errorf("current branch = %q, want %q", branch, tt.wantBranch)
+			}
+			if tt.wantSuccess && runGit(t, clone, "rev-parse", "HEAD") != runGit(t, upstream, "rev-parse", "main") {
+				t.Errorf("branch was not created from the updated default branch")
+			}
+		})
+	}
+}
+
+func TestSwitchBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name           string
+		setup          func(t *testing.T, upstream, clone string)
+		wantSuccess    bool
+		wantNotFound   bool
+		wantMsgContain string
+		wantBranch     string
+	}{
+		{
+			name: "should switch to a local branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "branch", "feature/x")
+			},
+			wantSuccess:    true,
+			wantMsgContain: "Switched from 'main' to 'feature/x'",
+			wantBranch:     "feature/x",
+		},
+		{
+			name: "should track a branch that only exists on origin",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, upstream, "branch", "feature/x")
+				runGit(t, clone, "fetch", "--quiet")
+			},
+			wantSuccess:    true,
+			wantMsgContain: "tracking 'origin/feature/x'",
+			wantBranch:     "feature/x",
+		},
+		{
+			name:           "should report a missing branch",
+			setup:          func(t *testing.T, upstream, clone string) {},
+			wantNotFound:   true,
+			wantMsgContain: "does not exist",
+			wantBranch:     "main",
+		},
+		{
+			name: "should skip repositories with uncommitted changes",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "branch", "feature/x")
+				commitFile(t, clone, "tracked.txt", "one", "Add tracked file")
+				writeFile(t, clone, "tracked.txt", "two")
+			},
+			wantMsgContain: "uncommitted changes",
+			wantBranch:     "main",
+		},
+		{
+			name: "should succeed when already on the branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature/x")
+			},
+			wantSuccess:    true,
+			wantMsgContain: "Already on",
+			wantBranch:     "feature/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, clone := createTestClone(t)
+			tt.setup(t, upstream, clone)
+
+			result := NewOperations().SwitchBranch(Repository{Path: clone, Name: "clone"}, "feature/x")
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("SwitchBranch() Success = %v, want %v (%s)", result.Success, tt.wantSuccess, result.Message)
+			}
+			if errors.Is(result.Error, ErrBranchNotFound) != tt.wantNotFound {
+				t.Errorf("SwitchBranch() Error = %v, want ErrBranchNotFound: %v", result.Error, tt.wantNotFound)
+			}
+			if !strings.Contains(result.Message, tt.wantMsgContain) {
+				t.Errorf("SwitchBranch() Message = %q, want to contain %q", result.Message, tt.wantMsgContain)
+			}
+			if branch := runGit(t, clone, "branch", "--show-current"); branch != tt.wantBranch {
+				t.Errorf("current branch = %q, want %q", branch, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestListBranches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+
+	runGit(t, clone, "checkout", "--quiet", "-b", "feature/pushed")
+	runGit(t, clone, "push", "--quiet", "-u", "origin", "feature/pushed")
+	commitFile(t, clone, "local.txt", "local", "Local change")
+	runGit(t, upstream, "checkout", "--quiet", "feature/pushed")
+	commitFile(t, upstream, "remote1.txt", "remote", "Remote change 1")
+	commitFile(t, upstream, "remote2.txt", "remote", "Remote change 2")
+	runGit(t, clone, "fetch", "--quiet")
+	runGit(t, clone, "branch", "feature/local", "main")
+	runGit(t, clone, "branch", "bugfix/other", "main")
+
+	want := []BranchInfo{
+		{Name: "feature/local"},
+		{Name: "feature/pushed", Current: true, Upstream: "origin/feature/pushed", Ahead: 1, Behind: 2},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			branches, err := NewOperationsWithBackend(b.backend).ListBranches(clone, "feature/*")
+			if err != nil {
+				t.Fatalf("ListBranches() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(branches, want) {
+				t.Errorf("ListBranches() = %+v, want %+v", branches, want)
+			}
+		})
+	}
+}
+
+func TestMatchesBranchPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		pattern string
+		want    bool
+	}{
+		{name: "should match everything without a pattern", branch: "main", want: true},
+		{name: "should match globs", branch: "feature/login", pattern: "feature/*", want: true},
+		{name: "should not match across slashes", branch: "feature/a/b", pattern: "feature/*", want: false},
+		{name: "should match substrings without wildcards", branch: "feature/checkout-v2", pattern: "checkout", want: true},
+		{name: "should reject other branches", branch: "main", pattern: "checkout", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesBranchPattern(tt.branch, tt.pattern); got != tt.want {
+				t.Errorf("matchesBranchPattern(%q, %q) = %v, want %v", tt.branch, tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrack(t *testing.T) {
+	tests := []struct {
+		track      string
+		wantAhead  int
+		wantBehind int
+		wantGone   bool
+	}{
+		{track: ""},
+		{track: "ahead 3", wantAhead: 3},
+		{track: "behind 2", wantBehind: 2},
+		{track: "ahead 1, behind 4", wantAhead: 1, wantBehind: 4},
+		{track: "gone", wantGone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.track, func(t *testing.T) {
+			ahead, behind, gone := parseTrack(tt.track)
+			if ahead != tt.wantAhead || behind != tt.wantBehind || gone != tt.wantGone {
+				t.Errorf("parseTrack(%q) = %d, %d, %v", tt.track, ahead, behind, gone)
+			}
+		})
+	}
+}
+
+// Helper functions
+
+// writeFile writes a file without committing it
+func writeFile(t *testing.T, repoPath, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
@@ -0,0 +1,201 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoUpstream is returned by Push for branches without an upstream when not setting one, and
+// for branches whose upstream is another local branch
+var ErrNoUpstream = errors.New("branch has no upstream")
+
+// PendingCommit describes the changes a commit would record
+type PendingCommit struct {
+	Stat         string // Output of git diff --stat
+	FilesChanged int
+	Insertions   int
+	Deletions    int
+}
+
+// PendingCommit returns the changes "git commit" would record: the staged changes, or with all
+// every change to tracked files, as "git commit --all". It returns nil when there is nothing
+// to commit.
+func (o *Operations) PendingCommit(repoPath string, all bool) (*PendingCommit, error) {
+	diff := []string{"diff", "--cached"}
+	if all {
+		if _, err := o.getHead(repoPath); err == nil {
+			diff = []string{"diff", "HEAD"}
+		}
+	}
+
+	stat, err := o.gitOutput(repoPath, append(diff, "--stat")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute diffstat: %w", err)
+	}
+	if stat == "" {
+		return nil, nil
+	}
+
+	shortStat, err := o.gitOutput(repoPath, append(diff, "--shortstat")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute diffstat: %w", err)
+	}
+
+	pending := &PendingCommit{Stat: stat}
+	pending.FilesChanged, pending.Insertions, pending.Deletions = parseShortStat(shortStat)
+	return pending, nil
+}
+
+// Commit records a commit with the given message, of the staged changes or with all of every
+// change to tracked files
+func (o *Operations) Commit(repo Repository, message string, all bool) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	oldHead, _ := o.getHead(repo.Path)
+
+	args := []string{"commit", "--quiet", "-m", message}
+	if all {
+		args = append(args, "--all")
+	}
+	if err := o.executeGitCommand(repo.Path, args...); err != nil {
+		result.Error, result.Message = o.handleGitError(strings.TrimSpace(err.Error()), "commit", "")
+		return result
+	}
+
+	newHead, err := o.getHead(repo.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to read HEAD: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	result.Success = true
+	result.OldHead = oldHead
+	result.NewHead = newHead
+	result.CurrentBranch, _ = o.getCurrentBranch(repo.Path)
+	result.Message = fmt.Sprintf("Committed %s on '%s'", shortSHA(newHead), result.CurrentBranch)
+	return result
+}
+
+// Push pushes the current branch to its upstream when it has unpushed commits. A branch
+// without an upstream is pushed to origin and tracked when setUpstream is set, and skipped
+// otherwise. A branch tracking another local branch is always skipped.
+func (o *Operations) Push(repo Repository, setUpstream bool) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	branch, err := o.getCurrentBranch(repo.Path)
+	if err != nil {
+		result.Error = err
+		result.Message = err.Error()
+		return result
+	}
+	if branch == "" {
+		result.Error = fmt.Errorf("not on a branch")
+		result.Message = "Skipped: HEAD is detached"
+		return result
+	}
+	result.CurrentBranch = branch
+
+	upstream, err := o.upstreamOf(repo.Path, branch)
+	if err != nil {
+		result.Error = err
+		result.Message = err.Error()
+		return result
+	}
+	remote, merge := upstream.Remote, upstream.Merge
+
+	// "git checkout --track" from a local branch records "." as the remote; pushing there would
+	// move the local branch instead of publishing anything
+	if remote == "." {
+		result.Error = ErrNoUpstream
+		result.Message = fmt.Sprintf("Skipped: '%s' tracks the local branch '%s', not a remote branch",
+			branch, strings.TrimPrefix(merge, "refs/heads/"))
+		return result
+	}
+
+	hasUpstream := remote != "" && merge != ""
+
+	var args []string
+	switch {
+	case hasUpstream:
+		result.PushedCommits, err = o.backend.CountCommits(repo.Path, "HEAD", upstream.Upstream)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to count unpushed commits: %w", err)
+			result.Message = result.Error.Error()
+			return result
+		}
+		args = []string{"push", "--quiet", remote, "HEAD:" + merge}
+	case setUpstream:
+		result.PushedCommits, err = o.countUnpushed(repo.Path, "origin")
+		if err != nil {
+			result.Error = fmt.Errorf("failed to count unpushed commits: %w", err)
+			result.Message = result.Error.Error()
+			return result
+		}
+		args = []string{"push", "--quiet", "--set-upstream", "origin", branch}
+	default:
+		result.Error = ErrNoUpstream
+		result.Message = fmt.Sprintf("Skipped: '%s' has no upstream branch; use --set-upstream to push it to origin", branch)
+		return result
+	}
+
+	if hasUpstream && result.PushedCommits == 0 {
+		result.Success = true
+		result.UpToDate = true
+		result.Message = fmt.Sprintf("Nothing to push on '%s'", branch)
+		return result
+	}
+
+	if err := o.executeGitCommand(repo.Path, args...); err != nil {
+		output := strings.TrimSpace(err.Error())
+		if strings.Contains(output, "[rejected]") {
+			result.Error = fmt.Errorf("%s", output)
+			result.Message = fmt.Sprintf("Push of '%s' rejected: the remote has commits that are not in the local branch", branch)
+			return result
+		}
+		result.Error, result.Message = o.handleGitError(output, "push", "")
+		return result
+	}
+
+	result.Success = true
+	if !hasUpstream {
+		result.Message = fmt.Sprintf("Pushed %d commits to new branch 'origin/%s'", result.PushedCommits, branch)
+	} else {
+		result.Message = fmt.Sprintf("Pushed %d commits on '%s' to '%s'", result.PushedCommits, branch, remote)
+	}
+	return result
+}
+
+// upstreamOf returns a local branch with its upstream, which is empty when it tracks nothing
+func (o *Operations) upstreamOf(repoPath, name string) (Branch, error) {
+	branches, err := o.backend.Branches(repoPath)
+	if err != nil {
+		return Branch{}, fmt.Errorf("failed to list branches: %w", err)
+	}
+	for _, branch := range branches {
+		if branch.Name == name {
+			return branch, nil
+		}
+	}
+	return Branch{Name: name}, nil
+}
+
+// countUnpushed counts the commits of HEAD that are on none of the remote's branches
+func (o *Operations) countUnpushed(repoPath, remote string) (int, error) {
+	remoteBranches, err := RemoteBranches(repoPath, remote)
+	if err != nil {
+		return 0, err
+	}
+	exclude := make([]string, 0, len(remoteBranches))
+	for _, branch := range remoteBranches {
+		exclude = append(exclude, "refs/remotes/"+remote+"/"+branch)
+	}
+	return o.backend.CountCommits(repoPath, "HEAD", exclude...)
+}
@@ -0,0 +1,215 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPendingCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, repo string)
+		all       bool
+		wantNil   bool
+		wantFiles int
+		wantStat  string
+	}{
+		{
+			name:    "should report nothing for a clean repository",
+			setup:   func(t *testing.T, repo string) {},
+			all:     true,
+			wantNil: true,
+		},
+		{
+			name: "should ignore unstaged changes without all",
+			setup: func(t *testing.T, repo string) {
+				writeFile(t, repo, "test.txt", "changed\n")
+			},
+			wantNil: true,
+		},
+		{
+			name: "should include unstaged changes to tracked files with all",
+			setup: func(t *testing.T, repo string) {
+				writeFile(t, repo, "test.txt", "changed\n")
+				writeFile(t, repo, "untracked.txt", "new\n")
+			},
+			all:       true,
+			wantFiles: 1,
+			wantStat:  "test.txt",
+		},
+		{
+			name: "should report staged changes",
+			setup: func(t *testing.T, repo string) {
+				writeFile(t, repo, "go.mod", "module x\n")
+				runGit(t, repo, "add", "go.mod")
+			},
+			wantFiles: 1,
+			wantStat:  "go.mod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := createTestGitRepo(t, "main")
+			tt.setup(t, repo)
+
+			pending, err := NewOperations().PendingCommit(repo, tt.all)
+			if err != nil {
+				t.Fatalf("PendingCommit() unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if pending != nil {
+					t.Errorf("PendingCommit() = %+v, want nil", pending)
+				}
+				return
+			}
+			if pending == nil {
+				t.Fatalf("PendingCommit() = nil, want changes")
+			}
+			if pending.FilesChanged != tt.wantFiles || !strings.Contains(pending.Stat, tt.wantStat) {
+				t.Errorf("PendingCommit() = %+v, want %d files including %s", pending, tt.wantFiles, tt.wantStat)
+			}
+		})
+	}
+}
+
+func TestCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	writeFile(t, repo, "test.txt", "changed\n")
+	writeFile(t, repo, "untracked.txt", "new\n")
+
+	result := NewOperations().Commit(Repository{Path: repo, Name: "repo"}, "Bump version", true)
+	if !result.Success {
+		t.Fatalf("Commit() failed: %s", result.Message)
+	}
+	if result.NewHead == result.OldHead || result.NewHead != runGit(t, repo, "rev-parse", "HEAD") {
+		t.Errorf("Commit() heads = %s -> %s, want the new commit", result.OldHead, result.NewHead)
+	}
+	if subject := runGit(t, repo, "log", "-1", "--format=%s"); subject != "Bump version" {
+		t.Errorf("commit subject = %q, want %q", subject, "Bump version")
+	}
+	if status := runGit(t, repo, "status", "--porcelain"); status != "?? untracked.txt" {
+		t.Errorf("status = %q, want only the untracked file left", status)
+	}
+}
+
+func TestPush(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name           string
+		setup          func(t *testing.T, upstream, clone string)
+		setUpstream    bool
+		wantSuccess    bool
+		wantUpToDate   bool
+		wantNoUpstream bool
+		wantCommits    int
+		wantMsgContain string
+	}{
+		{
+			name: "should push unpushed commits to the upstream branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, clone, "a.txt", "a", "First")
+				commitFile(t, clone, "b.txt", "b", "Second")
+			},
+			wantSuccess:    true,
+			wantCommits:    2,
+			wantMsgContain: "Pushed 2 commits on 'main' to 'origin'",
+		},
+		{
+			name:           "should skip branches without unpushed commits",
+			setup:          func(t *testing.T, upstream, clone string) {},
+			wantSuccess:    true,
+			wantUpToDate:   true,
+			wantMsgContain: "Nothing to push",
+		},
+		{
+			name: "should skip branches without an upstream",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature/x")
+				commitFile(t, clone, "a.txt", "a", "First")
+			},
+			wantNoUpstream: true,
+			wantMsgContain: "--set-upstream",
+		},
+		{
+			name: "should skip branches tracking a local branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature/x", "--track", "main")
+				commitFile(t, clone, "a.txt", "a", "First")
+			},
+			setUpstream:    true,
+			wantNoUpstream: true,
+			wantMsgContain: "tracks the local branch 'main'",
+		},
+		{
+			name: "should push and track new branches with set upstream",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature/x")
+				commitFile(t, clone, "a.txt", "a", "First")
+			},
+			setUpstream:    true,
+			wantSuccess:    true,
+			wantCommits:    1,
+			wantMsgContain: "new branch 'origin/feature/x'",
+		},
+		{
+			name: "should report rejected pushes",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, upstream, "checkout", "--quiet", "-b", "other")
+				runGit(t, upstream, "checkout", "--quiet", "main")
+				commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+				runGit(t, upstream, "checkout", "--quiet", "other")
+				commitFile(t, clone, "a.txt", "a", "First")
+			},
+			wantCommits:    1,
+			wantMsgContain: "rejected",
+		},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				upstream, clone := createTestClone(t)
+				// A bare-like upstream: pushing to its checked-out branch would be refused
+				runGit(t, upstream, "checkout", "--quiet", "--detach")
+				tt.setup(t, upstream, clone)
+
+				result := NewOperationsWithBackend(b.backend).Push(Repository{Path: clone, Name: "clone"}, tt.setUpstream)
+				if result.Success != tt.wantSuccess || result.UpToDate != tt.wantUpToDate {
+					t.Fatalf("Push() Success = %v, UpToDate = %v, want %v, %v (%s)",
+						result.Success, result.UpToDate, tt.wantSuccess, tt.wantUpToDate, result.Message)
+				}
+				if errors.Is(result.Error, ErrNoUpstream) != tt.wantNoUpstream {
+					t.Errorf("Push() Error = %v, want ErrNoUpstream: %v", result.Error, tt.wantNoUpstream)
+				}
+				if result.PushedCommits != tt.wantCommits {
+					t.Errorf("Push() PushedCommits = %d, want %d", result.PushedCommits, tt.wantCommits)
+				}
+				if !strings.Contains(result.Message, tt.wantMsgContain) {
+					t.Errorf("Push() Message = %q, want to contain %q", result.Message, tt.wantMsgContain)
+				}
+				if tt.wantNoUpstream && runGit(t, clone, "rev-parse", "main") != runGit(t, upstream, "rev-parse", "main") {
+					t.Errorf("local main was moved by a skipped push")
+				}
+				if tt.wantSuccess && !tt.wantUpToDate {
+					branch := runGit(t, clone, "branch", "--show-current")
+					if runGit(t, upstream, "rev-parse", branch) != runGit(t, clone, "rev-parse", "HEAD") {
+						t.Errorf("upstream %s was not updated", branch)
+					}
+				}
+			})
+		}
+	}
+}
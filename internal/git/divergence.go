@@ -0,0 +1,176 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oddjob23/go-cli/pkg/config"
+)
+
+// Policies for a local branch that has commits origin does not have, as configured with
+// "divergencePolicy" in config.json
+const (
+	DivergenceSkip   = config.DivergenceSkip
+	DivergenceBackup = config.DivergenceBackup
+	DivergenceRebase = config.DivergenceRebase
+)
+
+// DivergencePolicies lists the accepted divergence policies
+var DivergencePolicies = config.DivergencePolicies
+
+// ErrDiverged is the error of a sync skipped because the branch has diverged from origin
+var ErrDiverged = errors.New("branch has diverged from origin")
+
+// Divergence describes a local branch with commits its origin counterpart does not have
+type Divergence struct {
+	Branch        string
+	Upstream      string   // e.g. "origin/main"
+	LocalCommits  []Commit // Only on the local branch, newest first
+	RemoteCommits int      // Only on origin
+}
+
+// String summarizes the divergence, e.g. "'main' has 2 commits not on 'origin/main'"
+func (d *Divergence) String() string {
+	s := fmt.Sprintf("'%s' has %d commits not on '%s'", d.Branch, len(d.LocalCommits), d.Upstream)
+	if d.RemoteCommits > 0 {
+		s += fmt.Sprintf(", which has %d new commits", d.RemoteCommits)
+	}
+	return s
+}
+
+// CheckDivergence fetches the branch from origin and compares it with the local branch.
+// It returns nil when the local branch has no commits of its own, so a pull can only
+// fast-forward, or when either branch does not exist.
+func (o *Operations) CheckDivergence(repoPath, branch string) (*Divergence, error) {
+	local := "refs/heads/" + branch
+	remote := "refs/remotes/origin/" + branch
+	if _, err := o.backend.ResolveRevision(repoPath, local); err != nil {
+		return nil, nil
+	}
+
+	if err := o.backend.Fetch(repoPath, "origin", branch); err != nil {
+		_, message := o.handleGitError(err.Error(), "fetch", branch)
+		return nil, fmt.Errorf("%s", message)
+	}
+	if _, err := o.backend.ResolveRevision(repoPath, remote); err != nil {
+		return nil, nil
+	}
+
+	localCommits, remoteCommits, err := o.backend.AheadBehind(repoPath, local, remote)
+	if err != nil {
+		return nil, err
+	}
+	if localCommits == 0 {
+		return nil, nil
+	}
+
+	commits, err := o.backend.Log(repoPath, remote+".."+local, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local commits: %w", err)
+	}
+
+	return &Divergence{
+		Branch:        branch,
+		Upstream:      "origin/" + branch,
+		LocalCommits:  commits,
+		RemoteCommits: remoteCommits,
+	}, nil
+}
+
+// ResolveDivergence applies a divergence policy. With DivergenceSkip, or when the policy
+// cannot be applied, the result fails with ErrDiverged and the repository is unchanged.
+// Otherwise the local branch is left at or on top of origin, ready for a fast-forward pull.
+// Rebasing requires the branch to be checked out.
+func (o *Operations) ResolveDivergence(repo Repository, divergence *Divergence, policy string) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+		Divergence: divergence,
+	}
+
+	skip := func(reason string) OperationResult {
+		result.Error = ErrDiverged
+		result.Message = fmt.Sprintf("Skipped: %s", divergence)
+		if reason != "" {
+			result.Message += "; " + reason
+		}
+		return result
+	}
+
+	currentBranch, err := o.getCurrentBranch(repo.Path)
+	if err != nil {
+		result.Error = err
+		result.Message = err.Error()
+		return result
+	}
+
+	switch policy {
+	case DivergenceBackup:
+		if currentBranch == divergence.Branch {
+			if dirty, err := o.hasUncommittedChanges(repo.Path); err != nil || dirty {
+				return skip("uncommitted changes prevent resetting it")
+			}
+		}
+
+		backup, err := o.backupBranchName(repo.Path, divergence.Branch, time.Now())
+		if err != nil {
+			result.Error = err
+			result.Message = err.Error()
+			return result
+		}
+		if err := o.executeGitCommand(repo.Path, "branch", backup, divergence.Branch); err != nil {
+			result.Error, result.Message = o.handleGitError(err.Error(), "branch", "")
+			return result
+		}
+
+		if currentBranch == divergence.Branch {
+			err = o.executeGitCommand(repo.Path, "reset", "--hard", "--quiet", divergence.Upstream)
+		} else {
+			err = o.executeGitCommand(repo.Path, "branch", "--force", divergence.Branch, divergence.Upstream)
+		}
+		if err != nil {
+			result.Error, result.Message = o.handleGitError(err.Error(), "reset", "")
+			return result
+		}
+
+		result.Success = true
+		result.Message = fmt.Sprintf("Moved %d local commits of '%s' to '%s' and reset it to '%s'",
+			len(divergence.LocalCommits), divergence.Branch, backup, divergence.Upstream)
+		return result
+
+	case DivergenceRebase:
+		if currentBranch != divergence.Branch {
+			return skip(fmt.Sprintf("rebasing needs '%s' checked out", divergence.Branch))
+		}
+		if dirty, err := o.hasUncommittedChanges(repo.Path); err != nil || dirty {
+			return skip("uncommitted changes prevent rebasing it")
+		}
+
+		if err := o.executeGitCommand(repo.Path, "rebase", "--quiet", divergence.Upstream); err != nil {
+			_ = o.executeGitCommand(repo.Path, "rebase", "--abort")
+			return skip("rebasing onto it conflicts")
+		}
+
+		result.Success = true
+		result.Message = fmt.Sprintf("Rebased %d local commits of '%s' onto '%s'",
+			len(divergence.LocalCommits), divergence.Branch, divergence.Upstream)
+		return result
+
+	default:
+		return skip("")
+	}
+}
+
+// backupBranchName returns backup/<branch>-<date>, adding a counter when that branch exists
+func (o *Operations) backupBranchName(repoPath, branch string, now time.Time) (string, error) {
+	base := fmt.Sprintf("backup/%s-%s", branch, now.Format("2006-01-02"))
+	name := base
+	for i := 2; i < 100; i++ {
+		if _, err := o.backend.ResolveRevision(repoPath, "refs/heads/"+name); err != nil {
+			return name, nil
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return "", fmt.Errorf("too many backup branches named %s", base)
+}
@@ -0,0 +1,319 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createDivergedClone returns a clone whose main has one local commit while origin/main
+// gained one commit on a different file
+func createDivergedClone(t *testing.T) (upstream string, clone string) {
+	t.Helper()
+
+	upstream, clone = createTestClone(t)
+	commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+	commitFile(t, clone, "local.txt", "local", "Local change")
+	return upstream, clone
+}
+
+func TestCheckDivergence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name          string
+		setup         func(t *testing.T, upstream, clone string)
+		wantDiverged  bool
+		wantLocal     int
+		wantRemote    int
+		wantFirstName string
+	}{
+		{
+			name: "should report nothing when the branch is behind origin",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+			},
+			wantDiverged: false,
+		},
+		{
+			name: "should report local commits when the branch is ahead of origin",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, clone, "one.txt", "one", "First local")
+				commitFile(t, clone, "two.txt", "two", "Second local")
+			},
+			wantDiverged:  true,
+			wantLocal:     2,
+			wantRemote:    0,
+			wantFirstName: "Second local",
+		},
+		{
+			name: "should report both sides when the branches diverged",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+				commitFile(t, clone, "local.txt", "local", "Local change")
+			},
+			wantDiverged:  true,
+			wantLocal:     1,
+			wantRemote:    1,
+			wantFirstName: "Local change",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, clone := createTestClone(t)
+			tt.setup(t, upstream, clone)
+
+			divergence, err := NewOperations().CheckDivergence(clone, "main")
+			if err != nil {
+				t.Fatalf("CheckDivergence() error = %v", err)
+			}
+			if (divergence != nil) != tt.wantDiverged {
+				t.Fatalf("CheckDivergence() = %v, want diverged %v", divergence, tt.wantDiverged)
+			}
+			if divergence == nil {
+				return
+			}
+			if len(divergence.LocalCommits) != tt.wantLocal || divergence.RemoteCommits != tt.wantRemote {
+				t.Errorf("CheckDivergence() = %d local, %d remote, want %d, %d",
+					len(divergence.LocalCommits), divergence.RemoteCommits, tt.wantLocal, tt.wantRemote)
+			}
+			if divergence.LocalCommits[0].Subject != tt.wantFirstName {
+				t.Errorf("CheckDivergence() newest local commit = %q, want %q", divergence.LocalCommits[0].Subject, tt.wantFirstName)
+			}
+			if divergence.Upstream != "origin/main" {
+				t.Errorf("CheckDivergence() Upstream = %q, want origin/main", divergence.Upstream)
+			}
+		})
+	}
+}
+
+func TestCheckDivergenceMissingBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, clone := createTestClone(t)
+	divergence, err := NewOperations().CheckDivergence(clone, "develop")
+	if err != nil || divergence != nil {
+		t.Errorf("CheckDivergence() for a missing branch = %v, %v, want nil, nil", divergence, err)
+	}
+}
+
+func TestResolveDivergence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	ops := NewOperations()
+
+	t.Run("should leave the repository alone when skipping", func(t *testing.T) {
+		_, clone := createDivergedClone(t)
+		head := runGit(t, clone, "rev-parse", "HEAD")
+		divergence, err := ops.CheckDivergence(clone, "main")
+		if err != nil || divergence == nil {
+			t.Fatalf("CheckDivergence() = %v, %v", divergence, err)
+		}
+
+		result := ops.ResolveDivergence(Repository{Path: clone, Name: "clone"}, divergence, DivergenceSkip)
+		if result.Success || !errors.Is(result.Error, ErrDiverged) {
+			t.Errorf("ResolveDivergence() = %+v, want ErrDiverged", result)
+		}
+		if !strings.HasPrefix(result.Message, "Skipped: 'main' has 1 commits not on 'origin/main'") {
+			t.Errorf("ResolveDivergence() Message = %q", result.Message)
+		}
+		if got := runGit(t, clone, "rev-parse", "HEAD"); got != head {
+			t.Errorf("ResolveDivergence() moved HEAD to %s", got)
+		}
+	})
+
+	t.Run("should keep the commits on a backup branch and reset to origin", func(t *testing.T) {
+		_, clone := createDivergedClone(t)
+		head := runGit(t, clone, "rev-parse", "HEAD")
+		divergence, _ := ops.CheckDivergence(clone, "main")
+
+		result := ops.ResolveDivergence(Repository{Path: clone, Name: "clone"}, divergence, DivergenceBackup)
+		if !result.Success {
+			t.Fatalf("ResolveDivergence() failed: %s", result.Message)
+		}
+
+		backup := "backup/main-" + time.Now().Format("2006-01-02")
+		if got := runGit(t, clone, "rev-parse", backup); got != head {
+			t.Errorf("%s = %s, want %s", backup, got, head)
+		}
+		if got, want := runGit(t, clone, "rev-parse", "main"), runGit(t, clone, "rev-parse", "origin/main"); got != want {
+			t.Errorf("main = %s, want origin/main %s", got, want)
+		}
+		if !strings.Contains(result.Message, backup) {
+			t.Errorf("ResolveDivergence() Message = %q, want it to name %s", result.Message, backup)
+		}
+	})
+
+	t.Run("should move the branch without checking it out when resetting", func(t *testing.T) {
+		_, clone := createDivergedClone(t)
+		divergence, _ := ops.CheckDivergence(clone, "main")
+		runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+
+		result := ops.ResolveDivergence(Repository{Path: clone, Name: "clone"}, divergence, DivergenceBackup)
+		if !result.Success {
+			t.Fatalf("ResolveDivergence() failed: %s", result.Message)
+		}
+		if got, want := runGit(t, clone, "rev-parse", "main"), runGit(t, clone, "rev-parse", "origin/main"); got != want {
+			t.Errorf("main = %s, want origin/main %s", got, want)
+		}
+		if branch := runGit(t, clone, "branch", "--show-current"); branch != "feature" {
+			t.Errorf("current branch = %s, want feature", branch)
+		}
+	})
+
+	t.Run("should skip resetting when the working tree is dirty", func(t *testing.T) {
+		_, clone := createDivergedClone(t)
+		head := runGit(t, clone, "rev-parse", "HEAD")
+		divergence, _ := ops.CheckDivergence(clone, "main")
+		writeFile(t, clone, "local.txt", "uncommitted")
+
+		result := ops.ResolveDivergence(Repository{Path: clone, Name: "clone"}, divergence, DivergenceBackup)
+		if result.Success || !errors.Is(result.Error, ErrDiverged) {
+			t.Errorf("ResolveDivergence() = %+v, want ErrDiverged", result)
+		}
+		if got := runGit(t, clone, "rev-parse", "HEAD"); got != head {
+			t.Errorf("ResolveDivergence() moved HEAD to %s", got)
+		}
+	})
+
+	t.Run("should rebase the local commits onto origin", func(t *testing.T) {
+		_, clone := createDivergedClone(t)
+		divergence, _ := ops.CheckDivergence(clone, "main")
+
+		result := ops.ResolveDivergence(Repository{Path: clone, Name: "clone"}, divergence, DivergenceRebase)
+		if !result.Success {
+			t.Fatalf("ResolveDivergence() failed: %s", result.Message)
+		}
+		if count := runGit(t, clone, "rev-list", "--count", "main..origin/main"); count != "0" {
+			t.Errorf("main is %s commits behind origin/main after rebase", count)
+		}
+		if subject := runGit(t, clone, "log", "-1", "--format=%s"); subject != "Local change" {
+			t.Errorf("HEAD subject = %q, want the local commit", subject)
+		}
+	})
+
+	t.Run("should abort and skip when rebasing conflicts", func(t *testing.T) {
+		upstream, clone := createTestClone(t)
+		commitFile(t, upstream, "test.txt", "remote", "Remote change")
+		commitFile(t, clone, "test.txt", "local", "Local change")
+		head := runGit(t, clone, "rev-parse", "HEAD")
+		divergence, _ := ops.CheckDivergence(clone, "main")
+
+		result := ops.ResolveDivergence(Repository{Path: clone, Name: "clone"}, divergence, DivergenceRebase)
+		if result.Success || !errors.Is(result.Error, ErrDiverged) {
+			t.Errorf("ResolveDivergence() = %+v, want ErrDiverged", result)
+		}
+		if got := runGit(t, clone, "rev-parse", "HEAD"); got != head {
+			t.Errorf("HEAD = %s after aborted rebase, want %s", got, head)
+		}
+		if status := runGit(t, clone, "status", "--porcelain"); status != "" {
+			t.Errorf("working tree not clean after aborted rebase: %q", status)
+		}
+	})
+}
+
+func TestSyncRepositoryDiverged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, clone := createDivergedClone(t)
+	syncer := &Syncer{operations: NewOperations()}
+
+	result := syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "main", SyncOptions{})
+	if result.Success || !errors.Is(result.Error, ErrDiverged) {
+		t.Fatalf("SyncRepository() with skip policy = %+v, want ErrDiverged", result)
+	}
+	if merges := runGit(t, clone, "rev-list", "--merges", "--count", "HEAD"); merges != "0" {
+		t.Errorf("SyncRepository() created %s merge commits", merges)
+	}
+
+	result = syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "main", SyncOptions{DivergencePolicy: DivergenceRebase})
+	if !result.Success {
+		t.Fatalf("SyncRepository() with rebase policy failed: %s", result.Message)
+	}
+	if result.Divergence == nil || !strings.HasPrefix(result.DivergenceMessage, "Rebased 1 local commits") {
+		t.Errorf("SyncRepository() Divergence = %v, DivergenceMessage = %q", result.Divergence, result.DivergenceMessage)
+	}
+}
+
+func TestSyncRepositoryDivergedNonMainBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	runGit(t, upstream, "branch", "develop")
+	runGit(t, clone, "fetch", "--quiet")
+	runGit(t, clone, "branch", "--track", "develop", "origin/develop")
+	mainHead := runGit(t, clone, "rev-parse", "main")
+
+	runGit(t, upstream, "checkout", "--quiet", "develop")
+	commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+	runGit(t, upstream, "checkout", "--quiet", "main")
+	runGit(t, clone, "checkout", "--quiet", "develop")
+	commitFile(t, clone, "local.txt", "local", "Local change")
+	runGit(t, clone, "checkout", "--quiet", "main")
+
+	syncer := &Syncer{operations: NewOperations()}
+
+	result := syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "develop", SyncOptions{})
+	if result.Success || !errors.Is(result.Error, ErrDiverged) {
+		t.Fatalf("SyncRepository() with skip policy = %+v, want ErrDiverged", result)
+	}
+	if !strings.Contains(result.Message, "'develop' has 1 commits not on 'origin/develop'") {
+		t.Errorf("SyncRepository() Message = %q", result.Message)
+	}
+	if branch := runGit(t, clone, "branch", "--show-current"); branch != "main" {
+		t.Errorf("current branch = %s after skipping, want main", branch)
+	}
+
+	result = syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "develop", SyncOptions{DivergencePolicy: DivergenceRebase})
+	if !result.Success {
+		t.Fatalf("SyncRepository() with rebase policy failed: %s", result.Message)
+	}
+	if branch := runGit(t, clone, "branch", "--show-current"); branch != "develop" {
+		t.Errorf("current branch = %s, want develop", branch)
+	}
+	if !strings.Contains(result.Message, "'develop'") {
+		t.Errorf("SyncRepository() Message = %q, want it to name develop", result.Message)
+	}
+	if count := runGit(t, clone, "rev-list", "--count", "develop..origin/develop"); count != "0" {
+		t.Errorf("develop is %s commits behind origin/develop", count)
+	}
+	if subject := runGit(t, clone, "log", "-1", "--format=%s", "develop"); subject != "Local change" {
+		t.Errorf("develop tip = %q, want the rebased local commit", subject)
+	}
+	if got := runGit(t, clone, "rev-parse", "main"); got != mainHead {
+		t.Errorf("main moved to %s, want %s", got, mainHead)
+	}
+}
+
+func TestBackupBranchName(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	ops := NewOperations()
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	for _, want := range []string{"backup/main-2024-03-09", "backup/main-2024-03-09-2", "backup/main-2024-03-09-3"} {
+		got, err := ops.backupBranchName(repo, "main", now)
+		if err != nil {
+			t.Fatalf("backupBranchName() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("backupBranchName() = %q, want %q", got, want)
+		}
+		runGit(t, repo, "branch", got)
+	}
+}
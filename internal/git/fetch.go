@@ -0,0 +1,243 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FetchOptions controls what Fetch retrieves
+type FetchOptions struct {
+	Prune      bool // Delete remote-tracking branches that no longer exist on the remote
+	Tags       bool // Fetch all tags, not only those pointing into fetched history
+	AllRemotes bool // Fetch every remote instead of origin
+}
+
+// Kinds of RefUpdate
+const (
+	RefCreated  = "created"
+	RefUpdated  = "updated"
+	RefForced   = "forced" // Updated to a commit that does not contain the old one
+	RefDeleted  = "deleted"
+	RefRejected = "rejected"
+)
+
+// RefUpdate is a reference changed by a fetch
+type RefUpdate struct {
+	Ref  string // Short name, e.g. "origin/main" or "v1.2.0"
+	Kind string
+	Old  string // Empty when created
+	New  string // Empty when deleted
+}
+
+// porcelainFetchVersion is the first git release supporting "git fetch --porcelain"
+var porcelainFetchVersion = [2]int{2, 41}
+
+var (
+	porcelainFetchOnce      sync.Once
+	porcelainFetchSupported bool
+)
+
+// Fetch updates the remote-tracking references of a repository without touching the working
+// tree or any local branch, and reports the references that changed
+func (o *Operations) Fetch(repo Repository, opts FetchOptions) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	args := []string{"fetch"}
+	if opts.Prune {
+		args = append(args, "--prune")
+	}
+	if opts.Tags {
+		args = append(args, "--tags")
+	}
+
+	porcelain := supportsPorcelainFetch()
+	if porcelain {
+		args = append(args, "--porcelain")
+	}
+	if opts.AllRemotes {
+		args = append(args, "--all")
+	} else {
+		args = append(args, "origin")
+	}
+
+	// Rejected updates are listed even when the fetch fails, so parse before checking err
+	stdout, stderr, err := gitCommand(repo.Path, args...)
+	if porcelain {
+		result.RefUpdates = parsePorcelainFetch(stdout)
+	} else {
+		result.RefUpdates = parseFetchSummary(stderr)
+	}
+
+	if err != nil {
+		output := strings.TrimSpace(stderr)
+		if output == "" {
+			output = err.Error()
+		}
+		result.Error, result.Message = o.handleGitError(output, "fetch", "")
+		for _, update := range result.RefUpdates {
+			if update.Kind == RefRejected {
+				result.Message = fmt.Sprintf("Fetch rejected the update of '%s'", update.Ref)
+				break
+			}
+		}
+		return result
+	}
+
+	result.Success = true
+	result.UpToDate = len(result.RefUpdates) == 0
+	result.Message = describeRefUpdates(result.RefUpdates)
+	return result
+}
+
+// describeRefUpdates summarizes fetched references, e.g. "2 updated, 1 created"
+func describeRefUpdates(updates []RefUpdate) string {
+	if len(updates) == 0 {
+		return "Already up to date"
+	}
+
+	counts := make(map[string]int)
+	for _, update := range updates {
+		counts[update.Kind]++
+	}
+
+	var parts []string
+	for _, kind := range []string{RefUpdated, RefForced, RefCreated, RefDeleted, RefRejected} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return "Fetched: " + strings.Join(parts, ", ")
+}
+
+// parsePorcelainFetch parses "git fetch --porcelain" lines: "<flag> <old> <new> <local ref>"
+func parsePorcelainFetch(output string) []RefUpdate {
+	var updates []RefUpdate
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		fields := strings.Fields(line[2:])
+		if len(fields) != 3 {
+			continue
+		}
+
+		kind := refUpdateKind(line[0])
+		if kind == "" {
+			continue
+		}
+		update := RefUpdate{Ref: shortRefName(fields[2]), Kind: kind, Old: fields[0], New: fields[1]}
+		if isZeroObjectID(update.Old) {
+			update.Old = ""
+		}
+		if isZeroObjectID(update.New) {
+			update.New = ""
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// parseFetchSummary parses the reference lines git fetch prints to stderr when --porcelain
+// is not available, e.g. "   1a2b3c4..5d6e7f8  main       -> origin/main"
+func parseFetchSummary(output string) []RefUpdate {
+	var updates []RefUpdate
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 4 || line[0] != ' ' || line[2] != ' ' {
+			continue
+		}
+		kind := refUpdateKind(line[1])
+		if kind == "" {
+			continue
+		}
+
+		summary, ref, found := strings.Cut(strings.TrimSpace(line[3:]), "-> ")
+		if !found {
+			continue
+		}
+		// The local reference may be followed by a reason, e.g. "(forced update)"
+		ref, _, _ = strings.Cut(strings.TrimSpace(ref), " ")
+
+		update := RefUpdate{Ref: ref, Kind: kind}
+		summary = strings.Fields(summary)[0]
+		if oldSHA, newSHA, ok := strings.Cut(summary, "..."); ok {
+			update.Old, update.New = oldSHA, newSHA
+		} else if oldSHA, newSHA, ok := strings.Cut(summary, ".."); ok {
+			update.Old, update.New = oldSHA, newSHA
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// refUpdateKind maps the flag git fetch prints for a reference, ignoring unchanged ones
+func refUpdateKind(flag byte) string {
+	switch flag {
+	case ' ':
+		return RefUpdated
+	case '+':
+		return RefForced
+	case '*':
+		return RefCreated
+	case '-':
+		return RefDeleted
+	case 't':
+		return RefUpdated
+	case '!':
+		return RefRejected
+	default:
+		return ""
+	}
+}
+
+// shortRefName strips the namespace of a full reference name
+func shortRefName(ref string) string {
+	for _, prefix := range []string{"refs/remotes/", "refs/tags/", "refs/heads/"} {
+		if strings.HasPrefix(ref, prefix) {
+			return strings.TrimPrefix(ref, prefix)
+		}
+	}
+	return ref
+}
+
+// isZeroObjectID reports whether an object ID is all zeros, which fetch uses for missing refs
+func isZeroObjectID(id string) bool {
+	return strings.Trim(id, "0") == ""
+}
+
+// supportsPorcelainFetch reports whether the installed git understands "fetch --porcelain"
+func supportsPorcelainFetch() bool {
+	porcelainFetchOnce.Do(func() {
+		output, err := gitCommandOutput("", "version")
+		if err != nil {
+			return
+		}
+		porcelainFetchSupported = versionAtLeast(output, porcelainFetchVersion)
+	})
+	return porcelainFetchSupported
+}
+
+// versionAtLeast parses "git version 2.41.0" style output and compares major and minor numbers
+func versionAtLeast(output string, minimum [2]int) bool {
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return false
+	}
+	parts := strings.SplitN(fields[2], ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	return major > minimum[0] || major == minimum[0] && minor >= minimum[1]
+}
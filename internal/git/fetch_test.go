@@ -0,0 +1,136 @@
+package git
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	runGit(t, upstream, "branch", "stale")
+	runGit(t, clone, "fetch", "--quiet")
+
+	commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+	runGit(t, upstream, "branch", "feature/x")
+	runGit(t, upstream, "branch", "-D", "stale")
+	runGit(t, upstream, "tag", "v1.0.0")
+	commitFile(t, clone, "local.txt", "local", "Local change")
+	localHead := runGit(t, clone, "rev-parse", "HEAD")
+
+	result := NewOperations().Fetch(Repository{Path: clone, Name: "clone"}, FetchOptions{Prune: true, Tags: true})
+	if !result.Success {
+		t.Fatalf("Fetch() failed: %s", result.Message)
+	}
+
+	var got []string
+	for _, update := range result.RefUpdates {
+		got = append(got, update.Kind+" "+update.Ref)
+	}
+	sort.Strings(got)
+	want := []string{"created origin/feature/x", "created v1.0.0", "deleted origin/stale", "updated origin/main"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fetch() RefUpdates = %q, want %q", got, want)
+	}
+	if !strings.Contains(result.Message, "1 updated, 2 created, 1 deleted") {
+		t.Errorf("Fetch() Message = %q", result.Message)
+	}
+	if head := runGit(t, clone, "rev-parse", "HEAD"); head != localHead {
+		t.Errorf("Fetch() moved HEAD to %s", head)
+	}
+
+	again := NewOperations().Fetch(Repository{Path: clone, Name: "clone"}, FetchOptions{Prune: true, Tags: true})
+	if !again.Success || !again.UpToDate || len(again.RefUpdates) != 0 {
+		t.Errorf("second Fetch() = %+v, want up to date", again)
+	}
+}
+
+func TestFetchFailure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	result := NewOperations().Fetch(Repository{Path: repo, Name: "repo"}, FetchOptions{})
+	if result.Success || result.Error == nil {
+		t.Errorf("Fetch() without an origin remote = %+v, want failure", result)
+	}
+}
+
+func TestParsePorcelainFetch(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	a, b := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	output := strings.Join([]string{
+		"  " + a + " " + b + " refs/remotes/origin/main",
+		"+ " + a + " " + b + " refs/remotes/origin/rewritten",
+		"* " + zero + " " + b + " refs/tags/v1.0.0",
+		"- " + a + " " + zero + " refs/remotes/origin/old",
+		"! " + a + " " + b + " refs/tags/v0.9.0",
+		"= " + a + " " + a + " refs/remotes/origin/same",
+		"",
+	}, "\n")
+
+	want := []RefUpdate{
+		{Ref: "origin/main", Kind: RefUpdated, Old: a, New: b},
+		{Ref: "origin/rewritten", Kind: RefForced, Old: a, New: b},
+		{Ref: "v1.0.0", Kind: RefCreated, New: b},
+		{Ref: "origin/old", Kind: RefDeleted, Old: a},
+		{Ref: "v0.9.0", Kind: RefRejected, Old: a, New: b},
+	}
+	if got := parsePorcelainFetch(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePorcelainFetch() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseFetchSummary(t *testing.T) {
+	output := `From github.com:org/orders
+   1a2b3c4..5d6e7f8  main       -> origin/main
+ + 1111111...2222222 rewritten  -> origin/rewritten  (forced update)
+ * [new branch]      feature/x  -> origin/feature/x
+ * [new tag]         v1.0.0     -> v1.0.0
+ - [deleted]         (none)     -> origin/old
+ ! [rejected]        v0.9.0     -> v0.9.0  (would clobber existing tag)
+ t [tag update]      latest     -> latest
+error: some local refs could not be updated
+`
+
+	want := []RefUpdate{
+		{Ref: "origin/main", Kind: RefUpdated, Old: "1a2b3c4", New: "5d6e7f8"},
+		{Ref: "origin/rewritten", Kind: RefForced, Old: "1111111", New: "2222222"},
+		{Ref: "origin/feature/x", Kind: RefCreated},
+		{Ref: "v1.0.0", Kind: RefCreated},
+		{Ref: "origin/old", Kind: RefDeleted},
+		{Ref: "v0.9.0", Kind: RefRejected},
+		{Ref: "latest", Kind: RefUpdated},
+	}
+	if got := parseFetchSummary(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFetchSummary() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{output: "git version 2.39.5", want: false},
+		{output: "git version 2.41.0", want: true},
+		{output: "git version 2.45.1.windows.1", want: true},
+		{output: "git version 3.0.0", want: true},
+		{output: "git version 1.99.0", want: false},
+		{output: "garbage", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			if got := versionAtLeast(tt.output, [2]int{2, 41}); got != tt.want {
+				t.Errorf("versionAtLeast(%q) = %v, want %v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
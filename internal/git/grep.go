@@ -0,0 +1,105 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GrepOptions configures a git grep search
+type GrepOptions struct {
+	Pattern          string
+	Pathspecs        []string // Limits the search, e.g. "*.go" or "internal/"
+	Ref              string   // Searches this revision instead of the worktree, e.g. "origin/main"
+	IgnoreCase       bool
+	WordRegexp       bool
+	FilesWithMatches bool // Only list the matching files
+	Count            bool // Only count the matching lines per file
+}
+
+// GrepMatch is a matching line, or a matching file when listing files or counting
+type GrepMatch struct {
+	Path  string `json:"path"` // Relative to the repository root
+	Line  int    `json:"line,omitempty"`
+	Text  string `json:"text,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+// Grep runs git grep in a repository. Binary files are skipped. No matches is not an error.
+func (o *Operations) Grep(repoPath string, opts GrepOptions) ([]GrepMatch, error) {
+	args := []string{"grep", "-z", "-I", "--no-color", "--full-name"}
+	switch {
+	case opts.FilesWithMatches:
+		args = append(args, "-l")
+	case opts.Count:
+		args = append(args, "-c")
+	default:
+		args = append(args, "-n")
+	}
+	if opts.IgnoreCase {
+		args = append(args, "-i")
+	}
+	if opts.WordRegexp {
+		args = append(args, "-w")
+	}
+	args = append(args, "-e", opts.Pattern)
+	if opts.Ref != "" {
+		args = append(args, opts.Ref)
+	}
+	args = append(args, "--")
+	args = append(args, opts.Pathspecs...)
+
+	output, code, err := gitCommandOutputCode(repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	// git grep exits with 1 when nothing matches and 128 on errors
+	switch code {
+	case 0:
+		return parseGrepOutput(output, opts), nil
+	case 1:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("git grep exited with code %d", code)
+	}
+}
+
+// parseGrepOutput parses the NUL-separated output of git grep -z. Files searched in a
+// revision are prefixed with "<ref>:", which is removed.
+func parseGrepOutput(output string, opts GrepOptions) []GrepMatch {
+	trimRef := func(path string) string {
+		if opts.Ref != "" {
+			return strings.TrimPrefix(path, opts.Ref+":")
+		}
+		return path
+	}
+
+	var matches []GrepMatch
+	if opts.FilesWithMatches {
+		for _, path := range strings.Split(output, "\x00") {
+			if path = strings.TrimSpace(path); path != "" {
+				matches = append(matches, GrepMatch{Path: trimRef(path)})
+			}
+		}
+		return matches
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		switch {
+		case opts.Count && len(fields) == 2:
+			count, err := strconv.Atoi(fields[1])
+			if err != nil {
+				continue
+			}
+			matches = append(matches, GrepMatch{Path: trimRef(fields[0]), Count: count})
+		case !opts.Count && len(fields) == 3:
+			number, err := strconv.Atoi(fields[1])
+			if err != nil {
+				continue
+			}
+			matches = append(matches, GrepMatch{Path: trimRef(fields[0]), Line: number, Text: fields[2]})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	runGit(t, upstream, "checkout", "--quiet", "-b", "other")
+	commitFile(t, upstream, "client.go", "callLegacy()\n// LegacyCheckout\n", "Add client")
+	runGit(t, upstream, "checkout", "--quiet", "main")
+	runGit(t, clone, "fetch", "--quiet")
+	commitFile(t, clone, "client.go", "callLegacy()\nlegacy := true\n", "Add client")
+	commitFile(t, clone, "notes.md", "Legacy endpoints\n", "Add notes")
+
+	tests := []struct {
+		name string
+		opts GrepOptions
+		want []GrepMatch
+	}{
+		{
+			name: "should report matching lines with repository-relative paths",
+			opts: GrepOptions{Pattern: "Legacy"},
+			want: []GrepMatch{
+				{Path: "client.go", Line: 1, Text: "callLegacy()"},
+				{Path: "notes.md", Line: 1, Text: "Legacy endpoints"},
+			},
+		},
+		{
+			name: "should match case-insensitively and whole words",
+			opts: GrepOptions{Pattern: "legacy", IgnoreCase: true, WordRegexp: true},
+			want: []GrepMatch{
+				{Path: "client.go", Line: 2, Text: "legacy := true"},
+				{Path: "notes.md", Line: 1, Text: "Legacy endpoints"},
+			},
+		},
+		{
+			name: "should limit the search to pathspecs",
+			opts: GrepOptions{Pattern: "Legacy", IgnoreCase: true, Pathspecs: []string{"*.md"}},
+			want: []GrepMatch{{Path: "notes.md", Line: 1, Text: "Legacy endpoints"}},
+		},
+		{
+			name: "should list matching files",
+			opts: GrepOptions{Pattern: "legacy", IgnoreCase: true, FilesWithMatches: true},
+			want: []GrepMatch{{Path: "client.go"}, {Path: "notes.md"}},
+		},
+		{
+			name: "should count matching lines",
+			opts: GrepOptions{Pattern: "legacy", IgnoreCase: true, Count: true},
+			want: []GrepMatch{{Path: "client.go", Count: 2}, {Path: "notes.md", Count: 1}},
+		},
+		{
+			name: "should search a revision instead of the worktree",
+			opts: GrepOptions{Pattern: "LegacyCheckout", Ref: "origin/other"},
+			want: []GrepMatch{{Path: "client.go", Line: 2, Text: "// LegacyCheckout"}},
+		},
+		{
+			name: "should return nothing without matches",
+			opts: GrepOptions{Pattern: "no-such-text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewOperations().Grep(clone, tt.opts)
+			if err != nil {
+				t.Fatalf("Grep() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Grep() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepUnknownRef(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	_, err := NewOperations().Grep(repo, GrepOptions{Pattern: "x", Ref: "origin/missing"})
+	if err == nil || !strings.Contains(err.Error(), "origin/missing") {
+		t.Errorf("Grep() error = %v, want one naming the unknown revision", err)
+	}
+}
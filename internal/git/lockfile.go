@@ -0,0 +1,216 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// lockfileVersion is written to new lockfiles and is the only version LoadLockfile accepts
+const lockfileVersion = 1
+
+// Lockfile pins the checked-out revision of every repository in a workspace
+type Lockfile struct {
+	Version      int                `json:"version"`
+	Created      time.Time          `json:"created"`
+	Repositories []LockedRepository `json:"repositories"`
+}
+
+// LockedRepository is the state of a repository when the lockfile was saved
+type LockedRepository struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Branch string `json:"branch,omitempty"` // Empty when HEAD was detached
+	Head   string `json:"head"`
+	Dirty  bool   `json:"dirty,omitempty"` // Tracked files had uncommitted changes, which are not recorded
+}
+
+// Kinds of LockfileChange
+const (
+	LockfileAdded   = "added"
+	LockfileRemoved = "removed"
+	LockfileMoved   = "moved"
+	LockfileBranch  = "branch changed" // Same commit, different branch
+)
+
+// LockfileChange is a repository whose state differs between two lockfiles
+type LockfileChange struct {
+	Name string
+	Kind string
+	From *LockedRepository // Nil when added
+	To   *LockedRepository // Nil when removed
+}
+
+// NewLockfile creates an empty lockfile stamped with the current time
+func NewLockfile() *Lockfile {
+	return &Lockfile{Version: lockfileVersion, Created: time.Now().UTC().Truncate(time.Second)}
+}
+
+// LoadLockfile reads a lockfile written by Save
+func LoadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("lockfile %s not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lockfile Lockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+	if lockfile.Version != lockfileVersion {
+		return nil, fmt.Errorf("lockfile %s has unsupported version %d", path, lockfile.Version)
+	}
+	return &lockfile, nil
+}
+
+// Save writes the lockfile as indented JSON
+func (l *Lockfile) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Find returns the entry of the named repository, or nil
+func (l *Lockfile) Find(name string) *LockedRepository {
+	for i := range l.Repositories {
+		if l.Repositories[i].Name == name {
+			return &l.Repositories[i]
+		}
+	}
+	return nil
+}
+
+// DiffLockfiles lists the repositories whose state differs from a to b, in the order of a
+// followed by the repositories only b has
+func DiffLockfiles(a, b *Lockfile) []LockfileChange {
+	var changes []LockfileChange
+	for i := range a.Repositories {
+		from := &a.Repositories[i]
+		to := b.Find(from.Name)
+		switch {
+		case to == nil:
+			changes = append(changes, LockfileChange{Name: from.Name, Kind: LockfileRemoved, From: from})
+		case to.Head != from.Head:
+			changes = append(changes, LockfileChange{Name: from.Name, Kind: LockfileMoved, From: from, To: to})
+		case to.Branch != from.Branch:
+			changes = append(changes, LockfileChange{Name: from.Name, Kind: LockfileBranch, From: from, To: to})
+		}
+	}
+	for i := range b.Repositories {
+		if to := &b.Repositories[i]; a.Find(to.Name) == nil {
+			changes = append(changes, LockfileChange{Name: to.Name, Kind: LockfileAdded, To: to})
+		}
+	}
+	return changes
+}
+
+// LockRepository records the checked-out branch, HEAD and dirty flag of a repository
+func (o *Operations) LockRepository(repo Repository) (LockedRepository, error) {
+	locked := LockedRepository{Name: repo.Name, Path: repo.Path}
+
+	head, err := o.getHead(repo.Path)
+	if err != nil {
+		return locked, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	locked.Head = head
+
+	if locked.Branch, err = o.getCurrentBranch(repo.Path); err != nil {
+		return locked, err
+	}
+	if locked.Dirty, err = o.hasUncommittedChanges(repo.Path); err != nil {
+		return locked, fmt.Errorf("failed to read status: %w", err)
+	}
+	return locked, nil
+}
+
+// CheckoutLocked checks out the commit recorded in a lockfile. The recorded branch is checked
+// out when it still points at the commit; otherwise the commit is checked out on tempBranch,
+// created or reset for the purpose, or as a detached HEAD when tempBranch is empty. The commit
+// is fetched from origin when missing. Repositories with uncommitted changes are refused.
+func (o *Operations) CheckoutLocked(repo Repository, locked LockedRepository, tempBranch string) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	oldHead, err := o.getHead(repo.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to read HEAD: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+	result.OldHead = oldHead
+	currentBranch, _ := o.getCurrentBranch(repo.Path)
+
+	onRecordedBranch := locked.Branch != "" && currentBranch == locked.Branch
+	if oldHead == locked.Head && (onRecordedBranch || locked.Branch == "" && currentBranch == "") {
+		result.Success = true
+		result.UpToDate = true
+		result.NewHead = oldHead
+		result.CurrentBranch = currentBranch
+		result.Message = fmt.Sprintf("Already at %s", shortSHA(oldHead))
+		return result
+	}
+
+	if dirty, err := o.hasUncommittedChanges(repo.Path); err != nil || dirty {
+		result.Error, result.Message = o.dirtyError(err)
+		return result
+	}
+
+	if !o.hasCommit(repo.Path, locked.Head) {
+		if err := o.executeGitCommand(repo.Path, "fetch", "--quiet", "origin"); err != nil {
+			result.Error, result.Message = o.handleGitError(err.Error(), "fetch", "")
+			return result
+		}
+		if !o.hasCommit(repo.Path, locked.Head) {
+			result.Error = fmt.Errorf("commit %s not found", locked.Head)
+			result.Message = fmt.Sprintf("Commit %s not found, even after fetching from origin", shortSHA(locked.Head))
+			return result
+		}
+	}
+
+	var args []string
+	switch {
+	case locked.Branch != "" && o.branchPointsAt(repo.Path, locked.Branch, locked.Head):
+		args = []string{"switch", "--quiet", locked.Branch}
+		result.CurrentBranch = locked.Branch
+		result.Message = fmt.Sprintf("Checked out '%s' at %s", locked.Branch, shortSHA(locked.Head))
+	case tempBranch != "":
+		args = []string{"switch", "--quiet", "--no-track", "-C", tempBranch, locked.Head}
+		result.CurrentBranch = tempBranch
+		result.Message = fmt.Sprintf("Checked out %s on temporary branch '%s'", shortSHA(locked.Head), tempBranch)
+	default:
+		args = []string{"switch", "--quiet", "--detach", locked.Head}
+		result.Message = fmt.Sprintf("Checked out %s as a detached HEAD", shortSHA(locked.Head))
+	}
+
+	if err := o.executeGitCommand(repo.Path, args...); err != nil {
+		result.CurrentBranch = ""
+		result.Error, result.Message = o.handleGitError(err.Error(), "switch", "")
+		return result
+	}
+
+	result.Success = true
+	result.NewHead = locked.Head
+	return result
+}
+
+// hasCommit reports whether a commit exists in the repository
+func (o *Operations) hasCommit(repoPath, sha string) bool {
+	_, err := o.backend.ResolveRevision(repoPath, sha)
+	return err == nil
+}
+
+// branchPointsAt reports whether a local branch exists and points at the commit
+func (o *Operations) branchPointsAt(repoPath, branch, sha string) bool {
+	head, err := o.backend.ResolveRevision(repoPath, "refs/heads/"+branch)
+	return err == nil && head == sha
+}
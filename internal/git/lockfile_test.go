@@ -0,0 +1,221 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLockfileSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.lock.json")
+
+	lockfile := NewLockfile()
+	lockfile.Repositories = []LockedRepository{
+		{Name: "orders", Path: "/src/orders", Branch: "main", Head: "1111111111"},
+		{Name: "auth", Path: "/src/auth", Head: "2222222222", Dirty: true},
+	}
+	if err := lockfile.Save(path); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	loaded, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("LoadLockfile() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, lockfile) {
+		t.Errorf("LoadLockfile() = %+v, want %+v", loaded, lockfile)
+	}
+}
+
+func TestLoadLockfileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name           string
+		content        string
+		wantErrContain string
+	}{
+		{name: "should reject invalid JSON", content: "{", wantErrContain: "failed to parse"},
+		{name: "should reject other versions", content: `{"version": 2}`, wantErrContain: "unsupported version 2"},
+		{name: "should report missing files", wantErrContain: "not found"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.Repeat("x", i+1)+".json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatalf("failed to write lockfile: %v", err)
+				}
+			}
+
+			_, err := LoadLockfile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrContain) {
+				t.Errorf("LoadLockfile() error = %v, want to contain %q", err, tt.wantErrContain)
+			}
+		})
+	}
+}
+
+func TestDiffLockfiles(t *testing.T) {
+	a := &Lockfile{Repositories: []LockedRepository{
+		{Name: "orders", Branch: "main", Head: "1"},
+		{Name: "auth", Branch: "main", Head: "2"},
+		{Name: "gateway", Branch: "main", Head: "3"},
+		{Name: "legacy", Branch: "main", Head: "4"},
+	}}
+	b := &Lockfile{Repositories: []LockedRepository{
+		{Name: "payments", Branch: "main", Head: "5"},
+		{Name: "gateway", Branch: "main", Head: "3"},
+		{Name: "auth", Branch: "feature/x", Head: "2"},
+		{Name: "orders", Head: "6"},
+	}}
+
+	var got []string
+	for _, change := range DiffLockfiles(a, b) {
+		got = append(got, change.Name+": "+change.Kind)
+	}
+
+	want := []string{
+		"orders: " + LockfileMoved,
+		"auth: " + LockfileBranch,
+		"legacy: " + LockfileRemoved,
+		"payments: " + LockfileAdded,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLockfiles() = %q, want %q", got, want)
+	}
+}
+
+func TestLockRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "feature/x")
+	writeFile(t, repo, "test.txt", "changed")
+
+	locked, err := NewOperations().LockRepository(Repository{Path: repo, Name: "repo"})
+	if err != nil {
+		t.Fatalf("LockRepository() unexpected error: %v", err)
+	}
+
+	want := LockedRepository{Name: "repo", Path: repo, Branch: "feature/x", Head: runGit(t, repo, "rev-parse", "HEAD"), Dirty: true}
+	if locked != want {
+		t.Errorf("LockRepository() = %+v, want %+v", locked, want)
+	}
+}
+
+func TestCheckoutLocked(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name           string
+		setup          func(t *testing.T, upstream, clone string) LockedRepository
+		tempBranch     string
+		wantSuccess    bool
+		wantUpToDate   bool
+		wantBranch     string
+		wantMsgContain string
+	}{
+		{
+			name: "should check out the recorded branch when it points at the commit",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				head := runGit(t, clone, "rev-parse", "HEAD")
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature/x")
+				commitFile(t, clone, "a.txt", "a", "Move on")
+				return LockedRepository{Branch: "main", Head: head}
+			},
+			wantSuccess:    true,
+			wantBranch:     "main",
+			wantMsgContain: "Checked out 'main'",
+		},
+		{
+			name: "should detach HEAD when the branch moved",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				head := runGit(t, clone, "rev-parse", "HEAD")
+				commitFile(t, clone, "a.txt", "a", "Move on")
+				return LockedRepository{Branch: "main", Head: head}
+			},
+			wantSuccess:    true,
+			wantMsgContain: "detached HEAD",
+		},
+		{
+			name: "should use the temporary branch when given",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				head := runGit(t, clone, "rev-parse", "HEAD")
+				commitFile(t, clone, "a.txt", "a", "Move on")
+				return LockedRepository{Branch: "main", Head: head}
+			},
+			tempBranch:     "snapshot/bisect",
+			wantSuccess:    true,
+			wantBranch:     "snapshot/bisect",
+			wantMsgContain: "temporary branch 'snapshot/bisect'",
+		},
+		{
+			name: "should fetch commits missing locally",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				commitFile(t, upstream, "remote.txt", "remote", "Remote change")
+				return LockedRepository{Branch: "main", Head: runGit(t, upstream, "rev-parse", "HEAD")}
+			},
+			wantSuccess:    true,
+			wantMsgContain: "detached HEAD",
+		},
+		{
+			name: "should fail for unknown commits",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				return LockedRepository{Head: strings.Repeat("ab", 20)}
+			},
+			wantBranch:     "main",
+			wantMsgContain: "not found",
+		},
+		{
+			name: "should refuse repositories with uncommitted changes",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				head := runGit(t, clone, "rev-parse", "HEAD")
+				commitFile(t, clone, "a.txt", "a", "Move on")
+				writeFile(t, clone, "a.txt", "changed")
+				return LockedRepository{Branch: "main", Head: head}
+			},
+			wantBranch:     "main",
+			wantMsgContain: "uncommitted changes",
+		},
+		{
+			name: "should do nothing when already at the recorded state",
+			setup: func(t *testing.T, upstream, clone string) LockedRepository {
+				writeFile(t, clone, "test.txt", "changed")
+				return LockedRepository{Branch: "main", Head: runGit(t, clone, "rev-parse", "HEAD")}
+			},
+			wantSuccess:    true,
+			wantUpToDate:   true,
+			wantBranch:     "main",
+			wantMsgContain: "Already at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream, clone := createTestClone(t)
+			locked := tt.setup(t, upstream, clone)
+
+			result := NewOperations().CheckoutLocked(Repository{Path: clone, Name: "clone"}, locked, tt.tempBranch)
+			if result.Success != tt.wantSuccess || result.UpToDate != tt.wantUpToDate {
+				t.Fatalf("CheckoutLocked() Success = %v, UpToDate = %v, want %v, %v (%s)",
+					result.Success, result.UpToDate, tt.wantSuccess, tt.wantUpToDate, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMsgContain) {
+				t.Errorf("CheckoutLocked() Message = %q, want to contain %q", result.Message, tt.wantMsgContain)
+			}
+			if branch := runGit(t, clone, "branch", "--show-current"); branch != tt.wantBranch {
+				t.Errorf("current branch = %q, want %q", branch, tt.wantBranch)
+			}
+			if tt.wantSuccess && runGit(t, clone, "rev-parse", "HEAD") != locked.Head {
+				t.Errorf("HEAD is not at the recorded commit %s", locked.Head)
+			}
+		})
+	}
+}
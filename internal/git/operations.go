@@ -2,13 +2,12 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
+	"strconv"
 	"strings"
 )
 
-const (
-	mainBranch = "main"
-)
+// uncommittedChangesMessage is reported for repositories skipped because of local changes
+const uncommittedChangesMessage = "Skipped: Repository has uncommitted changes. Please commit or stash changes first."
 
 // OperationResult represents the result of a Git operation
 type OperationResult struct {
@@ -16,17 +15,67 @@ type OperationResult struct {
 	Success    bool
 	Error      error
 	Message    string
+	OldHead    string // HEAD before pulling
+	NewHead    string // HEAD after pulling
+	UpToDate   bool   // True when the pull brought in no new commits
+
+	CurrentBranch string // Branch left checked out when the default branch was updated in place
+	RebaseStatus  string // One of the Rebase* statuses, empty when no rebase was requested
+	RebaseMessage string
+
+	Divergence        *Divergence // Set when the branch had commits origin does not have
+	DivergenceMessage string      // How the divergence was resolved before updating
+
+	SubmoduleStatus  string // One of the Submodules* outcomes, empty when there are no submodules or they were left alone
+	SubmoduleMessage string
+	LFSStatus        string // One of the LFS* outcomes, empty when the repository does not use LFS
+	LFSMessage       string
+
+	DeletedBranches []string    // Branches removed by DeleteBranches
+	PushedCommits   int         // Commits sent to the remote by Push
+	RefUpdates      []RefUpdate // References changed by Fetch
+}
+
+// Outcomes of rebasing the current branch onto the updated default branch
+const (
+	RebaseDone     = "rebased"
+	RebaseUpToDate = "up-to-date"
+	RebaseSkipped  = "skipped"
+	RebaseConflict = "conflict"
+)
+
+// Commit describes a single commit brought in by a pull
+type Commit struct {
+	SHA     string
+	Author  string
+	Subject string
+}
+
+// ChangeSummary describes the commits and diffstat between two revisions
+type ChangeSummary struct {
+	Commits      []Commit // Newest first, capped at the requested limit
+	TotalCommits int
+	FilesChanged int
+	Insertions   int
+	Deletions    int
 }
 
 // Operations handles Git operations on repositories
-type Operations struct{}
+type Operations struct {
+	backend GitBackend // Carries out the fetch, checkout, pull and queries of a sync
+}
 
-// NewOperations creates a new Operations instance
+// NewOperations creates a new Operations instance that runs the git binary for everything
 func NewOperations() *Operations {
-	return &Operations{}
+	return NewOperationsWithBackend(ExecBackend{})
 }
 
-// CheckoutMainBranch attempts to checkout the main branch for a repository
+// NewOperationsWithBackend creates a new Operations instance that syncs through backend
+func NewOperationsWithBackend(backend GitBackend) *Operations {
+	return &Operations{backend: backend}
+}
+
+// CheckoutMainBranch checks out the default branch of a repository and pulls it
 func (o *Operations) CheckoutMainBranch(repo Repository, branchName string) OperationResult {
 	result := OperationResult{
 		Repository: repo,
@@ -41,50 +90,237 @@ func (o *Operations) CheckoutMainBranch(repo Repository, branchName string) Oper
 		return result
 	}
 
-	// Checkout main branch if not already on it
-	if currentBranch != mainBranch {
-		err = o.executeGitCommand(repo.Path, "checkout", mainBranch)
+	// Checkout the branch if not already on it
+	if currentBranch != branchName {
+		err = o.backend.Checkout(repo.Path, branchName)
 		if err != nil {
-			result.Error, result.Message = o.handleGitError(err.Error(), "checkout")
+			result.Error, result.Message = o.handleGitError(err.Error(), "checkout", branchName)
 			return result
 		}
 	}
 
-	// Pull latest changes from main
-	err = o.PullFromMain(repo.Path)
+	// Record HEAD before pulling so we can tell whether anything arrived
+	result.OldHead, _ = o.getHead(repo.Path)
+
+	// Pull latest changes of the branch
+	err = o.PullFromMain(repo.Path, branchName)
+	if err != nil {
+		result.Error, result.Message = o.handleGitError(err.Error(), "pull", branchName)
+		return result
+	}
+
+	result.NewHead, err = o.getHead(repo.Path)
 	if err != nil {
-		result.Error, result.Message = o.handleGitError(err.Error(), "pull")
+		result.Error = fmt.Errorf("failed to read HEAD after pull: %w", err)
+		result.Message = result.Error.Error()
 		return result
 	}
 
 	result.Success = true
-	result.Message = fmt.Sprintf("Checked out '%s' and pulled latest changes", mainBranch)
+	if result.OldHead == result.NewHead {
+		result.UpToDate = true
+		result.Message = fmt.Sprintf("Already up to date on '%s'", branchName)
+		return result
+	}
+
+	result.Message = fmt.Sprintf("Checked out '%s' and pulled latest changes (%s..%s)",
+		branchName, shortSHA(result.OldHead), shortSHA(result.NewHead))
 	return result
 }
 
+// UpdateBranchInPlace updates the local branch from origin without switching away from the
+// current checkout. A branch that is not checked out is fast-forwarded with a fetch refspec;
+// a branch that is checked out is pulled as usual. When rebaseCurrent is set, the current
+// branch is then rebased onto the updated branch.
+func (o *Operations) UpdateBranchInPlace(repo Repository, branchName string, rebaseCurrent bool) OperationResult {
+	result := OperationResult{
+		Repository: repo,
+		Success:    false,
+	}
+
+	currentBranch, err := o.getCurrentBranch(repo.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to get current branch: %w", err)
+		result.Message = result.Error.Error()
+		return result
+	}
+	result.CurrentBranch = currentBranch
+
+	ref := "refs/heads/" + branchName
+	result.OldHead, _ = o.backend.ResolveRevision(repo.Path, ref)
 
-// getCurrentBranch gets the current branch name
-func (o *Operations) getCurrentBranch(repoPath string) (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	if currentBranch == branchName {
+		err = o.PullFromMain(repo.Path, branchName)
+		if err != nil {
+			result.Error, result.Message = o.handleGitError(err.Error(), "pull", branchName)
+			return result
+		}
+	} else {
+		// Fast-forward the local branch from origin; git refuses non-fast-forward updates
+		err = o.backend.Fetch(repo.Path, "origin", ref+":"+ref)
+		if err != nil {
+			result.Error, result.Message = o.handleGitError(err.Error(), "fetch", branchName)
+			return result
+		}
+	}
+
+	result.NewHead, err = o.backend.ResolveRevision(repo.Path, ref)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to read %s after update: %w", branchName, err)
+		result.Message = result.Error.Error()
+		return result
+	}
+
+	result.Success = true
+	result.UpToDate = result.OldHead == result.NewHead
+	switch {
+	case result.UpToDate:
+		result.Message = fmt.Sprintf("'%s' already up to date", branchName)
+	case result.OldHead == "":
+		result.Message = fmt.Sprintf("Created '%s' at %s", branchName, shortSHA(result.NewHead))
+	default:
+		result.Message = fmt.Sprintf("Updated '%s' (%s..%s)", branchName, shortSHA(result.OldHead), shortSHA(result.NewHead))
+	}
+	if currentBranch != branchName && currentBranch != "" {
+		result.Message += fmt.Sprintf(", kept '%s' checked out", currentBranch)
+	}
+
+	if rebaseCurrent && currentBranch != "" && currentBranch != branchName {
+		result.RebaseStatus, result.RebaseMessage = o.rebaseOnto(repo.Path, currentBranch, branchName)
+	}
+
+	return result
+}
+
+// rebaseOnto rebases the checked-out branch onto base, aborting on conflicts so the
+// repository is never left mid-rebase
+func (o *Operations) rebaseOnto(repoPath, currentBranch, base string) (string, string) {
+	dirty, err := o.hasUncommittedChanges(repoPath)
+	if err != nil {
+		return RebaseSkipped, fmt.Sprintf("Skipped rebase: %v", err)
+	}
+	if dirty {
+		return RebaseSkipped, "Skipped rebase: Repository has uncommitted changes"
+	}
+
+	before, _ := o.getHead(repoPath)
+
+	err = o.executeGitCommand(repoPath, "rebase", base)
+	if err != nil {
+		_ = o.executeGitCommand(repoPath, "rebase", "--abort")
+		return RebaseConflict, fmt.Sprintf("Rebase of '%s' onto '%s' hit conflicts and was aborted", currentBranch, base)
+	}
+
+	after, _ := o.getHead(repoPath)
+	if before == after {
+		return RebaseUpToDate, fmt.Sprintf("'%s' is already based on '%s'", currentBranch, base)
+	}
+
+	return RebaseDone, fmt.Sprintf("Rebased '%s' onto '%s'", currentBranch, base)
+}
+
+// hasUncommittedChanges reports whether tracked files have staged or unstaged changes
+func (o *Operations) hasUncommittedChanges(repoPath string) (bool, error) {
+	return o.backend.HasUncommittedChanges(repoPath)
+}
+
+// GetChanges lists the commits in from..to (at most limit of them) and the diffstat between the two revisions
+func (o *Operations) GetChanges(repoPath, from, to string, limit int) (*ChangeSummary, error) {
+	summary := &ChangeSummary{}
+	revRange := from + ".." + to
+
+	total, _, err := o.backend.AheadBehind(repoPath, to, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commits: %w", err)
+	}
+	summary.TotalCommits = total
+
+	if limit > 0 && summary.TotalCommits > 0 {
+		commits, err := o.backend.Log(repoPath, revRange, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+		summary.Commits = commits
+	}
+
+	summary.FilesChanged, summary.Insertions, summary.Deletions, err = o.backend.DiffStat(repoPath, from, to)
 	if err != nil {
-		return "", fmt.Errorf("failed to get current branch: %w", err)
+		return nil, fmt.Errorf("failed to compute diffstat: %w", err)
+	}
+
+	return summary, nil
+}
+
+// getHead returns the full SHA of HEAD
+func (o *Operations) getHead(repoPath string) (string, error) {
+	return o.backend.ResolveRevision(repoPath, "HEAD")
+}
+
+// parseCommitLog parses "sha\x1fauthor\x1fsubject" lines produced by git log
+func parseCommitLog(output string) []Commit {
+	var commits []Commit
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{SHA: fields[0], Author: fields[1], Subject: fields[2]})
+	}
+	return commits
+}
+
+// parseShortStat parses the output of git diff --shortstat, e.g.
+// " 3 files changed, 10 insertions(+), 2 deletions(-)"
+func parseShortStat(output string) (files, insertions, deletions int) {
+	for _, part := range strings.Split(output, ",") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(fields[1], "file"):
+			files = n
+		case strings.HasPrefix(fields[1], "insertion"):
+			insertions = n
+		case strings.HasPrefix(fields[1], "deletion"):
+			deletions = n
+		}
 	}
-	return strings.TrimSpace(string(output)), nil
+	return files, insertions, deletions
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// getCurrentBranch gets the current branch name
+func (o *Operations) getCurrentBranch(repoPath string) (string, error) {
+	return o.backend.CurrentBranch(repoPath)
 }
 
-// PullFromMain pulls the latest changes from the main branch
-func (o *Operations) PullFromMain(repoPath string) error {
+// PullFromMain pulls the latest changes into the checked out branchName, setting its upstream
+// to origin/<branchName> when it has none
+func (o *Operations) PullFromMain(repoPath, branchName string) error {
 	// Try regular pull first
-	err := o.executeGitCommand(repoPath, "pull")
+	err := o.backend.Pull(repoPath, "", "")
 	if err == nil {
 		return nil
 	}
 
 	// If pull fails, handle tracking issues
 	if strings.Contains(err.Error(), "no tracking information") {
-		return o.handleNoTrackingBranch(repoPath)
+		return o.handleNoTrackingBranch(repoPath, branchName)
 	}
 
 	// Return the original error
@@ -92,26 +328,26 @@ func (o *Operations) PullFromMain(repoPath string) error {
 }
 
 // handleNoTrackingBranch handles the case when branch has no tracking information
-func (o *Operations) handleNoTrackingBranch(repoPath string) error {
+func (o *Operations) handleNoTrackingBranch(repoPath, branchName string) error {
 	// First, fetch to make sure we have latest remote info
-	err := o.executeGitCommand(repoPath, "fetch")
+	err := o.backend.Fetch(repoPath, "")
 	if err != nil {
 		return fmt.Errorf("failed to fetch: %w", err)
 	}
 
-	// Try to set upstream tracking for main
-	err = o.executeGitCommand(repoPath, "branch", "--set-upstream-to=origin/"+mainBranch, mainBranch)
+	// Try to set upstream tracking for the branch
+	err = o.executeGitCommand(repoPath, "branch", "--set-upstream-to=origin/"+branchName, branchName)
 	if err != nil {
 		// If setting upstream fails, try pull with explicit remote and branch
-		err = o.executeGitCommand(repoPath, "pull", "origin", mainBranch)
+		err = o.backend.Pull(repoPath, "origin", branchName)
 		if err != nil {
-			return fmt.Errorf("failed to pull from origin/%s: %w", mainBranch, err)
+			return fmt.Errorf("failed to pull from origin/%s: %w", branchName, err)
 		}
 		return nil
 	}
 
 	// Now try pull again
-	err = o.executeGitCommand(repoPath, "pull")
+	err = o.backend.Pull(repoPath, "", "")
 	if err != nil {
 		return fmt.Errorf("failed to pull after setting upstream: %w", err)
 	}
@@ -119,18 +355,19 @@ func (o *Operations) handleNoTrackingBranch(repoPath string) error {
 	return nil
 }
 
-// handleGitError analyzes git command output and returns user-friendly messages
-func (o *Operations) handleGitError(output string, command string) (error, string) {
+// handleGitError analyzes git command output and returns user-friendly messages. branchName is
+// the branch the command worked on, used to explain checkout errors; "" when there is none.
+func (o *Operations) handleGitError(output string, command string, branchName string) (error, string) {
 	outputLower := strings.ToLower(output)
 
 	// Check for common git errors in the output
 	switch {
 	case strings.Contains(outputLower, "uncommitted changes") || strings.Contains(outputLower, "would be overwritten"):
-		return fmt.Errorf("%s", output), "Skipped: Repository has uncommitted changes. Please commit or stash changes first."
-	case strings.Contains(outputLower, "already on") && strings.Contains(outputLower, mainBranch):
-		return fmt.Errorf("%s", output), fmt.Sprintf("Already on '%s' branch", mainBranch)
-	case strings.Contains(outputLower, "did not match any file") || (strings.Contains(outputLower, "pathspec") && strings.Contains(outputLower, "did not match")):
-		return fmt.Errorf("%s", output), fmt.Sprintf("Branch '%s' does not exist in this repository", mainBranch)
+		return fmt.Errorf("%s", output), uncommittedChangesMessage
+	case branchName != "" && strings.Contains(outputLower, "already on") && strings.Contains(outputLower, strings.ToLower(branchName)):
+		return fmt.Errorf("%s", output), fmt.Sprintf("Already on '%s' branch", branchName)
+	case branchName != "" && (strings.Contains(outputLower, "did not match any file") || (strings.Contains(outputLower, "pathspec") && strings.Contains(outputLower, "did not match"))):
+		return fmt.Errorf("%s", output), fmt.Sprintf("Branch '%s' does not exist in this repository", branchName)
 	case strings.Contains(outputLower, "not a git repository"):
 		return fmt.Errorf("%s", output), "Not a valid Git repository"
 	case strings.Contains(outputLower, "no such file or directory"):
@@ -141,6 +378,8 @@ func (o *Operations) handleGitError(output string, command string) (error, strin
 		return fmt.Errorf("%s", output), "Remote repository not accessible or not found"
 	case strings.Contains(outputLower, "no tracking information"):
 		return fmt.Errorf("%s", output), "No tracking branch configured for this branch"
+	case strings.Contains(outputLower, "non-fast-forward"):
+		return fmt.Errorf("%s", output), "Local branch has diverged from origin and cannot be fast-forwarded"
 	case strings.Contains(outputLower, "your local changes to the following files"):
 		return fmt.Errorf("%s", output), "Local changes would be overwritten. Please commit or stash changes first."
 	default:
@@ -148,15 +387,15 @@ func (o *Operations) handleGitError(output string, command string) (error, strin
 	}
 }
 
-// executeGitCommand executes a git command in the specified directory
+// executeGitCommand executes a git command in the specified directory. It is for the commands
+// no GitBackend method covers, which run the git binary whatever the backend.
 func (o *Operations) executeGitCommand(repoPath string, args ...string) error {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = repoPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%s", string(output))
-	}
+	return runGitCommand(repoPath, args...)
+}
 
-	return nil
+// gitOutput executes a git command and returns its trimmed standard output. Queries about
+// history and branches go through the backend instead; this is left for those about the index
+// and working tree, such as diffing staged changes, which need git like HasUncommittedChanges.
+func (o *Operations) gitOutput(repoPath string, args ...string) (string, error) {
+	return gitCommandOutput(repoPath, args...)
 }
@@ -167,7 +167,7 @@ func TestPullFromMain(t *testing.T) {
 			defer os.RemoveAll(repoPath)
 
 			ops := NewOperations()
-			err := ops.PullFromMain(repoPath)
+			err := ops.PullFromMain(repoPath, "main")
 
 			if tt.wantErr {
 				if err == nil {
@@ -259,7 +259,7 @@ func TestHandleGitError(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ops := NewOperations()
-			err, msg := ops.handleGitError(tt.output, tt.command)
+			err, msg := ops.handleGitError(tt.output, tt.command, "main")
 
 			if err == nil {
 				t.Errorf("handleGitError() expected error, got nil")
@@ -310,7 +310,7 @@ func TestHandleNoTrackingBranch(t *testing.T) {
 			defer os.RemoveAll(repoPath)
 
 			ops := NewOperations()
-			err := ops.handleNoTrackingBranch(repoPath)
+			err := ops.handleNoTrackingBranch(repoPath, "main")
 
 			if tt.wantErr {
 				if err == nil {
@@ -396,6 +396,286 @@ func TestExecuteGitCommand(t *testing.T) {
 	}
 }
 
+func TestCheckoutMainBranchRecordsHeads(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	ops := NewOperations()
+	repo := Repository{Path: clone, Name: "test-repo"}
+
+	t.Run("should report up to date when nothing was pulled", func(t *testing.T) {
+		result := ops.CheckoutMainBranch(repo, "main")
+		if !result.Success {
+			t.Fatalf("CheckoutMainBranch() failed: %v", result.Error)
+		}
+		if !result.UpToDate {
+			t.Errorf("CheckoutMainBranch() UpToDate = false, want true")
+		}
+		if result.OldHead == "" || result.OldHead != result.NewHead {
+			t.Errorf("CheckoutMainBranch() OldHead = %q, NewHead = %q, want equal and non-empty", result.OldHead, result.NewHead)
+		}
+		if !strings.Contains(result.Message, "Already up to date") {
+			t.Errorf("CheckoutMainBranch() Message = %q, want to contain %q", result.Message, "Already up to date")
+		}
+	})
+
+	t.Run("should record old and new heads when commits were pulled", func(t *testing.T) {
+		commitFile(t, upstream, "new.txt", "new content", "Add new file")
+
+		result := ops.CheckoutMainBranch(repo, "main")
+		if !result.Success {
+			t.Fatalf("CheckoutMainBranch() failed: %v", result.Error)
+		}
+		if result.UpToDate {
+			t.Errorf("CheckoutMainBranch() UpToDate = true, want false")
+		}
+		if result.OldHead == result.NewHead {
+			t.Errorf("CheckoutMainBranch() OldHead = NewHead = %q, want different", result.OldHead)
+		}
+		if !strings.Contains(result.Message, "pulled latest changes") {
+			t.Errorf("CheckoutMainBranch() Message = %q, want to contain %q", result.Message, "pulled latest changes")
+		}
+	})
+}
+
+func TestUpdateBranchInPlace(t *testing.T) {
+	tests := []struct {
+		name             string
+		setup            func(t *testing.T, upstream, clone string)
+		rebaseCurrent    bool
+		wantSuccess      bool
+		wantUpToDate     bool
+		wantBranch       string
+		wantRebaseStatus string
+		wantMsgContain   string
+	}{
+		{
+			name: "should fast-forward main while staying on the feature branch",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+				commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+			},
+			wantSuccess:    true,
+			wantBranch:     "feature",
+			wantMsgContain: "kept 'feature' checked out",
+		},
+		{
+			name: "should report up to date when origin has nothing new",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+			},
+			wantSuccess:    true,
+			wantUpToDate:   true,
+			wantBranch:     "feature",
+			wantMsgContain: "already up to date",
+		},
+		{
+			name: "should pull when main is already checked out",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+			},
+			wantSuccess:    true,
+			wantBranch:     "main",
+			wantMsgContain: "Updated 'main'",
+		},
+		{
+			name: "should fail when local main has diverged from origin",
+			setup: func(t *testing.T, upstream, clone string) {
+				commitFile(t, clone, "local.txt", "local", "Local change on main")
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+				commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+			},
+			wantSuccess:    false,
+			wantBranch:     "feature",
+			wantMsgContain: "cannot be fast-forwarded",
+		},
+		{
+			name: "should rebase the feature branch onto updated main",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+				commitFile(t, clone, "feature.txt", "feature", "Feature work")
+				commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+			},
+			rebaseCurrent:    true,
+			wantSuccess:      true,
+			wantBranch:       "feature",
+			wantRebaseStatus: RebaseDone,
+		},
+		{
+			name: "should abort the rebase on conflicts",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+				commitFile(t, clone, "test.txt", "feature version", "Feature edit")
+				commitFile(t, upstream, "test.txt", "upstream version", "Upstream edit")
+			},
+			rebaseCurrent:    true,
+			wantSuccess:      true,
+			wantBranch:       "feature",
+			wantRebaseStatus: RebaseConflict,
+		},
+		{
+			name: "should skip the rebase when the worktree is dirty",
+			setup: func(t *testing.T, upstream, clone string) {
+				runGit(t, clone, "checkout", "--quiet", "-b", "feature")
+				if err := os.WriteFile(filepath.Join(clone, "test.txt"), []byte("dirty"), 0644); err != nil {
+					t.Fatalf("failed to modify file: %v", err)
+				}
+				commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+			},
+			rebaseCurrent:    true,
+			wantSuccess:      true,
+			wantBranch:       "feature",
+			wantRebaseStatus: RebaseSkipped,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if testing.Short() {
+				t.Skip("skipping integration test in short mode")
+			}
+
+			upstream, clone := createTestClone(t)
+			tt.setup(t, upstream, clone)
+
+			ops := NewOperations()
+			result := ops.UpdateBranchInPlace(Repository{Path: clone, Name: "test-repo"}, "main", tt.rebaseCurrent)
+
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("UpdateBranchInPlace() Success = %v, want %v (Error: %v, Message: %v)",
+					result.Success, tt.wantSuccess, result.Error, result.Message)
+			}
+			if result.UpToDate != tt.wantUpToDate {
+				t.Errorf("UpdateBranchInPlace() UpToDate = %v, want %v", result.UpToDate, tt.wantUpToDate)
+			}
+			if tt.wantMsgContain != "" && !strings.Contains(result.Message, tt.wantMsgContain) {
+				t.Errorf("UpdateBranchInPlace() Message = %q, want to contain %q", result.Message, tt.wantMsgContain)
+			}
+			if result.RebaseStatus != tt.wantRebaseStatus {
+				t.Errorf("UpdateBranchInPlace() RebaseStatus = %q, want %q (%s)", result.RebaseStatus, tt.wantRebaseStatus, result.RebaseMessage)
+			}
+			if branch := runGit(t, clone, "branch", "--show-current"); branch != tt.wantBranch {
+				t.Errorf("current branch = %q, want %q", branch, tt.wantBranch)
+			}
+			if tt.wantSuccess {
+				if local, remote := runGit(t, clone, "rev-parse", "main"), runGit(t, clone, "rev-parse", "origin/main"); local != remote {
+					t.Errorf("main = %s, origin/main = %s, want equal", local, remote)
+				}
+			}
+			if tt.wantRebaseStatus == RebaseDone {
+				runGit(t, clone, "merge-base", "--is-ancestor", "main", "feature")
+			}
+			if _, err := os.Stat(filepath.Join(clone, ".git", "rebase-merge")); err == nil {
+				t.Errorf("repository was left mid-rebase")
+			}
+		})
+	}
+}
+
+func TestGetChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repoPath := createTestGitRepo(t, "main")
+	ops := NewOperations()
+
+	from, err := ops.getHead(repoPath)
+	if err != nil {
+		t.Fatalf("getHead() unexpected error: %v", err)
+	}
+	commitFile(t, repoPath, "a.txt", "a\n", "Add a")
+	commitFile(t, repoPath, "b.txt", "b\nb\n", "Add b")
+	commitFile(t, repoPath, "c.txt", "c\n", "Add c")
+	to, err := ops.getHead(repoPath)
+	if err != nil {
+		t.Fatalf("getHead() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		limit       int
+		wantCommits int
+	}{
+		{name: "should list all commits when under the limit", limit: 10, wantCommits: 3},
+		{name: "should cap listed commits at the limit", limit: 2, wantCommits: 2},
+		{name: "should list no commits when limit is zero", limit: 0, wantCommits: 0},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				changes, err := NewOperationsWithBackend(b.backend).GetChanges(repoPath, from, to, tt.limit)
+				if err != nil {
+					t.Fatalf("GetChanges() unexpected error: %v", err)
+				}
+				if changes.TotalCommits != 3 {
+					t.Errorf("GetChanges() TotalCommits = %d, want 3", changes.TotalCommits)
+				}
+				if len(changes.Commits) != tt.wantCommits {
+					t.Errorf("GetChanges() listed %d commits, want %d", len(changes.Commits), tt.wantCommits)
+				}
+				if tt.wantCommits > 0 && changes.Commits[0].Subject != "Add c" {
+					t.Errorf("GetChanges() first commit subject = %q, want %q", changes.Commits[0].Subject, "Add c")
+				}
+				if tt.wantCommits > 0 && changes.Commits[0].Author != "Test User" {
+					t.Errorf("GetChanges() first commit author = %q, want %q", changes.Commits[0].Author, "Test User")
+				}
+				if changes.FilesChanged != 3 || changes.Insertions != 4 || changes.Deletions != 0 {
+					t.Errorf("GetChanges() diffstat = %d files, +%d, -%d, want 3 files, +4, -0",
+						changes.FilesChanged, changes.Insertions, changes.Deletions)
+				}
+			})
+		}
+	}
+}
+
+func TestParseShortStat(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		wantFiles      int
+		wantInsertions int
+		wantDeletions  int
+	}{
+		{
+			name:           "should parse insertions and deletions",
+			output:         " 3 files changed, 10 insertions(+), 2 deletions(-)",
+			wantFiles:      3,
+			wantInsertions: 10,
+			wantDeletions:  2,
+		},
+		{
+			name:           "should parse singular forms",
+			output:         " 1 file changed, 1 insertion(+)",
+			wantFiles:      1,
+			wantInsertions: 1,
+		},
+		{
+			name:          "should parse deletions only",
+			output:        " 2 files changed, 5 deletions(-)",
+			wantFiles:     2,
+			wantDeletions: 5,
+		},
+		{
+			name:   "should return zeros for empty output",
+			output: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, insertions, deletions := parseShortStat(tt.output)
+			if files != tt.wantFiles || insertions != tt.wantInsertions || deletions != tt.wantDeletions {
+				t.Errorf("parseShortStat() = (%d, %d, %d), want (%d, %d, %d)",
+					files, insertions, deletions, tt.wantFiles, tt.wantInsertions, tt.wantDeletions)
+			}
+		})
+	}
+}
+
 func TestNewOperations(t *testing.T) {
 	t.Run("should create new Operations instance", func(t *testing.T) {
 		ops := NewOperations()
@@ -468,4 +748,42 @@ func createTestGitRepo(t *testing.T, branchName string) string {
 	}
 
 	return tmpDir
-}
\ No newline at end of file
+}
+
+// createTestClone creates an upstream repository on main and a clone of it tracking origin/main
+func createTestClone(t *testing.T) (upstream string, clone string) {
+	t.Helper()
+
+	upstream = createTestGitRepo(t, "main")
+	clone = filepath.Join(t.TempDir(), "clone")
+
+	runGit(t, "", "clone", "--quiet", upstream, clone)
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test User")
+
+	return upstream, clone
+}
+
+// commitFile writes a file and commits it
+func commitFile(t *testing.T, repoPath, name, content, message string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	runGit(t, repoPath, "add", name)
+	runGit(t, repoPath, "commit", "--quiet", "-m", message)
+}
+
+// runGit runs a git command and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
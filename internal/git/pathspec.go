@@ -0,0 +1,82 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// compilePathspec turns a pathspec as git ls-files takes it into a regular expression matching
+// file paths relative to the repository root. In a plain pathspec the wildcards also match "/";
+// with the ":(glob)" magic they don't, and "**/", "/**/" and "/**" match any number of
+// directories. Like git, a pathspec also matches every file below a directory it matches.
+// Other magic is not supported.
+func compilePathspec(pathspec string) (*regexp.Regexp, error) {
+	pattern, glob := pathspec, false
+	if strings.HasPrefix(pattern, ":") {
+		rest, ok := strings.CutPrefix(pattern, ":(glob)")
+		if !ok {
+			return nil, fmt.Errorf("unsupported pathspec %q", pathspec)
+		}
+		pattern, glob = rest, true
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" || pattern == "." {
+		return regexp.MustCompile(""), nil
+	}
+
+	// Wildcards never match the "/" separating directories in glob pathspecs
+	anyChar, anyChars := ".", ".*"
+	if glob {
+		anyChar, anyChars = "[^/]", "[^/]*"
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		atSegmentStart := i == 0 || pattern[i-1] == '/'
+		switch {
+		case glob && atSegmentStart && strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case glob && atSegmentStart && pattern[i:] == "**":
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString(anyChars)
+		case pattern[i] == '?':
+			expr.WriteString(anyChar)
+		case pattern[i] == '[' && strings.IndexByte(pattern[i+1:], ']') > 0:
+			end := i + 1 + strings.IndexByte(pattern[i+1:], ']')
+			class := pattern[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("(?:/.*)?$")
+
+	matcher, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pathspec %q: %w", pathspec, err)
+	}
+	return matcher, nil
+}
+
+// matchesAny reports whether a path matches one of the compiled pathspecs; no pathspecs match
+// every path
+func matchesAny(matchers []*regexp.Regexp, path string) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	for _, matcher := range matchers {
+		if matcher.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
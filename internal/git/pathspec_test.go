@@ -0,0 +1,44 @@
+package git
+
+import "testing"
+
+func TestCompilePathspec(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathspec string
+		path     string
+		want     bool
+	}{
+		{name: "should match the path itself", pathspec: "docs/guide.md", path: "docs/guide.md", want: true},
+		{name: "should match files below a directory", pathspec: "docs", path: "docs/guide.md", want: true},
+		{name: "should match files below a directory with a trailing slash", pathspec: "docs/", path: "docs/guide.md", want: true},
+		{name: "should not match a longer name", pathspec: "doc", path: "docs/guide.md", want: false},
+		{name: "should match any path for an empty pathspec", pathspec: "", path: "docs/guide.md", want: true},
+		{name: "should let a plain wildcard match slashes", pathspec: "*.md", path: "docs/guide.md", want: true},
+		{name: "should match a single character", pathspec: "test.tx?", path: "test.txt", want: true},
+		{name: "should match a character class", pathspec: "v[0-9].txt", path: "v1.txt", want: true},
+		{name: "should match a negated character class", pathspec: "v[!0-9].txt", path: "v1.txt", want: false},
+		{name: "should not let a glob wildcard match slashes", pathspec: ":(glob)*.md", path: "docs/guide.md", want: false},
+		{name: "should match any leading directories", pathspec: ":(glob)**/.gitattributes", path: "a/b/.gitattributes", want: true},
+		{name: "should match no leading directory", pathspec: ":(glob)**/.gitattributes", path: ".gitattributes", want: true},
+		{name: "should match any directories in between", pathspec: ":(glob)docs/**/*.md", path: "docs/a/b/guide.md", want: true},
+		{name: "should match everything below a trailing double star", pathspec: ":(glob)docs/**", path: "docs/a/guide.md", want: true},
+		{name: "should quote regular expression characters", pathspec: "a+b.txt", path: "aab.txt", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := compilePathspec(tt.pathspec)
+			if err != nil {
+				t.Fatalf("compilePathspec(%q) error = %v", tt.pathspec, err)
+			}
+			if got := matcher.MatchString(tt.path); got != tt.want {
+				t.Errorf("compilePathspec(%q) matches %q = %v, want %v", tt.pathspec, tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compilePathspec(":(icase)readme.md"); err == nil {
+		t.Error("compilePathspec() with unsupported magic succeeded, want error")
+	}
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RemoteBranches lists the remote-tracking branches of a repository for the given remote.
+// It reads loose and packed refs directly instead of forking git, so it stays cheap when
+// called for many repositories, e.g. during shell completion.
+func RemoteBranches(repoPath, remote string) ([]string, error) {
+	gitDir, err := resolveCommonDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "refs/remotes/" + remote + "/"
+	seen := make(map[string]bool)
+
+	// Loose refs
+	remoteDir := filepath.Join(gitDir, filepath.FromSlash(prefix))
+	err = filepath.WalkDir(remoteDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(remoteDir, path)
+		if err != nil {
+			return err
+		}
+		seen[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Packed refs
+	file, err := os.Open(filepath.Join(gitDir, "packed-refs"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) != 2 || !strings.HasPrefix(fields[1], prefix) {
+				continue
+			}
+			seen[strings.TrimPrefix(fields[1], prefix)] = true
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	delete(seen, "HEAD")
+
+	branches := make([]string, 0, len(seen))
+	for branch := range seen {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	return branches, nil
+}
+
+// resolveGitDir returns the git directory of a repository, following the
+// "gitdir:" indirection used by worktrees and submodules
+func resolveGitDir(repoPath string) (string, error) {
+	gitPath := filepath.Join(repoPath, ".git")
+
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return gitPath, nil
+	}
+
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return "", err
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repoPath, gitDir)
+	}
+
+	return gitDir, nil
+}
+
+// resolveCommonDir returns the directory holding the shared refs of a repository,
+// which differs from the git directory for linked worktrees
+func resolveCommonDir(repoPath string) (string, error) {
+	gitDir, err := resolveGitDir(repoPath)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return gitDir, nil
+		}
+		return "", err
+	}
+
+	commonDir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+
+	return commonDir, nil
+}
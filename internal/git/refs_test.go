@@ -0,0 +1,73 @@
+package git
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRemoteBranches(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, clone := createTestClone(t)
+	runGit(t, upstream, "branch", "feature/login")
+	runGit(t, upstream, "branch", "release")
+	runGit(t, clone, "fetch", "--quiet")
+
+	t.Run("should list loose remote branches without HEAD", func(t *testing.T) {
+		branches, err := RemoteBranches(clone, "origin")
+		if err != nil {
+			t.Fatalf("RemoteBranches() unexpected error: %v", err)
+		}
+		want := []string{"feature/login", "main", "release"}
+		if !reflect.DeepEqual(branches, want) {
+			t.Errorf("RemoteBranches() = %v, want %v", branches, want)
+		}
+	})
+
+	t.Run("should list packed remote branches", func(t *testing.T) {
+		runGit(t, clone, "pack-refs", "--all")
+		runGit(t, upstream, "branch", "hotfix")
+		runGit(t, clone, "fetch", "--quiet")
+
+		branches, err := RemoteBranches(clone, "origin")
+		if err != nil {
+			t.Fatalf("RemoteBranches() unexpected error: %v", err)
+		}
+		want := []string{"feature/login", "hotfix", "main", "release"}
+		if !reflect.DeepEqual(branches, want) {
+			t.Errorf("RemoteBranches() = %v, want %v", branches, want)
+		}
+	})
+
+	t.Run("should resolve refs through a linked worktree", func(t *testing.T) {
+		worktree := filepath.Join(t.TempDir(), "wt")
+		runGit(t, clone, "worktree", "add", "--quiet", "-b", "wt-branch", worktree)
+
+		branches, err := RemoteBranches(worktree, "origin")
+		if err != nil {
+			t.Fatalf("RemoteBranches() unexpected error: %v", err)
+		}
+		if len(branches) != 4 {
+			t.Errorf("RemoteBranches() = %v, want 4 branches", branches)
+		}
+	})
+
+	t.Run("should return no branches for an unknown remote", func(t *testing.T) {
+		branches, err := RemoteBranches(clone, "upstream")
+		if err != nil {
+			t.Fatalf("RemoteBranches() unexpected error: %v", err)
+		}
+		if len(branches) != 0 {
+			t.Errorf("RemoteBranches() = %v, want none", branches)
+		}
+	})
+
+	t.Run("should return error when path is not a git repository", func(t *testing.T) {
+		if _, err := RemoteBranches(t.TempDir(), "origin"); err == nil {
+			t.Errorf("RemoteBranches() expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/oddjob23/go-cli/pkg/config"
+)
+
+// How sync updates the submodules of a repository, as configured with "submodules" in config.json
+const (
+	SubmodulesRecursive = config.SubmodulesRecursive
+	SubmodulesTopLevel  = config.SubmodulesTopLevel
+	SubmodulesOff       = config.SubmodulesOff
+)
+
+// SubmoduleModes lists the accepted submodule modes
+var SubmoduleModes = config.SubmoduleModes
+
+// Outcomes of updating submodules and pulling LFS objects after a sync
+const (
+	SubmodulesUpdated = "updated"
+	SubmodulesFailed  = "failed"
+
+	LFSPulled  = "pulled"
+	LFSSkipped = "skipped" // The repository uses LFS but git-lfs is not installed
+	LFSFailed  = "failed"
+)
+
+// lfsBinary is the executable "git lfs" runs, looked up before pulling
+var lfsBinary = "git-lfs"
+
+// UpdateSubmodules checks out the submodule commits recorded by HEAD, initializing submodules
+// that were never cloned. The status is empty when the repository has no .gitmodules or no
+// submodule moved; otherwise the message counts the submodules checked out at a new commit.
+func (o *Operations) UpdateSubmodules(repoPath string, recursive bool) (string, string) {
+	if _, err := os.Stat(filepath.Join(repoPath, ".gitmodules")); err != nil {
+		return "", ""
+	}
+
+	args := []string{"submodule", "update", "--init"}
+	statusArgs := []string{"submodule", "status"}
+	if recursive {
+		args = append(args, "--recursive")
+		statusArgs = append(statusArgs, "--recursive")
+	}
+
+	before, err := o.gitOutput(repoPath, statusArgs...)
+	if err != nil {
+		return SubmodulesFailed, "Failed to read submodule status: " + firstLine(err.Error())
+	}
+
+	if err := o.executeGitCommand(repoPath, args...); err != nil {
+		return SubmodulesFailed, "Failed to update submodules: " + firstLine(err.Error())
+	}
+
+	after, err := o.gitOutput(repoPath, statusArgs...)
+	if err != nil {
+		return SubmodulesFailed, "Failed to read submodule status: " + firstLine(err.Error())
+	}
+
+	checkedOut := parseSubmoduleStatus(before)
+	count := 0
+	for path, sha := range parseSubmoduleStatus(after) {
+		if checkedOut[path] != sha {
+			count++
+		}
+	}
+	if count == 0 {
+		return "", ""
+	}
+
+	if recursive {
+		return SubmodulesUpdated, fmt.Sprintf("Updated %d submodules recursively", count)
+	}
+	return SubmodulesUpdated, fmt.Sprintf("Updated %d submodules", count)
+}
+
+// parseSubmoduleStatus maps the submodule paths listed by "git submodule status" to the commit
+// each has checked out, or "" for submodules that are not initialized. Lines look like
+// " <sha> path (describe)", with "-" instead of the space when the submodule is not
+// initialized and "+" or "U" when it differs from the recorded commit or has conflicts.
+func parseSubmoduleStatus(output string) map[string]string {
+	submodules := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		flag := line[0]
+		if flag == '-' || flag == '+' || flag == 'U' || flag == ' ' {
+			line = line[1:]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if flag == '-' {
+			submodules[fields[1]] = ""
+		} else {
+			submodules[fields[1]] = fields[0]
+		}
+	}
+	return submodules
+}
+
+// PullLFS downloads and checks out the LFS objects of the current checkout, replacing pointer
+// files. The status is empty when no tracked .gitattributes routes any path through LFS.
+func (o *Operations) PullLFS(repoPath string) (string, string) {
+	uses, err := o.usesLFS(repoPath)
+	if err != nil {
+		return LFSFailed, "Failed to read .gitattributes: " + firstLine(err.Error())
+	}
+	if !uses {
+		return "", ""
+	}
+
+	if _, err := exec.LookPath(lfsBinary); err != nil {
+		return LFSSkipped, "Skipped LFS pull: git-lfs is not installed, files are left as pointers"
+	}
+
+	if err := o.executeGitCommand(repoPath, "lfs", "pull"); err != nil {
+		return LFSFailed, "Failed to pull LFS objects: " + firstLine(err.Error())
+	}
+	return LFSPulled, "Pulled LFS objects"
+}
+
+// usesLFS reports whether any tracked .gitattributes file, at the root or in a subdirectory,
+// assigns the lfs filter to a path
+func (o *Operations) usesLFS(repoPath string) (bool, error) {
+	files, err := o.backend.TrackedFiles(repoPath, ":(glob).gitattributes", ":(glob)**/.gitattributes")
+	if err != nil {
+		return false, err
+	}
+
+	for _, file := range files {
+		uses, err := attributesUseLFS(filepath.Join(repoPath, filepath.FromSlash(file)))
+		if err != nil {
+			return false, err
+		}
+		if uses {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// attributesUseLFS reports whether a gitattributes file assigns the lfs filter to any path
+func attributesUseLFS(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, attribute := range strings.Fields(line)[1:] {
+			if attribute == "filter=lfs" {
+				return true, nil
+			}
+		}
+	}
+	return false, scanner.Err()
+}
+
+// firstLine returns the first non-empty line of git output, for one-line status messages
+func firstLine(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return output
+}
@@ -0,0 +1,247 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// allowFileSubmodules lets git clone submodules from local paths, which it refuses by default
+func allowFileSubmodules(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+}
+
+// createSubmoduleClone returns a clone of a repository whose "proto" submodule has a nested
+// "vendor" submodule, with no submodule initialized in the clone
+func createSubmoduleClone(t *testing.T) (upstream, proto, clone string) {
+	t.Helper()
+
+	allowFileSubmodules(t)
+	vendor := createTestGitRepo(t, "main")
+	proto = createTestGitRepo(t, "main")
+	runGit(t, proto, "submodule", "add", "--quiet", vendor, "vendor")
+	runGit(t, proto, "commit", "--quiet", "-m", "Add vendor")
+
+	upstream = createTestGitRepo(t, "main")
+	runGit(t, upstream, "submodule", "add", "--quiet", proto, "proto")
+	runGit(t, upstream, "commit", "--quiet", "-m", "Add proto")
+
+	clone = filepath.Join(t.TempDir(), "clone")
+	runGit(t, "", "clone", "--quiet", upstream, clone)
+	runGit(t, clone, "config", "user.email", "test@example.com")
+	runGit(t, clone, "config", "user.name", "Test User")
+	return upstream, proto, clone
+}
+
+func TestUpdateSubmodules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tests := []struct {
+		name        string
+		recursive   bool
+		wantMessage string
+		wantNested  bool
+	}{
+		{name: "should initialize nested submodules when recursive", recursive: true, wantMessage: "Updated 2 submodules recursively", wantNested: true},
+		{name: "should initialize only top-level submodules", recursive: false, wantMessage: "Updated 1 submodules", wantNested: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, clone := createSubmoduleClone(t)
+
+			status, message := NewOperations().UpdateSubmodules(clone, tt.recursive)
+			if status != SubmodulesUpdated || message != tt.wantMessage {
+				t.Errorf("UpdateSubmodules() = %q, %q, want %q, %q", status, message, SubmodulesUpdated, tt.wantMessage)
+			}
+			if _, err := os.Stat(filepath.Join(clone, "proto", "test.txt")); err != nil {
+				t.Errorf("proto submodule not checked out: %v", err)
+			}
+			_, err := os.Stat(filepath.Join(clone, "proto", "vendor", "test.txt"))
+			if (err == nil) != tt.wantNested {
+				t.Errorf("nested submodule checked out = %v, want %v", err == nil, tt.wantNested)
+			}
+		})
+	}
+}
+
+func TestUpdateSubmodulesUnchanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, _, clone := createSubmoduleClone(t)
+	ops := NewOperations()
+	if status, _ := ops.UpdateSubmodules(clone, true); status != SubmodulesUpdated {
+		t.Fatalf("first UpdateSubmodules() status = %q, want %q", status, SubmodulesUpdated)
+	}
+
+	if status, message := ops.UpdateSubmodules(clone, true); status != "" || message != "" {
+		t.Errorf("UpdateSubmodules() with nothing to move = %q, %q, want empty status", status, message)
+	}
+}
+
+func TestParseSubmoduleStatus(t *testing.T) {
+	a, b := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	// gitOutput trims the leading space of the first line
+	output := a + " proto (heads/main)\n-" + b + " docs\n+" + b + " proto/vendor (v1.0.0-1-gbbbbbbb)"
+
+	want := map[string]string{"proto": a, "docs": "", "proto/vendor": b}
+	if got := parseSubmoduleStatus(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSubmoduleStatus() = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateSubmodulesWithoutGitmodules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := createTestGitRepo(t, "main")
+	if status, message := NewOperations().UpdateSubmodules(repo, true); status != "" || message != "" {
+		t.Errorf("UpdateSubmodules() = %q, %q, want empty status", status, message)
+	}
+}
+
+func TestUpdateSubmodulesFailure(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, proto, clone := createSubmoduleClone(t)
+	if err := os.RemoveAll(proto); err != nil {
+		t.Fatalf("failed to remove submodule upstream: %v", err)
+	}
+
+	status, message := NewOperations().UpdateSubmodules(clone, true)
+	if status != SubmodulesFailed || !strings.HasPrefix(message, "Failed to update submodules: ") {
+		t.Errorf("UpdateSubmodules() = %q, %q, want failure", status, message)
+	}
+}
+
+func TestSyncRepositoryUpdatesSubmodules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	upstream, proto, clone := createSubmoduleClone(t)
+	syncer := &Syncer{operations: NewOperations()}
+
+	result := syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "main", SyncOptions{Submodules: SubmodulesOff})
+	if !result.Success || result.SubmoduleStatus != "" {
+		t.Fatalf("SyncRepository() with submodules off = %+v", result)
+	}
+
+	// Move the submodule forward upstream
+	commitFile(t, proto, "test.txt", "v2", "Bump proto")
+	runGit(t, filepath.Join(upstream, "proto"), "pull", "--quiet", "origin", "main")
+	runGit(t, upstream, "commit", "--quiet", "-am", "Bump proto")
+
+	result = syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "main", SyncOptions{})
+	if !result.Success {
+		t.Fatalf("SyncRepository() failed: %s", result.Message)
+	}
+	if result.SubmoduleStatus != SubmodulesUpdated {
+		t.Errorf("SyncRepository() SubmoduleStatus = %q, message %q", result.SubmoduleStatus, result.SubmoduleMessage)
+	}
+	if got, want := runGit(t, filepath.Join(clone, "proto"), "rev-parse", "HEAD"), runGit(t, proto, "rev-parse", "HEAD"); got != want {
+		t.Errorf("proto submodule at %s after sync, want %s", got, want)
+	}
+}
+
+func TestSyncRepositoryFailsWhenSubmodulesFail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, proto, clone := createSubmoduleClone(t)
+	if err := os.RemoveAll(proto); err != nil {
+		t.Fatalf("failed to remove submodule upstream: %v", err)
+	}
+
+	syncer := &Syncer{operations: NewOperations()}
+	result := syncer.SyncRepository(Repository{Path: clone, Name: "clone"}, "main", SyncOptions{})
+	if result.Success || result.SubmoduleStatus != SubmodulesFailed {
+		t.Fatalf("SyncRepository() = %+v, want a failed submodule update", result)
+	}
+	if result.Error == nil || !strings.HasPrefix(result.Error.Error(), "Failed to update submodules: ") {
+		t.Errorf("SyncRepository() Error = %v", result.Error)
+	}
+}
+
+func TestPullLFS(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	previous := lfsBinary
+	lfsBinary = "git-lfs-not-installed"
+	t.Cleanup(func() { lfsBinary = previous })
+
+	tests := []struct {
+		name       string
+		dir        string
+		attributes string
+		wantStatus string
+	}{
+		{name: "should do nothing without .gitattributes", attributes: "", wantStatus: ""},
+		{name: "should do nothing when no path uses LFS", attributes: "*.sh text eol=lf\n", wantStatus: ""},
+		{name: "should skip when git-lfs is not installed", attributes: "*.bin filter=lfs diff=lfs merge=lfs -text\n", wantStatus: LFSSkipped},
+		{name: "should detect LFS in a nested .gitattributes", dir: "fixtures/images", attributes: "*.png filter=lfs -text\n", wantStatus: LFSSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := createTestGitRepo(t, "main")
+			if tt.attributes != "" {
+				if err := os.MkdirAll(filepath.Join(repo, tt.dir), 0755); err != nil {
+					t.Fatalf("failed to create %s: %v", tt.dir, err)
+				}
+				commitFile(t, repo, filepath.Join(tt.dir, ".gitattributes"), tt.attributes, "Add attributes")
+			}
+
+			status, message := NewOperations().PullLFS(repo)
+			if status != tt.wantStatus {
+				t.Errorf("PullLFS() = %q, %q, want status %q", status, message, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAttributesUseLFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		want       bool
+	}{
+		{name: "should detect the lfs filter", attributes: "*.psd filter=lfs diff=lfs merge=lfs -text\n", want: true},
+		{name: "should detect the lfs filter after other patterns", attributes: "*.go text\n\nfixtures/** filter=lfs -text\n", want: true},
+		{name: "should ignore comments", attributes: "# *.psd filter=lfs\n*.go text\n", want: false},
+		{name: "should ignore other filters", attributes: "*.enc filter=crypt\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitattributes")
+			if err := os.WriteFile(path, []byte(tt.attributes), 0644); err != nil {
+				t.Fatalf("failed to write .gitattributes: %v", err)
+			}
+
+			got, err := attributesUseLFS(path)
+			if err != nil {
+				t.Fatalf("attributesUseLFS() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("attributesUseLFS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/oddjob23/go-cli/pkg/utils"
@@ -16,6 +18,14 @@ type SyncResult struct {
 	Results           []OperationResult
 }
 
+// SyncOptions controls how a repository is synced
+type SyncOptions struct {
+	KeepBranch       bool   // Update the target branch without switching away from the current branch
+	RebaseCurrent    bool   // Rebase the current branch onto the updated target branch; implies KeepBranch
+	DivergencePolicy string // One of the Divergence* policies; DivergenceSkip when empty
+	Submodules       string // One of the SubmoduleModes; SubmodulesRecursive when empty
+}
+
 // Syncer orchestrates the Git synchronization process
 type Syncer struct {
 	scanner    *Scanner
@@ -23,11 +33,11 @@ type Syncer struct {
 	output     *utils.CliOutput
 }
 
-// NewSyncer creates a new Syncer instance
-func NewSyncer(output *utils.CliOutput) *Syncer {
+// NewSyncer creates a new Syncer instance running the given operations
+func NewSyncer(output *utils.CliOutput, operations *Operations) *Syncer {
 	return &Syncer{
 		scanner:    NewScanner(),
-		operations: NewOperations(),
+		operations: operations,
 		output:     output,
 	}
 }
@@ -55,9 +65,13 @@ func (s *Syncer) SyncRepositories(rootDir string, branchName string) (*SyncResul
 	// Process repositories in parallel
 	results := s.processRepositoriesParallel(repositories, branchName)
 
-	// Calculate summary
+	return NewSyncResult(results), nil
+}
+
+// NewSyncResult summarizes the results of syncing a set of repositories
+func NewSyncResult(results []OperationResult) *SyncResult {
 	syncResult := &SyncResult{
-		TotalRepositories: len(repositories),
+		TotalRepositories: len(results),
 		Results:           results,
 	}
 
@@ -69,7 +83,7 @@ func (s *Syncer) SyncRepositories(rootDir string, branchName string) (*SyncResul
 		}
 	}
 
-	return syncResult, nil
+	return syncResult
 }
 
 // SyncSingleRepository syncs a single repository at the given path
@@ -80,8 +94,7 @@ func (s *Syncer) SyncSingleRepository(repoPath string, branchName string) error
 		Name: filepath.Base(repoPath),
 	}
 
-	// Perform the sync operation
-	result := s.operations.CheckoutMainBranch(repo, branchName)
+	result := s.SyncRepository(repo, branchName, SyncOptions{})
 
 	if !result.Success {
 		return result.Error
@@ -90,6 +103,79 @@ func (s *Syncer) SyncSingleRepository(repoPath string, branchName string) error
 	return nil
 }
 
+// SyncRepository syncs a single repository and returns the full operation result. A target
+// branch with commits origin does not have is handled by the divergence policy first, so
+// pulling never creates a merge commit.
+func (s *Syncer) SyncRepository(repo Repository, branchName string, opts SyncOptions) OperationResult {
+	keepBranch := opts.KeepBranch || opts.RebaseCurrent
+
+	divergence, err := s.operations.CheckDivergence(repo.Path, branchName)
+	if err != nil {
+		return OperationResult{Repository: repo, Error: err, Message: err.Error()}
+	}
+
+	var resolved OperationResult
+	if divergence != nil {
+		// Rebasing needs the branch checked out, which a sync without --keep-branch does anyway
+		if opts.DivergencePolicy == DivergenceRebase && !keepBranch {
+			if err := s.operations.backend.Checkout(repo.Path, branchName); err != nil {
+				result := OperationResult{Repository: repo}
+				result.Error, result.Message = s.operations.handleGitError(err.Error(), "checkout", branchName)
+				return result
+			}
+		}
+
+		resolved = s.operations.ResolveDivergence(repo, divergence, opts.DivergencePolicy)
+		if !resolved.Success {
+			return resolved
+		}
+	}
+
+	var result OperationResult
+	if keepBranch {
+		result = s.operations.UpdateBranchInPlace(repo, branchName, opts.RebaseCurrent)
+	} else {
+		result = s.operations.CheckoutMainBranch(repo, branchName)
+	}
+	if divergence != nil {
+		result.Divergence = divergence
+		result.DivergenceMessage = resolved.Message
+	}
+
+	// Bring submodules and LFS files in line with the new checkout. Updating the target branch
+	// in place does not touch the working tree unless it is checked out or was rebased onto.
+	worktreeUpdated := !keepBranch || result.CurrentBranch == branchName || result.RebaseStatus == RebaseDone
+	if result.Success && worktreeUpdated {
+		if opts.Submodules != SubmodulesOff {
+			result.SubmoduleStatus, result.SubmoduleMessage = s.operations.UpdateSubmodules(repo.Path, opts.Submodules != SubmodulesTopLevel)
+		}
+		result.LFSStatus, result.LFSMessage = s.operations.PullLFS(repo.Path)
+	}
+
+	// A checkout with stale submodules or LFS pointers is not synced
+	var failures []string
+	if result.SubmoduleStatus == SubmodulesFailed {
+		failures = append(failures, result.SubmoduleMessage)
+	}
+	if result.LFSStatus == LFSFailed {
+		failures = append(failures, result.LFSMessage)
+	}
+	if len(failures) > 0 {
+		result.Success = false
+		result.Error = errors.New(strings.Join(failures, "; "))
+	}
+	return result
+}
+
+// DescribeChanges summarizes the commits a successful sync pulled in, listing at most limit commits
+func (s *Syncer) DescribeChanges(result OperationResult, limit int) (*ChangeSummary, error) {
+	if !result.Success || result.UpToDate || result.OldHead == "" {
+		return &ChangeSummary{}, nil
+	}
+
+	return s.operations.GetChanges(result.Repository.Path, result.OldHead, result.NewHead, limit)
+}
+
 // processRepositoriesParallel processes multiple repositories concurrently using goroutines
 func (s *Syncer) processRepositoriesParallel(repositories []Repository, branchName string) []OperationResult {
 	var wg sync.WaitGroup
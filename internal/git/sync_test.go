@@ -0,0 +1,333 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeBackend is an in-memory GitBackend: branches point into a commit graph, and origin is a
+// second set of branches that Fetch copies into remote-tracking references
+type fakeBackend struct {
+	current  string            // Checked out branch
+	branches map[string]string // Local branch -> commit
+	tracking map[string]string // Remote-tracking branch -> commit, filled by Fetch
+	origin   map[string]string // Branch on origin -> commit
+	parents  map[string]string // Commit -> parent, "" for the root commit
+	dirty    bool
+	calls    []string
+}
+
+// newFakeBackend returns a backend with main checked out at commit "a" and origin's main at
+// the same commit
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		current:  "main",
+		branches: map[string]string{"main": "a"},
+		tracking: map[string]string{},
+		origin:   map[string]string{"main": "a"},
+		parents:  map[string]string{"a": ""},
+	}
+}
+
+func (f *fakeBackend) CurrentBranch(repoPath string) (string, error) {
+	return f.current, nil
+}
+
+func (f *fakeBackend) ResolveRevision(repoPath, revision string) (string, error) {
+	var sha string
+	var ok bool
+	switch {
+	case revision == "HEAD":
+		sha, ok = f.branches[f.current]
+	case strings.HasPrefix(revision, "refs/heads/"):
+		sha, ok = f.branches[strings.TrimPrefix(revision, "refs/heads/")]
+	case strings.HasPrefix(revision, "refs/remotes/origin/"):
+		sha, ok = f.tracking[strings.TrimPrefix(revision, "refs/remotes/origin/")]
+	case strings.HasPrefix(revision, "origin/"):
+		sha, ok = f.tracking[strings.TrimPrefix(revision, "origin/")]
+	default:
+		_, ok = f.parents[revision]
+		sha = revision
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRevision, revision)
+	}
+	return sha, nil
+}
+
+// history lists the commits reachable from sha, newest first
+func (f *fakeBackend) history(sha string) []string {
+	var commits []string
+	for ; sha != ""; sha = f.parents[sha] {
+		commits = append(commits, sha)
+	}
+	return commits
+}
+
+// only lists the commits reachable from from but not from not, newest first
+func (f *fakeBackend) only(from, not string) []string {
+	excluded := make(map[string]bool)
+	for _, sha := range f.history(not) {
+		excluded[sha] = true
+	}
+	var commits []string
+	for _, sha := range f.history(from) {
+		if !excluded[sha] {
+			commits = append(commits, sha)
+		}
+	}
+	return commits
+}
+
+func (f *fakeBackend) AheadBehind(repoPath, local, upstream string) (int, int, error) {
+	localSHA, err := f.ResolveRevision(repoPath, local)
+	if err != nil {
+		return 0, 0, err
+	}
+	upstreamSHA, err := f.ResolveRevision(repoPath, upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(f.only(localSHA, upstreamSHA)), len(f.only(upstreamSHA, localSHA)), nil
+}
+
+func (f *fakeBackend) CountCommits(repoPath, revision string, exclude ...string) (int, error) {
+	sha, err := f.ResolveRevision(repoPath, revision)
+	if err != nil {
+		return 0, err
+	}
+	excluded := make(map[string]bool)
+	for _, revision := range exclude {
+		excludedSHA, err := f.ResolveRevision(repoPath, revision)
+		if err != nil {
+			return 0, err
+		}
+		for _, commit := range f.history(excludedSHA) {
+			excluded[commit] = true
+		}
+	}
+
+	count := 0
+	for _, commit := range f.history(sha) {
+		if !excluded[commit] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeBackend) HasUncommittedChanges(repoPath string) (bool, error) {
+	return f.dirty, nil
+}
+
+func (f *fakeBackend) Log(repoPath, revRange string, limit int) ([]Commit, error) {
+	from, to, _ := strings.Cut(revRange, "..")
+	fromSHA, err := f.ResolveRevision(repoPath, from)
+	if err != nil {
+		return nil, err
+	}
+	toSHA, err := f.ResolveRevision(repoPath, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, sha := range f.only(toSHA, fromSHA) {
+		if limit > 0 && len(commits) == limit {
+			break
+		}
+		commits = append(commits, Commit{SHA: sha, Author: "Test User", Subject: "Commit " + sha})
+	}
+	return commits, nil
+}
+
+func (f *fakeBackend) TrackedFiles(repoPath string, pathspecs ...string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) DiffStat(repoPath, from, to string) (int, int, int, error) {
+	return 0, 0, 0, nil
+}
+
+// Branches lists the local branches, each tracking the branch of the same name on origin
+func (f *fakeBackend) Branches(repoPath string) ([]Branch, error) {
+	var branches []Branch
+	for name, sha := range f.branches {
+		branch := Branch{Name: name, SHA: sha}
+		if _, ok := f.origin[name]; ok {
+			branch.Upstream, branch.Remote, branch.Merge = "refs/remotes/origin/"+name, "origin", "refs/heads/"+name
+			if tracking, ok := f.tracking[name]; ok {
+				branch.Ahead, branch.Behind = len(f.only(sha, tracking)), len(f.only(tracking, sha))
+			} else {
+				branch.Gone = true
+			}
+		}
+		branches = append(branches, branch)
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches, nil
+}
+
+func (f *fakeBackend) MergedBranches(repoPath, base string) ([]string, error) {
+	baseSHA, err := f.ResolveRevision(repoPath, base)
+	if err != nil {
+		return nil, err
+	}
+	var merged []string
+	for name, sha := range f.branches {
+		if len(f.only(sha, baseSHA)) == 0 {
+			merged = append(merged, name)
+		}
+	}
+	sort.Strings(merged)
+	return merged, nil
+}
+
+func (f *fakeBackend) Fetch(repoPath, remote string, refspecs ...string) error {
+	f.calls = append(f.calls, "fetch")
+	for branch, sha := range f.origin {
+		f.tracking[branch] = sha
+	}
+	return nil
+}
+
+func (f *fakeBackend) Checkout(repoPath, branch string) error {
+	f.calls = append(f.calls, "checkout "+branch)
+	if _, ok := f.branches[branch]; !ok {
+		return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to git", branch)
+	}
+	if f.dirty {
+		return errors.New("error: Your local changes to the following files would be overwritten by checkout")
+	}
+	f.current = branch
+	return nil
+}
+
+func (f *fakeBackend) Pull(repoPath, remote, branch string) error {
+	f.calls = append(f.calls, "pull")
+	if err := f.Fetch(repoPath, remote); err != nil {
+		return err
+	}
+	upstream := f.tracking[f.current]
+	local := f.branches[f.current]
+	if len(f.only(local, upstream)) > 0 {
+		return errors.New("fatal: Not possible to fast-forward, aborting. (non-fast-forward)")
+	}
+	f.branches[f.current] = upstream
+	return nil
+}
+
+func TestSyncRepositoryWithFakeBackend(t *testing.T) {
+	tests := []struct {
+		name           string
+		branch         string
+		setup          func(f *fakeBackend)
+		wantSuccess    bool
+		wantUpToDate   bool
+		wantNewHead    string
+		wantCheckedOut string
+		wantDivergence bool
+		wantMsgContain string
+		wantCalls      []string
+	}{
+		{
+			name:           "should report a branch that is already up to date",
+			branch:         "main",
+			setup:          func(f *fakeBackend) {},
+			wantSuccess:    true,
+			wantUpToDate:   true,
+			wantNewHead:    "a",
+			wantCheckedOut: "main",
+			wantCalls:      []string{"fetch", "pull", "fetch"},
+		},
+		{
+			name:   "should checkout the target branch and fast-forward it",
+			branch: "main",
+			setup: func(f *fakeBackend) {
+				f.branches["feature"] = "a"
+				f.current = "feature"
+				f.parents["b"] = "a"
+				f.origin["main"] = "b"
+			},
+			wantSuccess:    true,
+			wantNewHead:    "b",
+			wantCheckedOut: "main",
+			wantMsgContain: "Checked out 'main' and pulled latest changes",
+			wantCalls:      []string{"fetch", "checkout main", "pull", "fetch"},
+		},
+		{
+			name:   "should sync a target branch other than main",
+			branch: "develop",
+			setup: func(f *fakeBackend) {
+				f.branches["develop"] = "a"
+				f.parents["b"] = "a"
+				f.origin["develop"] = "b"
+			},
+			wantSuccess:    true,
+			wantNewHead:    "b",
+			wantCheckedOut: "develop",
+			wantCalls:      []string{"fetch", "checkout develop", "pull", "fetch"},
+		},
+		{
+			name:   "should leave a diverged branch alone",
+			branch: "main",
+			setup: func(f *fakeBackend) {
+				f.parents["b"] = "a"
+				f.parents["c"] = "a"
+				f.branches["main"] = "c"
+				f.origin["main"] = "b"
+			},
+			wantSuccess:    false,
+			wantCheckedOut: "main",
+			wantDivergence: true,
+			wantCalls:      []string{"fetch"},
+		},
+		{
+			name:   "should skip a checkout with uncommitted changes",
+			branch: "main",
+			setup: func(f *fakeBackend) {
+				f.branches["feature"] = "a"
+				f.current = "feature"
+				f.dirty = true
+			},
+			wantSuccess:    false,
+			wantCheckedOut: "feature",
+			wantMsgContain: uncommittedChangesMessage,
+			wantCalls:      []string{"fetch", "checkout main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend()
+			tt.setup(backend)
+			syncer := &Syncer{operations: NewOperationsWithBackend(backend)}
+
+			result := syncer.SyncRepository(Repository{Path: t.TempDir(), Name: "fake"}, tt.branch, SyncOptions{})
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("SyncRepository() success = %v, want %v: %s", result.Success, tt.wantSuccess, result.Message)
+			}
+			if result.UpToDate != tt.wantUpToDate {
+				t.Errorf("SyncRepository() UpToDate = %v, want %v", result.UpToDate, tt.wantUpToDate)
+			}
+			if result.NewHead != tt.wantNewHead {
+				t.Errorf("SyncRepository() NewHead = %q, want %q", result.NewHead, tt.wantNewHead)
+			}
+			if errors.Is(result.Error, ErrDiverged) != tt.wantDivergence {
+				t.Errorf("SyncRepository() error = %v, want diverged %v", result.Error, tt.wantDivergence)
+			}
+			if !strings.Contains(result.Message, tt.wantMsgContain) {
+				t.Errorf("SyncRepository() message = %q, want it to contain %q", result.Message, tt.wantMsgContain)
+			}
+			if backend.current != tt.wantCheckedOut {
+				t.Errorf("checked out branch = %q, want %q", backend.current, tt.wantCheckedOut)
+			}
+			if got, want := strings.Join(backend.calls, ", "), strings.Join(tt.wantCalls, ", "); got != want {
+				t.Errorf("backend calls = [%s], want [%s]", got, want)
+			}
+		})
+	}
+}
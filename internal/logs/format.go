@@ -0,0 +1,146 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// palette holds the prefix colors; a service always gets the same one
+var palette = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgYellow),
+	color.New(color.FgGreen),
+	color.New(color.FgMagenta),
+	color.New(color.FgBlue),
+	color.New(color.FgHiCyan),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiBlue),
+}
+
+// ColorFor returns the prefix color of a service, derived from its name so it stays the
+// same across runs regardless of which other services are shown
+func ColorFor(service string) *color.Color {
+	h := fnv.New32a()
+	h.Write([]byte(service))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// Formatter renders lines as "service | text" with the prefixes padded to the same width
+type Formatter struct {
+	width      int
+	timestamps bool
+}
+
+// NewFormatter creates a new Formatter for the given services
+func NewFormatter(services []string, timestamps bool) *Formatter {
+	width := 0
+	for _, service := range services {
+		if len(service) > width {
+			width = len(service)
+		}
+	}
+	return &Formatter{width: width, timestamps: timestamps}
+}
+
+// Format renders a line with its colored prefix
+func (f *Formatter) Format(line Line) string {
+	prefix := ColorFor(line.Service).Sprintf("%-*s |", f.width, line.Service)
+	if f.timestamps && !line.Time.IsZero() {
+		prefix += " " + line.Time.Local().Format("15:04:05.000")
+	}
+	return prefix + " " + line.Text
+}
+
+// Filter selects lines grep-style
+type Filter struct {
+	pattern *regexp.Regexp
+	invert  bool
+}
+
+// NewFilter compiles a filter. An empty pattern matches every line.
+func NewFilter(pattern string, ignoreCase, invert bool) (*Filter, error) {
+	if pattern == "" {
+		return &Filter{invert: invert}, nil
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return &Filter{pattern: re, invert: invert}, nil
+}
+
+// Match reports whether a line passes the filter
+func (f *Filter) Match(line Line) bool {
+	if f.pattern == nil {
+		return !f.invert
+	}
+	return f.pattern.MatchString(line.Text) != f.invert
+}
+
+// Record is the JSON form of a log line. Lines that are JSON objects themselves are parsed
+// into Fields, with the common level and message keys lifted out.
+type Record struct {
+	Service string                 `json:"service"`
+	Time    *time.Time             `json:"time,omitempty"`
+	Level   string                 `json:"level,omitempty"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// levelKeys and messageKeys are the names structured loggers commonly use
+var (
+	levelKeys   = []string{"level", "lvl", "severity", "log.level"}
+	messageKeys = []string{"msg", "message", "MESSAGE"}
+)
+
+// ToRecord converts a line to its JSON form
+func ToRecord(line Line) Record {
+	record := Record{Service: line.Service, Message: line.Text}
+	if !line.Time.IsZero() {
+		t := line.Time
+		record.Time = &t
+	}
+
+	text := strings.TrimSpace(line.Text)
+	if !strings.HasPrefix(text, "{") {
+		return record
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		return record
+	}
+
+	if value, ok := takeString(fields, levelKeys); ok {
+		record.Level = strings.ToLower(value)
+	}
+	if value, ok := takeString(fields, messageKeys); ok {
+		record.Message = value
+	}
+	if len(fields) > 0 {
+		record.Fields = fields
+	}
+	return record
+}
+
+// takeString removes and returns the first string field found under one of the keys
+func takeString(fields map[string]interface{}, keys []string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key].(string); ok {
+			delete(fields, key)
+			return value, true
+		}
+	}
+	return "", false
+}
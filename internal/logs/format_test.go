@@ -0,0 +1,95 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatter(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	formatter := NewFormatter([]string{"redis", "postgres-main"}, false)
+	line := Line{Service: "redis", Time: time.Now(), Text: "Ready to accept connections"}
+
+	if got, want := formatter.Format(line), "redis         | Ready to accept connections"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestColorForIsStable(t *testing.T) {
+	if ColorFor("postgres-main") != ColorFor("postgres-main") {
+		t.Errorf("ColorFor() returned different colors for the same service")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		ignoreCase bool
+		invert     bool
+		text       string
+		want       bool
+	}{
+		{name: "should match everything without a pattern", text: "anything", want: true},
+		{name: "should match a regular expression", pattern: `ERROR|FATAL`, text: "FATAL: role missing", want: true},
+		{name: "should be case sensitive by default", pattern: "error", text: "ERROR", want: false},
+		{name: "should ignore case", pattern: "error", ignoreCase: true, text: "ERROR", want: true},
+		{name: "should invert the match", pattern: "healthcheck", invert: true, text: "GET /healthcheck", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewFilter(tt.pattern, tt.ignoreCase, tt.invert)
+			if err != nil {
+				t.Fatalf("NewFilter() unexpected error: %v", err)
+			}
+			if got := filter.Match(Line{Text: tt.text}); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewFilter("([", false, false); err == nil {
+		t.Errorf("NewFilter() expected error for an invalid pattern, got nil")
+	}
+}
+
+func TestToRecord(t *testing.T) {
+	stamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line Line
+		want Record
+	}{
+		{
+			name: "should keep plain text as the message",
+			line: Line{Service: "postgres", Time: stamp, Text: "database system is ready"},
+			want: Record{Service: "postgres", Time: &stamp, Message: "database system is ready"},
+		},
+		{
+			name: "should parse structured lines",
+			line: Line{Service: "api", Text: `{"level":"ERROR","msg":"request failed","status":500}`},
+			want: Record{Service: "api", Level: "error", Message: "request failed", Fields: map[string]interface{}{"status": float64(500)}},
+		},
+		{
+			name: "should keep malformed json as text",
+			line: Line{Service: "api", Text: `{"level": broken`},
+			want: Record{Service: "api", Message: `{"level": broken`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToRecord(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToRecord() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
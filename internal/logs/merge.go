@@ -0,0 +1,134 @@
+package logs
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Line is a single log line of a service
+type Line struct {
+	Service string
+	Time    time.Time // Zero when the line carried no timestamp
+	Text    string
+
+	arrived time.Time
+}
+
+// ParseTimestamped splits a line written by `docker compose logs --timestamps` into its
+// timestamp and text. Lines without a leading RFC 3339 timestamp are returned as is.
+func ParseTimestamped(service, raw string) Line {
+	if stamp, text, ok := strings.Cut(raw, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			return Line{Service: service, Time: t, Text: text}
+		}
+	}
+	return Line{Service: service, Text: raw}
+}
+
+// Merger merges the lines of several streams in timestamp order. A line is emitted once
+// every open stream has a later line queued, or once it has waited for the reorder window,
+// so a quiet stream delays the others by at most the window.
+type Merger struct {
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewMerger creates a new Merger with the given reorder window
+func NewMerger(window time.Duration) *Merger {
+	return &Merger{window: window, now: time.Now}
+}
+
+type streamLine struct {
+	stream int
+	line   Line
+	closed bool
+}
+
+// Merge reads every stream until it is closed and calls emit for each line in order.
+// When ctx is cancelled the queued lines are flushed and Merge returns once the streams
+// are closed by their producers.
+func (m *Merger) Merge(ctx context.Context, streams []<-chan Line, emit func(Line)) {
+	events := make(chan streamLine)
+	for i, stream := range streams {
+		go func(index int, stream <-chan Line) {
+			for line := range stream {
+				events <- streamLine{stream: index, line: line}
+			}
+			events <- streamLine{stream: index, closed: true}
+		}(i, stream)
+	}
+
+	queues := make([][]Line, len(streams))
+	open := len(streams)
+	closed := make([]bool, len(streams))
+
+	tick := m.window / 2
+	if tick <= 0 {
+		tick = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	done := ctx.Done()
+	for open > 0 {
+		select {
+		case event := <-events:
+			if event.closed {
+				closed[event.stream] = true
+				open--
+			} else {
+				event.line.arrived = m.now()
+				queues[event.stream] = append(queues[event.stream], event.line)
+			}
+		case <-ticker.C:
+		case <-done:
+			// Stop holding lines back; producers close their streams as they wind down
+			for i := range closed {
+				closed[i] = true
+			}
+			done = nil
+		}
+		m.emitReady(queues, closed, emit)
+	}
+
+	m.emitReady(queues, closed, emit)
+}
+
+// emitReady emits queued lines for as long as the earliest one is known to be next
+func (m *Merger) emitReady(queues [][]Line, closed []bool, emit func(Line)) {
+	for {
+		next := -1
+		for i, queue := range queues {
+			if len(queue) > 0 && (next < 0 || before(queue[0], queues[next][0])) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return
+		}
+
+		// A later line may still arrive on a stream that has nothing queued
+		waiting := false
+		for i, queue := range queues {
+			if len(queue) == 0 && !closed[i] {
+				waiting = true
+				break
+			}
+		}
+		if waiting && m.now().Sub(queues[next][0].arrived) < m.window {
+			return
+		}
+
+		emit(queues[next][0])
+		queues[next] = queues[next][1:]
+	}
+}
+
+// before orders lines by timestamp; lines without one keep their arrival order
+func before(a, b Line) bool {
+	if a.Time.IsZero() || b.Time.IsZero() {
+		return a.arrived.Before(b.arrived)
+	}
+	return a.Time.Before(b.Time)
+}
@@ -0,0 +1,115 @@
+package logs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamped(t *testing.T) {
+	line := ParseTimestamped("redis", "2024-05-01T12:00:01.123456789Z Ready to accept connections")
+	want := time.Date(2024, 5, 1, 12, 0, 1, 123456789, time.UTC)
+	if !line.Time.Equal(want) || line.Text != "Ready to accept connections" || line.Service != "redis" {
+		t.Errorf("ParseTimestamped() = %+v", line)
+	}
+
+	line = ParseTimestamped("redis", "no timestamp here")
+	if !line.Time.IsZero() || line.Text != "no timestamp here" {
+		t.Errorf("ParseTimestamped() without timestamp = %+v", line)
+	}
+}
+
+func TestMergeOrdersByTimestamp(t *testing.T) {
+	redis := make(chan Line, 10)
+	kafka := make(chan Line, 10)
+
+	redis <- at("redis", 1, "first")
+	redis <- at("redis", 4, "fourth")
+	close(redis)
+
+	kafka <- at("kafka", 0, "zeroth")
+	kafka <- at("kafka", 2, "second")
+	kafka <- at("kafka", 3, "third")
+	close(kafka)
+
+	got := merge(t, context.Background(), time.Hour, redis, kafka)
+	if want := "zeroth first second third fourth"; got != want {
+		t.Errorf("Merge() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeReleasesLinesOfQuietStreams(t *testing.T) {
+	busy := make(chan Line, 10)
+	quiet := make(chan Line)
+	defer close(quiet)
+
+	busy <- at("busy", 1, "one")
+	busy <- at("busy", 2, "two")
+
+	emitted := make(chan string, 10)
+	go NewMerger(20*time.Millisecond).Merge(context.Background(), []<-chan Line{busy, quiet}, func(line Line) {
+		emitted <- line.Text
+	})
+
+	// The quiet stream never sends, so lines are released once the window passes
+	for _, want := range []string{"one", "two"} {
+		select {
+		case got := <-emitted:
+			if got != want {
+				t.Errorf("emitted %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("line %q was never emitted", want)
+		}
+	}
+}
+
+func TestMergeFlushesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	following := make(chan Line, 10)
+	quiet := make(chan Line)
+	following <- at("following", 1, "queued")
+
+	done := make(chan string)
+	go func() {
+		done <- merge(t, ctx, time.Hour, following, quiet)
+	}()
+
+	// Producers close their streams once the context is cancelled
+	cancel()
+	close(following)
+	close(quiet)
+
+	select {
+	case got := <-done:
+		if got != "queued" {
+			t.Errorf("Merge() = %q, want the queued line flushed", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Merge() did not return after cancellation")
+	}
+}
+
+// Helper functions
+
+func at(service string, second int, text string) Line {
+	return Line{Service: service, Time: time.Date(2024, 5, 1, 12, 0, second, 0, time.UTC), Text: text}
+}
+
+// merge runs a Merger over the streams and returns the emitted texts joined by spaces
+func merge(t *testing.T, ctx context.Context, window time.Duration, streams ...chan Line) string {
+	t.Helper()
+
+	readOnly := make([]<-chan Line, len(streams))
+	for i, stream := range streams {
+		readOnly[i] = stream
+	}
+
+	var texts []string
+	NewMerger(window).Merge(ctx, readOnly, func(line Line) {
+		texts = append(texts, line.Text)
+	})
+	return strings.Join(texts, " ")
+}
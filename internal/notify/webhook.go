@@ -0,0 +1,237 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+)
+
+// Sync outcomes reported in webhook payloads
+const (
+	StatusSuccess        = "success"
+	StatusPartialFailure = "partial_failure"
+	StatusFailure        = "failure"
+)
+
+// Payload is the JSON body sent to generic webhook targets
+type Payload struct {
+	Event        string              `json:"event"`
+	Status       string              `json:"status"`
+	Host         string              `json:"host"`
+	Timestamp    time.Time           `json:"timestamp"`
+	Total        int                 `json:"total"`
+	Succeeded    int                 `json:"succeeded"`
+	Failed       int                 `json:"failed"`
+	Repositories []RepositoryPayload `json:"repositories"`
+}
+
+// RepositoryPayload describes the sync outcome of a single repository
+type RepositoryPayload struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Success  bool   `json:"success"`
+	UpToDate bool   `json:"upToDate"`
+	Message  string `json:"message"`
+	OldHead  string `json:"oldHead,omitempty"`
+	NewHead  string `json:"newHead,omitempty"`
+}
+
+// slackPayload is the body sent to Slack-compatible incoming webhooks
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// Notifier delivers sync summaries to the configured webhook targets
+type Notifier struct {
+	webhooks   []config.Webhook
+	client     *http.Client
+	retryDelay time.Duration
+}
+
+// NewNotifier creates a new Notifier for the given webhook targets
+func NewNotifier(webhooks []config.Webhook) *Notifier {
+	return &Notifier{
+		webhooks:   webhooks,
+		client:     &http.Client{},
+		retryDelay: time.Second,
+	}
+}
+
+// Notify sends the sync summary to every webhook whose trigger matches the result.
+// It returns one error per webhook that could not be delivered.
+func (n *Notifier) Notify(ctx context.Context, result *git.SyncResult) []error {
+	payload := NewPayload(result)
+
+	var errs []error
+	for _, webhook := range n.webhooks {
+		if !shouldFire(webhook, payload.Status) {
+			continue
+		}
+
+		body, err := encodeBody(webhook, payload)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", webhookName(webhook), err))
+			continue
+		}
+
+		if err := n.deliver(ctx, webhook, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", webhookName(webhook), err))
+		}
+	}
+
+	return errs
+}
+
+// NewPayload builds the webhook payload for a sync result
+func NewPayload(result *git.SyncResult) Payload {
+	host, _ := os.Hostname()
+
+	payload := Payload{
+		Event:        "sync.completed",
+		Status:       syncStatus(result),
+		Host:         host,
+		Timestamp:    time.Now().UTC(),
+		Total:        result.TotalRepositories,
+		Succeeded:    result.SuccessCount,
+		Failed:       result.FailureCount,
+		Repositories: make([]RepositoryPayload, 0, len(result.Results)),
+	}
+
+	for _, r := range result.Results {
+		payload.Repositories = append(payload.Repositories, RepositoryPayload{
+			Name:     r.Repository.Name,
+			Path:     r.Repository.Path,
+			Success:  r.Success,
+			UpToDate: r.UpToDate,
+			Message:  r.Message,
+			OldHead:  r.OldHead,
+			NewHead:  r.NewHead,
+		})
+	}
+
+	return payload
+}
+
+// deliver posts the body to a webhook, retrying on network errors and server-side failures
+func (n *Notifier) deliver(ctx context.Context, webhook config.Webhook, body []byte) error {
+	timeout := time.Duration(webhook.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= webhook.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(n.retryDelay * time.Duration(attempt)):
+			}
+		}
+
+		retry, err := n.post(ctx, webhook.URL, body, timeout)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+// post sends a single request and reports whether a failure is worth retrying
+func (n *Notifier) post(ctx context.Context, url string, body []byte, timeout time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-cli")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected response status %s", resp.Status)
+}
+
+// encodeBody renders the payload in the webhook's format
+func encodeBody(webhook config.Webhook, payload Payload) ([]byte, error) {
+	if webhook.Format == config.WebhookFormatSlack {
+		return json.Marshal(slackPayload{Text: slackText(payload)})
+	}
+	return json.Marshal(payload)
+}
+
+// slackText formats the sync summary as Slack mrkdwn text
+func slackText(payload Payload) string {
+	var b strings.Builder
+
+	switch payload.Status {
+	case StatusSuccess:
+		fmt.Fprintf(&b, ":white_check_mark: *go-cli sync on %s*: all %d repositories synced", payload.Host, payload.Total)
+	case StatusPartialFailure:
+		fmt.Fprintf(&b, ":warning: *go-cli sync on %s*: %d/%d repositories failed", payload.Host, payload.Failed, payload.Total)
+	default:
+		fmt.Fprintf(&b, ":x: *go-cli sync on %s*: all %d repositories failed", payload.Host, payload.Total)
+	}
+
+	for _, repo := range payload.Repositories {
+		if !repo.Success {
+			fmt.Fprintf(&b, "\n• `%s`: %s", repo.Name, repo.Message)
+		}
+	}
+
+	return b.String()
+}
+
+// shouldFire reports whether a webhook is triggered by the given sync status
+func shouldFire(webhook config.Webhook, status string) bool {
+	if webhook.On == config.WebhookOnAlways {
+		return true
+	}
+	return status != StatusSuccess
+}
+
+// syncStatus classifies a sync result
+func syncStatus(result *git.SyncResult) string {
+	switch {
+	case result.FailureCount == 0:
+		return StatusSuccess
+	case result.FailureCount < result.TotalRepositories:
+		return StatusPartialFailure
+	default:
+		return StatusFailure
+	}
+}
+
+// webhookName returns a label for a webhook in error messages
+func webhookName(webhook config.Webhook) string {
+	if webhook.Name != "" {
+		return webhook.Name
+	}
+	return webhook.URL
+}
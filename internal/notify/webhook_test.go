@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oddjob23/go-cli/internal/git"
+	"github.com/oddjob23/go-cli/pkg/config"
+)
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		name         string
+		webhook      config.Webhook
+		result       *git.SyncResult
+		wantRequests int
+	}{
+		{
+			name:         "should fire on failure when configured for failures",
+			webhook:      config.Webhook{On: config.WebhookOnFailure},
+			result:       partialFailureResult(),
+			wantRequests: 1,
+		},
+		{
+			name:         "should not fire on success when configured for failures",
+			webhook:      config.Webhook{On: config.WebhookOnFailure},
+			result:       successResult(),
+			wantRequests: 0,
+		},
+		{
+			name:         "should fire on success when configured for always",
+			webhook:      config.Webhook{On: config.WebhookOnAlways},
+			result:       successResult(),
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+			}))
+			defer server.Close()
+
+			tt.webhook.URL = server.URL
+			notifier := NewNotifier([]config.Webhook{tt.webhook})
+
+			if errs := notifier.Notify(context.Background(), tt.result); len(errs) != 0 {
+				t.Fatalf("Notify() unexpected errors: %v", errs)
+			}
+			if got := int(atomic.LoadInt32(&requests)); got != tt.wantRequests {
+				t.Errorf("Notify() sent %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestNotifyJSONPayload(t *testing.T) {
+	var payload Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier([]config.Webhook{{URL: server.URL, Format: config.WebhookFormatJSON}})
+	if errs := notifier.Notify(context.Background(), partialFailureResult()); len(errs) != 0 {
+		t.Fatalf("Notify() unexpected errors: %v", errs)
+	}
+
+	if payload.Status != StatusPartialFailure {
+		t.Errorf("payload Status = %q, want %q", payload.Status, StatusPartialFailure)
+	}
+	if payload.Total != 2 || payload.Succeeded != 1 || payload.Failed != 1 {
+		t.Errorf("payload counts = %d/%d/%d, want 2/1/1", payload.Total, payload.Succeeded, payload.Failed)
+	}
+	if len(payload.Repositories) != 2 || payload.Repositories[1].Name != "billing" || payload.Repositories[1].Success {
+		t.Errorf("payload Repositories = %+v, want billing to be reported as failed", payload.Repositories)
+	}
+}
+
+func TestNotifySlackPayload(t *testing.T) {
+	var body slackPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier([]config.Webhook{{URL: server.URL, Format: config.WebhookFormatSlack}})
+	if errs := notifier.Notify(context.Background(), partialFailureResult()); len(errs) != 0 {
+		t.Fatalf("Notify() unexpected errors: %v", errs)
+	}
+
+	if !strings.Contains(body.Text, "1/2 repositories failed") {
+		t.Errorf("slack text = %q, want to contain failure summary", body.Text)
+	}
+	if !strings.Contains(body.Text, "`billing`: Remote repository not accessible or not found") {
+		t.Errorf("slack text = %q, want to list the failed repository", body.Text)
+	}
+	if strings.Contains(body.Text, "`auth`") {
+		t.Errorf("slack text = %q, should not list successful repositories", body.Text)
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "should retry server errors until success",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			retries:      2,
+			wantRequests: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "should give up after configured retries",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			retries:      1,
+			wantRequests: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "should not retry client errors",
+			statuses:     []int{http.StatusBadRequest, http.StatusOK},
+			retries:      3,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			notifier := NewNotifier([]config.Webhook{{URL: server.URL, Retries: tt.retries}})
+			notifier.retryDelay = time.Millisecond
+
+			errs := notifier.Notify(context.Background(), partialFailureResult())
+			if tt.wantErr && len(errs) == 0 {
+				t.Errorf("Notify() expected error, got none")
+			}
+			if !tt.wantErr && len(errs) != 0 {
+				t.Errorf("Notify() unexpected errors: %v", errs)
+			}
+			if got := int(atomic.LoadInt32(&requests)); got != tt.wantRequests {
+				t.Errorf("Notify() sent %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestNotifyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	notifier := NewNotifier([]config.Webhook{{Name: "slow", URL: server.URL, TimeoutSeconds: 1}})
+
+	start := time.Now()
+	errs := notifier.Notify(context.Background(), partialFailureResult())
+	if len(errs) != 1 {
+		t.Fatalf("Notify() returned %d errors, want 1", len(errs))
+	}
+	if !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("Notify() error = %v, want deadline exceeded", errs[0])
+	}
+	if !strings.HasPrefix(errs[0].Error(), "slow:") {
+		t.Errorf("Notify() error = %q, want it prefixed with the webhook name", errs[0].Error())
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Notify() took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+// Helper functions
+
+func successResult() *git.SyncResult {
+	return git.NewSyncResult([]git.OperationResult{
+		{Repository: git.Repository{Name: "auth", Path: "/src/auth"}, Success: true, UpToDate: true},
+	})
+}
+
+func partialFailureResult() *git.SyncResult {
+	return git.NewSyncResult([]git.OperationResult{
+		{Repository: git.Repository{Name: "auth", Path: "/src/auth"}, Success: true, OldHead: "abc", NewHead: "def"},
+		{Repository: git.Repository{Name: "billing", Path: "/src/billing"}, Message: "Remote repository not accessible or not found"},
+	})
+}
@@ -0,0 +1,127 @@
+package preflight
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// procRoot is the procfs mount point, replaced in tests
+var procRoot = "/proc"
+
+// Socket states in /proc/net/{tcp,udp}: TCP_LISTEN, and TCP_CLOSE which bound UDP sockets report
+const (
+	tcpListen = "0A"
+	udpBound  = "07"
+)
+
+// findPortOwner looks up the process listening on a port through procfs. Sockets of other
+// users' processes are only visible to root, in which case no owner is returned.
+func findPortOwner(port compose.Port) (*Process, error) {
+	protocol, state := "tcp", tcpListen
+	if port.Protocol == "udp" {
+		protocol, state = "udp", udpBound
+	}
+
+	inodes := make(map[string]bool)
+	for _, table := range []string{protocol, protocol + "6"} {
+		found, err := socketInodes(filepath.Join(procRoot, "net", table), port.Published, state)
+		if err != nil {
+			continue // tcp6 is missing when IPv6 is disabled
+		}
+		for _, inode := range found {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, fmt.Errorf("no socket bound to port %d found", port.Published)
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if ownsSocket(pid, inodes) {
+			return readProcess(pid), nil
+		}
+	}
+
+	return nil, fmt.Errorf("owner of port %d is not visible to the current user", port.Published)
+}
+
+// socketInodes returns the inodes of sockets in a /proc/net table bound to port in state
+func socketInodes(path string, port int, state string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var inodes []string
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+
+		i := strings.LastIndex(fields[1], ":")
+		if i < 0 {
+			continue
+		}
+		local, err := strconv.ParseInt(fields[1][i+1:], 16, 32)
+		if err != nil || int(local) != port {
+			continue
+		}
+
+		if fields[9] != "0" {
+			inodes = append(inodes, fields[9])
+		}
+	}
+	return inodes, scanner.Err()
+}
+
+// ownsSocket reports whether one of the process's file descriptors is one of the sockets
+func ownsSocket(pid int, inodes map[string]bool) bool {
+	fdDir := filepath.Join(procRoot, strconv.Itoa(pid), "fd")
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return false
+	}
+
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(link, "socket:[") && inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+			return true
+		}
+	}
+	return false
+}
+
+func readProcess(pid int) *Process {
+	process := &Process{PID: pid}
+
+	if comm, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm")); err == nil {
+		process.Name = strings.TrimSpace(string(comm))
+	}
+	if cmdline, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline")); err == nil {
+		process.Command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+
+	return process
+}
@@ -0,0 +1,47 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+func TestFindPortOwner(t *testing.T) {
+	listener, port := listen(t)
+	defer listener.Close()
+
+	owner, err := findPortOwner(compose.Port{Published: port, Protocol: "tcp"})
+	if err != nil {
+		t.Fatalf("findPortOwner() unexpected error: %v", err)
+	}
+	if owner.PID != os.Getpid() {
+		t.Errorf("findPortOwner() pid = %d, want %d", owner.PID, os.Getpid())
+	}
+	if owner.Name == "" || owner.Command == "" {
+		t.Errorf("findPortOwner() = %+v, want name and command", owner)
+	}
+}
+
+func TestSocketInodes(t *testing.T) {
+	table := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 31337 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1538 0100007F:9C40 01 00000000:00000000 00:00000000 00000000   999        0 31338 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:18EB 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4242 1 0000000000000000 100 0 0 10 0
+`
+	path := filepath.Join(t.TempDir(), "tcp")
+	if err := os.WriteFile(path, []byte(table), 0644); err != nil {
+		t.Fatalf("failed to write table: %v", err)
+	}
+
+	inodes, err := socketInodes(path, 5432, tcpListen)
+	if err != nil {
+		t.Fatalf("socketInodes() unexpected error: %v", err)
+	}
+	// The established connection on 5432 is not a listener
+	if !reflect.DeepEqual(inodes, []string{"31337"}) {
+		t.Errorf("socketInodes(5432) = %v, want [31337]", inodes)
+	}
+}
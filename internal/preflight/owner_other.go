@@ -0,0 +1,14 @@
+//go:build !linux
+
+package preflight
+
+import (
+	"errors"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// findPortOwner is only implemented on Linux, where procfs exposes socket owners
+func findPortOwner(port compose.Port) (*Process, error) {
+	return nil, errors.New("looking up port owners is only supported on Linux")
+}
@@ -0,0 +1,135 @@
+package preflight
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// maxSuggestionDistance bounds how far above a taken port a replacement is searched for
+const maxSuggestionDistance = 100
+
+// Process is a local process holding a port
+type Process struct {
+	PID     int
+	Name    string
+	Command string
+}
+
+// Conflict is a published port that is already in use on the host
+type Conflict struct {
+	Service   string
+	Port      compose.Port
+	Owner     *Process // Nil when the owner cannot be determined
+	Suggested int      // Free host port to publish instead; zero when none was found
+}
+
+// Override returns the port mapping with the suggested host port
+func (c Conflict) Override() compose.Port {
+	port := c.Port
+	port.Published = c.Suggested
+	return port
+}
+
+// PortChecker finds published ports that are already taken on the host
+type PortChecker struct {
+	isFree    func(port compose.Port) bool
+	findOwner func(port compose.Port) (*Process, error)
+}
+
+// NewPortChecker creates a new PortChecker that probes ports by binding them
+func NewPortChecker() *PortChecker {
+	return &PortChecker{
+		isFree:    portIsFree,
+		findOwner: findPortOwner,
+	}
+}
+
+// Check returns a conflict for every port published by services that cannot be bound.
+// Suggested ports avoid every port published anywhere in the project.
+func (c *PortChecker) Check(project *compose.Project, services []*compose.Service) []Conflict {
+	reserved := make(map[int]bool)
+	for _, service := range project.Services {
+		for _, port := range service.PublishedPorts() {
+			reserved[port.Published] = true
+		}
+	}
+
+	var conflicts []Conflict
+	for _, service := range services {
+		for _, port := range service.PublishedPorts() {
+			if c.isFree(port) {
+				continue
+			}
+
+			conflict := Conflict{Service: service.Name, Port: port}
+			conflict.Owner, _ = c.findOwner(port)
+			conflict.Suggested = c.suggest(port, reserved)
+			if conflict.Suggested != 0 {
+				reserved[conflict.Suggested] = true
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// OverridePorts returns the full port list of each conflicting service with the taken host
+// ports replaced by their suggestions, ready for compose.WritePortOverrides
+func OverridePorts(project *compose.Project, conflicts []Conflict) map[string][]compose.Port {
+	overrides := make(map[string][]compose.Port)
+	for _, conflict := range conflicts {
+		if conflict.Suggested == 0 {
+			continue
+		}
+		if _, ok := overrides[conflict.Service]; !ok {
+			service, _ := project.Service(conflict.Service)
+			overrides[conflict.Service] = append([]compose.Port(nil), service.Ports...)
+		}
+
+		ports := overrides[conflict.Service]
+		for i := range ports {
+			if ports[i] == conflict.Port {
+				ports[i] = conflict.Override()
+			}
+		}
+	}
+	return overrides
+}
+
+// suggest finds the closest free port above the taken one
+func (c *PortChecker) suggest(port compose.Port, reserved map[int]bool) int {
+	for candidate := port.Published + 1; candidate <= port.Published+maxSuggestionDistance && candidate <= 65535; candidate++ {
+		if reserved[candidate] {
+			continue
+		}
+		probe := port
+		probe.Published = candidate
+		if c.isFree(probe) {
+			return candidate
+		}
+	}
+	return 0
+}
+
+// portIsFree binds the port the way Docker would publish it
+func portIsFree(port compose.Port) bool {
+	addr := net.JoinHostPort(port.HostIP, strconv.Itoa(port.Published))
+
+	if port.Protocol == "udp" {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
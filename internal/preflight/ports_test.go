@@ -0,0 +1,104 @@
+package preflight
+
+import (
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+func TestPortCheckerCheck(t *testing.T) {
+	project := &compose.Project{
+		Services: []*compose.Service{
+			{Name: "postgres-main", Ports: []compose.Port{{Published: 5432, Target: 5432, Protocol: "tcp"}}},
+			{Name: "postgres-auth", Ports: []compose.Port{{Published: 5433, Target: 5432, Protocol: "tcp"}}},
+			{Name: "redis", Ports: []compose.Port{{Published: 6379, Target: 6379, Protocol: "tcp"}, {Target: 9121}}},
+		},
+	}
+
+	taken := map[int]bool{5432: true, 5433: true, 5434: true, 6379: true}
+	checker := &PortChecker{
+		isFree: func(port compose.Port) bool { return !taken[port.Published] },
+		findOwner: func(port compose.Port) (*Process, error) {
+			if port.Published == 5432 {
+				return &Process{PID: 42, Name: "postgres"}, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+
+	conflicts := checker.Check(project, project.Services)
+
+	// 5433 is published by postgres-auth and 5434 is taken, so postgres-main moves to 5435;
+	// postgres-auth then skips the port just suggested
+	want := []Conflict{
+		{Service: "postgres-main", Port: project.Services[0].Ports[0], Owner: &Process{PID: 42, Name: "postgres"}, Suggested: 5435},
+		{Service: "postgres-auth", Port: project.Services[1].Ports[0], Suggested: 5436},
+		{Service: "redis", Port: project.Services[2].Ports[0], Suggested: 6380},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("Check() = %+v, want %+v", conflicts, want)
+	}
+
+	overrides := OverridePorts(project, conflicts)
+	wantRedis := []compose.Port{{Published: 6380, Target: 6379, Protocol: "tcp"}, {Target: 9121}}
+	if !reflect.DeepEqual(overrides["redis"], wantRedis) {
+		t.Errorf("OverridePorts()[redis] = %v, want %v", overrides["redis"], wantRedis)
+	}
+	if project.Services[2].Ports[0].Published != 6379 {
+		t.Errorf("OverridePorts() modified the project's ports")
+	}
+	if len(overrides) != 3 {
+		t.Errorf("OverridePorts() returned %d services, want 3", len(overrides))
+	}
+}
+
+func TestPortCheckerNoFreePort(t *testing.T) {
+	project := &compose.Project{
+		Services: []*compose.Service{{Name: "redis", Ports: []compose.Port{{Published: 6379, Target: 6379}}}},
+	}
+	checker := &PortChecker{
+		isFree:    func(port compose.Port) bool { return false },
+		findOwner: func(port compose.Port) (*Process, error) { return nil, os.ErrPermission },
+	}
+
+	conflicts := checker.Check(project, project.Services)
+	if len(conflicts) != 1 || conflicts[0].Suggested != 0 {
+		t.Fatalf("Check() = %+v, want one conflict without suggestion", conflicts)
+	}
+	if overrides := OverridePorts(project, conflicts); len(overrides) != 0 {
+		t.Errorf("OverridePorts() = %v, want none", overrides)
+	}
+}
+
+func TestPortIsFree(t *testing.T) {
+	listener, port := listen(t)
+	defer listener.Close()
+
+	if portIsFree(compose.Port{HostIP: "127.0.0.1", Published: port, Protocol: "tcp"}) {
+		t.Errorf("portIsFree(%d) = true for a port with a listener", port)
+	}
+
+	listener.Close()
+	if !portIsFree(compose.Port{HostIP: "127.0.0.1", Published: port, Protocol: "tcp"}) {
+		t.Errorf("portIsFree(%d) = false after the listener closed", port)
+	}
+}
+
+// Helper functions
+
+// listen opens a TCP listener on a free local port
+func listen(t *testing.T) (net.Listener, int) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	n, _ := strconv.Atoi(port)
+	return listener, n
+}
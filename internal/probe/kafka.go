@@ -0,0 +1,67 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Kafka sends an ApiVersions (v0) request. The broker is ready once it answers without an error code.
+type Kafka struct{}
+
+const (
+	kafkaAPIVersionsKey = 18
+	kafkaClientID       = "go-cli"
+	kafkaCorrelationID  = 1
+)
+
+func (p *Kafka) Name() string {
+	return "kafka"
+}
+
+func (p *Kafka) Check(ctx context.Context, addr string) error {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(kafkaAPIVersionsRequest()); err != nil {
+		return fmt.Errorf("failed to send ApiVersions request: %w", err)
+	}
+
+	sizeBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, sizeBuf); err != nil {
+		return fmt.Errorf("failed to read ApiVersions response: %w", err)
+	}
+	size := binary.BigEndian.Uint32(sizeBuf)
+	if size < 6 || size > 1024*1024 {
+		return fmt.Errorf("unexpected ApiVersions response size %d", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return fmt.Errorf("failed to read ApiVersions response: %w", err)
+	}
+
+	if correlationID := binary.BigEndian.Uint32(body[0:4]); correlationID != kafkaCorrelationID {
+		return fmt.Errorf("unexpected correlation id %d", correlationID)
+	}
+	if errorCode := int16(binary.BigEndian.Uint16(body[4:6])); errorCode != 0 {
+		return notReady("ApiVersions error code %d", errorCode)
+	}
+
+	return nil
+}
+
+// kafkaAPIVersionsRequest builds a size-prefixed ApiVersions v0 request
+func kafkaAPIVersionsRequest() []byte {
+	msg := make([]byte, 0, 4+10+len(kafkaClientID))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(10+len(kafkaClientID)))
+	msg = binary.BigEndian.AppendUint16(msg, kafkaAPIVersionsKey)
+	msg = binary.BigEndian.AppendUint16(msg, 0) // api version
+	msg = binary.BigEndian.AppendUint32(msg, kafkaCorrelationID)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(kafkaClientID)))
+	return append(msg, kafkaClientID...)
+}
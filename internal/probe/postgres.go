@@ -0,0 +1,107 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Postgres sends a protocol 3.0 startup message. The server is ready once it answers with an
+// authentication request or with any error other than "the database system is starting up".
+type Postgres struct {
+	User     string
+	Database string
+}
+
+// SQLSTATE codes reported while the server cannot accept connections yet
+var postgresNotReadyCodes = map[string]bool{
+	"57P03": true, // cannot_connect_now
+	"57P01": true, // admin_shutdown
+}
+
+func (p *Postgres) Name() string {
+	return "postgres"
+}
+
+func (p *Postgres) Check(ctx context.Context, addr string) error {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(postgresStartupMessage(p.User, p.Database)); err != nil {
+		return fmt.Errorf("failed to send startup message: %w", err)
+	}
+
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("failed to read startup response: %w", err)
+	}
+
+	msgType := header[0]
+	length := int(binary.BigEndian.Uint32(header[1:5])) - 4
+	if length < 0 || length > 64*1024 {
+		return fmt.Errorf("unexpected startup response length %d", length)
+	}
+
+	switch msgType {
+	case 'R':
+		// Authentication request: the server is accepting connections
+		_, _ = conn.Write([]byte{'X', 0, 0, 0, 4})
+		return nil
+	case 'E':
+		body := make([]byte, length)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return fmt.Errorf("failed to read error response: %w", err)
+		}
+		code, message := parsePostgresError(body)
+		if postgresNotReadyCodes[code] {
+			return notReady("%s", message)
+		}
+		// Any other error (unknown role, bad database, ...) comes from a running server
+		return nil
+	default:
+		return fmt.Errorf("unexpected startup response %q", msgType)
+	}
+}
+
+// postgresStartupMessage builds a protocol 3.0 StartupMessage
+func postgresStartupMessage(user, database string) []byte {
+	var body bytes.Buffer
+	binary.Write(&body, binary.BigEndian, int32(196608)) // protocol version 3.0
+	for _, kv := range [][2]string{{"user", user}, {"database", database}} {
+		body.WriteString(kv[0])
+		body.WriteByte(0)
+		body.WriteString(kv[1])
+		body.WriteByte(0)
+	}
+	body.WriteByte(0)
+
+	msg := make([]byte, 4, 4+body.Len())
+	binary.BigEndian.PutUint32(msg, uint32(4+body.Len()))
+	return append(msg, body.Bytes()...)
+}
+
+// parsePostgresError extracts the SQLSTATE code and message from an ErrorResponse body
+func parsePostgresError(body []byte) (code, message string) {
+	for len(body) > 0 && body[0] != 0 {
+		field := body[0]
+		end := bytes.IndexByte(body[1:], 0)
+		if end < 0 {
+			break
+		}
+		value := string(body[1 : 1+end])
+		body = body[2+end:]
+
+		switch field {
+		case 'C':
+			code = value
+		case 'M':
+			message = value
+		}
+	}
+	return code, message
+}
@@ -0,0 +1,95 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+// ErrNotReady is returned when a service accepts connections but reports that it is not ready yet
+var ErrNotReady = errors.New("not ready")
+
+// Probe checks whether a service listening on an address is ready to serve requests
+type Probe interface {
+	Name() string
+	Check(ctx context.Context, addr string) error
+}
+
+// ForService picks a protocol-aware probe for a compose service based on its image, and the
+// host address of the published port to probe. ok is false when the service publishes no ports.
+func ForService(service *compose.Service) (p Probe, addr string, ok bool) {
+	image := strings.ToLower(service.Image)
+
+	var defaultPort int
+	switch {
+	case strings.Contains(image, "postgres"):
+		user := envOr(service.Environment, "POSTGRES_USER", "postgres")
+		p = &Postgres{User: user, Database: envOr(service.Environment, "POSTGRES_DB", user)}
+		defaultPort = 5432
+	case strings.Contains(image, "redis"):
+		p = &Redis{}
+		defaultPort = 6379
+	case strings.Contains(image, "kafka"):
+		p = &Kafka{}
+		defaultPort = 9092
+	default:
+		p = &TCP{}
+	}
+
+	addr, ok = service.PublishedAddress(defaultPort)
+	if !ok {
+		return nil, "", false
+	}
+	return p, addr, true
+}
+
+// TCP considers a service ready once it accepts TCP connections
+type TCP struct{}
+
+func (p *TCP) Name() string {
+	return "tcp"
+}
+
+func (p *TCP) Check(ctx context.Context, addr string) error {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// dial opens a connection that honors the context deadline for all I/O
+func dial(ctx context.Context, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func envOr(env map[string]string, key, fallback string) string {
+	if value := env[key]; value != "" {
+		return value
+	}
+	return fallback
+}
+
+// notReady wraps a server-reported reason in ErrNotReady
+func notReady(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrNotReady, fmt.Sprintf(format, args...))
+}
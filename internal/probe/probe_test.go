@@ -0,0 +1,310 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/oddjob23/go-cli/pkg/compose"
+)
+
+func TestTCP(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) {})
+
+	if err := (&TCP{}).Check(testContext(t), addr); err != nil {
+		t.Errorf("Check() unexpected error: %v", err)
+	}
+	if err := (&TCP{}).Check(testContext(t), closedAddr(t)); err == nil {
+		t.Errorf("Check() expected error for closed port, got nil")
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	tests := []struct {
+		name         string
+		reply        []byte
+		wantErr      bool
+		wantNotReady bool
+	}{
+		{
+			name:  "should be ready when the server requests authentication",
+			reply: postgresMessage('R', []byte{0, 0, 0, 3}),
+		},
+		{
+			name:         "should not be ready while the database system is starting up",
+			reply:        postgresMessage('E', postgresErrorBody("57P03", "the database system is starting up")),
+			wantErr:      true,
+			wantNotReady: true,
+		},
+		{
+			name:  "should be ready when the server rejects the role",
+			reply: postgresMessage('E', postgresErrorBody("28000", `role "postgres" does not exist`)),
+		},
+		{
+			name:    "should fail on an unexpected response",
+			reply:   []byte("HTTP/1.1 400 Bad Request\r\n"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startup := make(chan []byte, 1)
+			addr := fakeServer(t, func(conn net.Conn) {
+				header := make([]byte, 4)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				body := make([]byte, binary.BigEndian.Uint32(header)-4)
+				if _, err := io.ReadFull(conn, body); err != nil {
+					return
+				}
+				startup <- body
+				conn.Write(tt.reply)
+			})
+
+			err := (&Postgres{User: "app", Database: "app_db"}).Check(testContext(t), addr)
+			assertResult(t, err, tt.wantErr, tt.wantNotReady)
+
+			body := <-startup
+			if version := binary.BigEndian.Uint32(body[:4]); version != 196608 {
+				t.Errorf("startup protocol version = %d, want 196608", version)
+			}
+			if want := "user\x00app\x00database\x00app_db\x00\x00"; string(body[4:]) != want {
+				t.Errorf("startup parameters = %q, want %q", body[4:], want)
+			}
+		})
+	}
+}
+
+func TestRedis(t *testing.T) {
+	tests := []struct {
+		name         string
+		reply        string
+		wantErr      bool
+		wantNotReady bool
+	}{
+		{name: "should be ready on PONG", reply: "+PONG\r\n"},
+		{name: "should not be ready while loading", reply: "-LOADING Redis is loading the dataset in memory\r\n", wantErr: true, wantNotReady: true},
+		{name: "should be ready when authentication is required", reply: "-NOAUTH Authentication required.\r\n"},
+		{name: "should fail on an unexpected reply", reply: "hello\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := fakeServer(t, func(conn net.Conn) {
+				reader := bufio.NewReader(conn)
+				for i := 0; i < 3; i++ {
+					if _, err := reader.ReadString('\n'); err != nil {
+						return
+					}
+				}
+				conn.Write([]byte(tt.reply))
+			})
+
+			err := (&Redis{}).Check(testContext(t), addr)
+			assertResult(t, err, tt.wantErr, tt.wantNotReady)
+		})
+	}
+}
+
+func TestKafka(t *testing.T) {
+	tests := []struct {
+		name          string
+		correlationID uint32
+		errorCode     uint16
+		wantErr       bool
+		wantNotReady  bool
+	}{
+		{name: "should be ready when ApiVersions succeeds", correlationID: kafkaCorrelationID},
+		{name: "should not be ready when the broker returns an error code", correlationID: kafkaCorrelationID, errorCode: 35, wantErr: true, wantNotReady: true},
+		{name: "should fail on a mismatched correlation id", correlationID: 99, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make(chan []byte, 1)
+			addr := fakeServer(t, func(conn net.Conn) {
+				sizeBuf := make([]byte, 4)
+				if _, err := io.ReadFull(conn, sizeBuf); err != nil {
+					return
+				}
+				request := make([]byte, binary.BigEndian.Uint32(sizeBuf))
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				requests <- request
+
+				response := binary.BigEndian.AppendUint32(nil, 10)
+				response = binary.BigEndian.AppendUint32(response, tt.correlationID)
+				response = binary.BigEndian.AppendUint16(response, tt.errorCode)
+				response = binary.BigEndian.AppendUint32(response, 0) // empty api_keys array
+				conn.Write(response)
+			})
+
+			err := (&Kafka{}).Check(testContext(t), addr)
+			assertResult(t, err, tt.wantErr, tt.wantNotReady)
+
+			request := <-requests
+			if apiKey := binary.BigEndian.Uint16(request[:2]); apiKey != kafkaAPIVersionsKey {
+				t.Errorf("request api key = %d, want %d", apiKey, kafkaAPIVersionsKey)
+			}
+		})
+	}
+}
+
+func TestForService(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   *compose.Service
+		wantProbe string
+		wantAddr  string
+		wantOK    bool
+	}{
+		{
+			name: "should pick postgres probe with credentials from the environment",
+			service: &compose.Service{
+				Image:       "postgres:15-alpine",
+				Environment: map[string]string{"POSTGRES_USER": "auth"},
+				Ports:       []compose.Port{{Published: 5433, Target: 5432}},
+			},
+			wantProbe: "postgres",
+			wantAddr:  "localhost:5433",
+			wantOK:    true,
+		},
+		{
+			name:      "should pick redis probe",
+			service:   &compose.Service{Image: "redis:7-alpine", Ports: []compose.Port{{Published: 6379, Target: 6379}}},
+			wantProbe: "redis",
+			wantAddr:  "localhost:6379",
+			wantOK:    true,
+		},
+		{
+			name: "should pick kafka probe on the port publishing 9092",
+			service: &compose.Service{
+				Image: "confluentinc/cp-kafka:7.4.0",
+				Ports: []compose.Port{{Published: 9101, Target: 9101}, {HostIP: "127.0.0.1", Published: 19092, Target: 9092}},
+			},
+			wantProbe: "kafka",
+			wantAddr:  "127.0.0.1:19092",
+			wantOK:    true,
+		},
+		{
+			name:      "should fall back to tcp for other images",
+			service:   &compose.Service{Image: "mongo:7.0", Ports: []compose.Port{{Published: 27017, Target: 27017}}},
+			wantProbe: "tcp",
+			wantAddr:  "localhost:27017",
+			wantOK:    true,
+		},
+		{
+			name:    "should report nothing to probe without published ports",
+			service: &compose.Service{Image: "redis:7", Ports: []compose.Port{{Target: 6379}}},
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, addr, ok := ForService(tt.service)
+			if ok != tt.wantOK {
+				t.Fatalf("ForService() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if p.Name() != tt.wantProbe || addr != tt.wantAddr {
+				t.Errorf("ForService() = %s on %s, want %s on %s", p.Name(), addr, tt.wantProbe, tt.wantAddr)
+			}
+		})
+	}
+
+	p, _, _ := ForService(&compose.Service{
+		Image:       "postgres:15",
+		Environment: map[string]string{"POSTGRES_USER": "auth"},
+		Ports:       []compose.Port{{Published: 5432, Target: 5432}},
+	})
+	if pg := p.(*Postgres); pg.User != "auth" || pg.Database != "auth" {
+		t.Errorf("Postgres probe = %+v, want user and database auth", pg)
+	}
+}
+
+// Helper functions
+
+// fakeServer accepts connections on a local port and hands each one to handle
+func fakeServer(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// closedAddr returns an address nothing is listening on
+func closedAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func assertResult(t *testing.T, err error, wantErr, wantNotReady bool) {
+	t.Helper()
+
+	if wantErr && err == nil {
+		t.Fatalf("Check() expected error, got nil")
+	}
+	if !wantErr && err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	if errors.Is(err, ErrNotReady) != wantNotReady {
+		t.Errorf("Check() error = %v, want ErrNotReady = %v", err, wantNotReady)
+	}
+}
+
+func postgresMessage(msgType byte, body []byte) []byte {
+	msg := []byte{msgType}
+	msg = binary.BigEndian.AppendUint32(msg, uint32(4+len(body)))
+	return append(msg, body...)
+}
+
+func postgresErrorBody(code, message string) []byte {
+	body := []byte("SFATAL\x00")
+	body = append(body, 'C')
+	body = append(body, code...)
+	body = append(body, 0, 'M')
+	body = append(body, message...)
+	return append(body, 0, 0)
+}
@@ -0,0 +1,50 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Redis sends PING. The server is ready unless it reports that it is still loading its dataset
+// or otherwise busy; authentication errors come from a running server and count as ready.
+type Redis struct{}
+
+var redisNotReadyErrors = []string{"LOADING", "BUSY", "MASTERDOWN"}
+
+func (p *Redis) Name() string {
+	return "redis"
+}
+
+func (p *Redis) Check(ctx context.Context, addr string) error {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("*1\r\n$4\r\nPING\r\n")); err != nil {
+		return fmt.Errorf("failed to send PING: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read PING reply: %w", err)
+	}
+	reply = strings.TrimSpace(reply)
+
+	switch {
+	case strings.HasPrefix(reply, "+"):
+		return nil
+	case strings.HasPrefix(reply, "-"):
+		for _, prefix := range redisNotReadyErrors {
+			if strings.HasPrefix(reply[1:], prefix) {
+				return notReady("%s", reply[1:])
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected PING reply %q", reply)
+	}
+}
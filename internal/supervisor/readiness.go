@@ -0,0 +1,47 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// readinessChecker checks whether a started process is ready to serve
+type readinessChecker struct {
+	client *http.Client
+}
+
+func newReadinessChecker() *readinessChecker {
+	return &readinessChecker{client: &http.Client{Timeout: 2 * time.Second}}
+}
+
+// check requests the readiness URL, or connects to the port when there is none
+func (c *readinessChecker) check(ctx context.Context, p Process) error {
+	if p.ReadinessURL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.ReadinessURL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("readiness URL returned %s", resp.Status)
+		}
+		return nil
+	}
+
+	var dialer net.Dialer
+	dialCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(p.Port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
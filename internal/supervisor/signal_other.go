@@ -0,0 +1,21 @@
+//go:build !unix
+
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op; only the process itself can be signalled
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminate interrupts the process
+func terminate(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(os.Interrupt)
+}
+
+// kill kills the process
+func kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,299 @@
+package supervisor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Process describes a service to run
+type Process struct {
+	Name         string
+	Command      string // Run through sh -c
+	Dir          string
+	Env          []string // KEY=VALUE pairs added to the inherited environment
+	Port         int
+	ReadinessURL string
+	After        []string // Processes that must be ready before this one starts
+}
+
+// Event is a line written by a process, or a lifecycle message from the supervisor
+type Event struct {
+	Process string
+	Text    string
+	System  bool // Written by the supervisor rather than the process
+}
+
+// backoff controls the delay before a crashed process is restarted. The delay starts at
+// Initial and doubles after every crash up to Max; it is reset once a process has been
+// running for Reset.
+type backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Reset   time.Duration
+}
+
+// defaultBackoff is the restart policy of new supervisors
+var defaultBackoff = backoff{Initial: time.Second, Max: 30 * time.Second, Reset: 30 * time.Second}
+
+// Supervisor runs processes, restarting them when they exit, until its context is cancelled
+type Supervisor struct {
+	processes    []Process
+	emit         func(Event)
+	emitMu       sync.Mutex
+	backoff      backoff
+	readyTimeout time.Duration
+	stopTimeout  time.Duration
+	interval     time.Duration
+	checker      *readinessChecker
+}
+
+// New creates a new Supervisor. Processes must be ordered so that each comes after the
+// processes it starts after. emit is called for every event, one at a time.
+func New(processes []Process, readyTimeout time.Duration, emit func(Event)) *Supervisor {
+	return &Supervisor{
+		processes:    processes,
+		emit:         emit,
+		backoff:      defaultBackoff,
+		readyTimeout: readyTimeout,
+		stopTimeout:  10 * time.Second,
+		interval:     500 * time.Millisecond,
+		checker:      newReadinessChecker(),
+	}
+}
+
+// runner is the state of a single supervised process
+type runner struct {
+	process    Process
+	ready      chan struct{} // Closed once the process is first ready, or given up on
+	stopped    chan struct{} // Closed once the process is stopped for good
+	dependents []*runner
+}
+
+// Run starts every process once the processes it comes after are ready, and keeps them
+// running until ctx is cancelled. Processes are then stopped in reverse dependency order.
+func (s *Supervisor) Run(ctx context.Context) error {
+	runners := make(map[string]*runner, len(s.processes))
+	for _, p := range s.processes {
+		runners[p.Name] = &runner{process: p, ready: make(chan struct{}), stopped: make(chan struct{})}
+	}
+	for _, p := range s.processes {
+		for _, dep := range p.After {
+			r, ok := runners[dep]
+			if !ok {
+				return fmt.Errorf("%s starts after unknown process %q", p.Name, dep)
+			}
+			r.dependents = append(r.dependents, runners[p.Name])
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range s.processes {
+		wg.Add(1)
+		go func(r *runner) {
+			defer wg.Done()
+			defer close(r.stopped)
+			s.supervise(ctx, r, runners)
+		}(runners[p.Name])
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// supervise runs a process, restarting it after crashes, until ctx is cancelled
+func (s *Supervisor) supervise(ctx context.Context, r *runner, runners map[string]*runner) {
+	name := r.process.Name
+
+	for _, dep := range r.process.After {
+		select {
+		case <-runners[dep].ready:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	delay := s.backoff.Initial
+	readyOnce := sync.Once{}
+	markReady := func() { readyOnce.Do(func() { close(r.ready) }) }
+	defer markReady()
+
+	// The ready timeout counts from the first start, so a process that keeps exiting before its
+	// readiness check passes still releases its dependents
+	readyTimer := time.AfterFunc(s.readyTimeout, func() {
+		readyOnce.Do(func() {
+			s.system(name, "not ready after %s; starting dependents anyway", s.readyTimeout)
+			close(r.ready)
+		})
+	})
+	defer readyTimer.Stop()
+
+	for {
+		started := time.Now()
+		cmd, output, err := s.start(r.process)
+		if err != nil {
+			s.system(name, "failed to start: %v", err)
+		} else {
+			s.system(name, "started (pid %d)", cmd.Process.Pid)
+			forwarded := make(chan struct{})
+			go func() {
+				defer close(forwarded)
+				s.forward(name, output)
+			}()
+
+			exited := make(chan error, 1)
+			go func() {
+				err := cmd.Wait()
+				// Children left behind by the shell would keep the port and the output open
+				_ = kill(cmd)
+				<-forwarded
+				exited <- err
+			}()
+
+			checkCtx, cancelCheck := context.WithCancel(ctx)
+			go s.awaitReady(checkCtx, r, markReady)
+
+			select {
+			case err = <-exited:
+				cancelCheck()
+			case <-ctx.Done():
+				cancelCheck()
+				// Dependents go first so they never see their dependencies disappear
+				for _, dependent := range r.dependents {
+					<-dependent.stopped
+				}
+				s.stop(name, cmd, exited)
+				return
+			}
+
+			if time.Since(started) >= s.backoff.Reset {
+				delay = s.backoff.Initial
+			}
+			s.system(name, "exited: %s; restarting in %s", exitReason(err), delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > s.backoff.Max {
+			delay = s.backoff.Max
+		}
+	}
+}
+
+// start starts a process with its stdout and stderr combined
+func (s *Supervisor) start(p Process) (*exec.Cmd, io.ReadCloser, error) {
+	cmd := exec.Command("sh", "-c", p.Command)
+	cmd.Dir = p.Dir
+	cmd.Env = append(os.Environ(), p.Env...)
+	if p.Port != 0 {
+		cmd.Env = append(cmd.Env, "PORT="+strconv.Itoa(p.Port))
+	}
+	setProcessGroup(cmd)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	err = cmd.Start()
+	// The child holds its own copy; the read end sees EOF once every writer is gone
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	return cmd, reader, nil
+}
+
+// forward emits every line the process writes until its output is closed
+func (s *Supervisor) forward(name string, output io.ReadCloser) {
+	defer output.Close()
+
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.send(Event{Process: name, Text: scanner.Text()})
+	}
+	// Keep draining so a process writing very long lines is never blocked
+	io.Copy(io.Discard, output)
+}
+
+// stop asks the process group to terminate and kills it when it does not exit in time
+func (s *Supervisor) stop(name string, cmd *exec.Cmd, exited <-chan error) {
+	if err := terminate(cmd); err != nil {
+		s.system(name, "failed to stop: %v", err)
+	}
+
+	select {
+	case <-exited:
+		s.system(name, "stopped")
+	case <-time.After(s.stopTimeout):
+		_ = kill(cmd)
+		<-exited
+		s.system(name, "killed after not stopping within %s", s.stopTimeout)
+	}
+}
+
+// awaitReady marks the process ready once its readiness check passes. The ready timeout is
+// enforced by supervise, across restarts.
+func (s *Supervisor) awaitReady(ctx context.Context, r *runner, markReady func()) {
+	select {
+	case <-r.ready:
+		return
+	default:
+	}
+
+	name := r.process.Name
+	if r.process.ReadinessURL == "" && r.process.Port == 0 {
+		markReady()
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.checker.check(ctx, r.process); err == nil {
+			s.system(name, "ready")
+			markReady()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// system emits a lifecycle message
+func (s *Supervisor) system(name, format string, args ...interface{}) {
+	s.send(Event{Process: name, Text: fmt.Sprintf(format, args...), System: true})
+}
+
+func (s *Supervisor) send(event Event) {
+	s.emitMu.Lock()
+	defer s.emitMu.Unlock()
+	s.emit(event)
+}
+
+// exitReason describes how a process ended
+func exitReason(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
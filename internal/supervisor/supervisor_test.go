@@ -0,0 +1,234 @@
+package supervisor
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSupervisorForwardsOutputAndStops(t *testing.T) {
+	events := &recorder{}
+	s := newTestSupervisor([]Process{
+		{Name: "api", Command: `echo "listening on $PORT with $LOG_LEVEL"; exec sleep 60`, Port: 1, Env: []string{"LOG_LEVEL=debug"}},
+	}, events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runAsync(s, ctx)
+
+	events.waitFor(t, "api", "listening on 1 with debug")
+	cancel()
+	waitDone(t, done)
+
+	events.waitFor(t, "api", "stopped")
+}
+
+func TestSupervisorRestartsCrashedProcesses(t *testing.T) {
+	events := &recorder{}
+	s := newTestSupervisor([]Process{
+		{Name: "worker", Command: "echo boot; exit 3"},
+	}, events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runAsync(s, ctx)
+
+	for i := 0; i < 3; i++ {
+		events.waitForCount(t, "worker", "boot", i+1)
+	}
+	cancel()
+	waitDone(t, done)
+
+	if !events.contains("worker", "exited: exit status 3; restarting in") {
+		t.Errorf("events = %q, want the crash reported", events.texts())
+	}
+}
+
+func TestSupervisorOrdersStartupAndShutdown(t *testing.T) {
+	port := freePort(t)
+	events := &recorder{}
+	s := newTestSupervisor([]Process{
+		{Name: "auth", Command: "exec sleep 60", Port: port},
+		{Name: "orders", Command: "echo orders up; exec sleep 60", After: []string{"auth"}},
+	}, events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runAsync(s, ctx)
+
+	// orders must wait until auth accepts connections
+	time.Sleep(100 * time.Millisecond)
+	if events.contains("orders", "orders up") {
+		t.Fatalf("orders started before auth was ready")
+	}
+
+	listener, err := net.Listen("tcp", "localhost:"+strconv.Itoa(port))
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	events.waitFor(t, "auth", "ready")
+	events.waitFor(t, "orders", "orders up")
+	cancel()
+	waitDone(t, done)
+
+	if stoppedOrders, stoppedAuth := events.index("orders", "stopped"), events.index("auth", "stopped"); stoppedOrders < 0 || stoppedOrders > stoppedAuth {
+		t.Errorf("events = %q, want orders stopped before auth", events.texts())
+	}
+}
+
+func TestSupervisorReleasesDependentsAfterReadyTimeout(t *testing.T) {
+	events := &recorder{}
+	s := newTestSupervisor([]Process{
+		{Name: "auth", Command: "exec sleep 60", Port: freePort(t)},
+		{Name: "orders", Command: "echo orders up; exec sleep 60", After: []string{"auth"}},
+	}, events)
+	s.readyTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runAsync(s, ctx)
+
+	events.waitFor(t, "orders", "orders up")
+	cancel()
+	waitDone(t, done)
+
+	if !events.contains("auth", "not ready after 50ms") {
+		t.Errorf("events = %q, want the ready timeout reported", events.texts())
+	}
+}
+
+func TestSupervisorReleasesDependentsOfCrashLoopingProcesses(t *testing.T) {
+	events := &recorder{}
+	s := newTestSupervisor([]Process{
+		{Name: "auth", Command: "exit 1", Port: freePort(t)},
+		{Name: "orders", Command: "echo orders up; exec sleep 60", After: []string{"auth"}},
+	}, events)
+	// Longer than the backoff, so auth never survives a whole timeout
+	s.readyTimeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runAsync(s, ctx)
+
+	events.waitFor(t, "orders", "orders up")
+	cancel()
+	waitDone(t, done)
+
+	if !events.contains("auth", "not ready after 100ms") {
+		t.Errorf("events = %q, want the ready timeout reported", events.texts())
+	}
+}
+
+func TestSupervisorRejectsUnknownDependencies(t *testing.T) {
+	s := newTestSupervisor([]Process{{Name: "orders", Command: "true", After: []string{"auth"}}}, &recorder{})
+	if err := s.Run(context.Background()); err == nil {
+		t.Errorf("Run() expected error for an unknown dependency, got nil")
+	}
+}
+
+// Helper functions
+
+// recorder collects events
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) record(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// index returns the position of the first event of the process containing text, or -1
+func (r *recorder) index(process, text string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, event := range r.events {
+		if event.Process == process && strings.Contains(event.Text, text) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *recorder) contains(process, text string) bool {
+	return r.index(process, text) >= 0
+}
+
+func (r *recorder) count(process, text string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, event := range r.events {
+		if event.Process == process && strings.Contains(event.Text, text) {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *recorder) texts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	texts := make([]string, 0, len(r.events))
+	for _, event := range r.events {
+		texts = append(texts, event.Process+": "+event.Text)
+	}
+	return texts
+}
+
+func (r *recorder) waitFor(t *testing.T, process, text string) {
+	t.Helper()
+	r.waitForCount(t, process, text, 1)
+}
+
+func (r *recorder) waitForCount(t *testing.T, process, text string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for r.count(process, text) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d events %q of %s; got %q", n, text, process, r.texts())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newTestSupervisor returns a Supervisor with short delays
+func newTestSupervisor(processes []Process, events *recorder) *Supervisor {
+	s := New(processes, 5*time.Second, events.record)
+	s.backoff = backoff{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Reset: time.Hour}
+	s.interval = 10 * time.Millisecond
+	s.stopTimeout = 2 * time.Second
+	return s
+}
+
+func runAsync(s *Supervisor, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	return done
+}
+
+func waitDone(t *testing.T, done <-chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Run() did not return after cancellation")
+	}
+}
+
+// freePort returns a local port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
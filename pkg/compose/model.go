@@ -0,0 +1,251 @@
+package compose
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Project is a parsed docker-compose file
+type Project struct {
+	Path     string     `json:"path"`
+	Services []*Service `json:"services"` // In file order
+	Volumes  []string   `json:"volumes"`  // Named volumes declared at the top level
+	Networks []Network  `json:"networks"`
+}
+
+// Service is a single service definition
+type Service struct {
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	ContainerName string            `json:"containerName,omitempty"`
+	Command       []string          `json:"command,omitempty"`
+	Environment   map[string]string `json:"environment,omitempty"`
+	Ports         []Port            `json:"ports,omitempty"`
+	Volumes       []VolumeMount     `json:"volumes,omitempty"`
+	Networks      []string          `json:"networks,omitempty"`
+	DependsOn     []Dependency      `json:"dependsOn,omitempty"`
+	Healthcheck   *Healthcheck      `json:"healthcheck,omitempty"`
+}
+
+// Port is a container port, optionally published on the host
+type Port struct {
+	HostIP    string `json:"hostIp,omitempty"`
+	Published int    `json:"published,omitempty"` // Zero when the port is not published
+	Target    int    `json:"target"`
+	Protocol  string `json:"protocol"`
+}
+
+// VolumeMount is a volume or bind mount attached to a service
+type VolumeMount struct {
+	Type     string `json:"type"`   // "volume" or "bind"
+	Source   string `json:"source"` // Volume name or host path; empty for anonymous volumes
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+}
+
+// Dependency is an entry of depends_on
+type Dependency struct {
+	Service   string `json:"service"`
+	Condition string `json:"condition"` // service_started, service_healthy or service_completed_successfully
+}
+
+// Healthcheck is a service health check definition
+type Healthcheck struct {
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+	StartPeriod string   `json:"startPeriod,omitempty"`
+	Disable     bool     `json:"disable,omitempty"`
+}
+
+// Network is a top-level network declaration
+type Network struct {
+	Key    string `json:"key"`            // Name used by services
+	Name   string `json:"name,omitempty"` // Actual network name when overridden
+	Driver string `json:"driver,omitempty"`
+}
+
+// Volume types
+const (
+	VolumeTypeVolume = "volume"
+	VolumeTypeBind   = "bind"
+)
+
+// Dependency conditions
+const (
+	ConditionStarted   = "service_started"
+	ConditionHealthy   = "service_healthy"
+	ConditionCompleted = "service_completed_successfully"
+)
+
+// Service returns the service with the given name
+func (p *Project) Service(name string) (*Service, bool) {
+	for _, service := range p.Services {
+		if service.Name == name {
+			return service, true
+		}
+	}
+	return nil, false
+}
+
+// ServiceNames returns the service names in file order
+func (p *Project) ServiceNames() []string {
+	names := make([]string, 0, len(p.Services))
+	for _, service := range p.Services {
+		names = append(names, service.Name)
+	}
+	return names
+}
+
+// SelectServices returns the named services, or every service when names is empty
+func (p *Project) SelectServices(names []string) ([]*Service, error) {
+	if len(names) == 0 {
+		return p.Services, nil
+	}
+
+	services := make([]*Service, 0, len(names))
+	for _, name := range names {
+		service, ok := p.Service(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q (available: %s)", name, strings.Join(p.ServiceNames(), ", "))
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// WithDependencies returns the named services together with everything they depend on,
+// directly or transitively, in file order. Every service is returned when names is empty.
+func (p *Project) WithDependencies(names []string) ([]*Service, error) {
+	selected, err := p.SelectServices(names)
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]bool)
+	var visit func(service *Service)
+	visit = func(service *Service) {
+		if included[service.Name] {
+			return
+		}
+		included[service.Name] = true
+		for _, dep := range service.DependsOn {
+			if s, ok := p.Service(dep.Service); ok {
+				visit(s)
+			}
+		}
+	}
+	for _, service := range selected {
+		visit(service)
+	}
+
+	services := make([]*Service, 0, len(included))
+	for _, service := range p.Services {
+		if included[service.Name] {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// PublishedPorts returns the ports published on the host
+func (s *Service) PublishedPorts() []Port {
+	var ports []Port
+	for _, port := range s.Ports {
+		if port.Published != 0 {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// PublishedAddress returns the host address ("localhost:5433") for reaching the container
+// port target, or the first published port when target is not published. ok is false when
+// the service publishes no ports.
+func (s *Service) PublishedAddress(target int) (addr string, ok bool) {
+	ports := s.PublishedPorts()
+	if len(ports) == 0 {
+		return "", false
+	}
+
+	chosen := ports[0]
+	for _, port := range ports {
+		if port.Target == target {
+			chosen = port
+			break
+		}
+	}
+
+	host := chosen.HostIP
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(chosen.Published)), true
+}
+
+// NamedVolumes returns the names of the named volumes mounted by the service
+func (s *Service) NamedVolumes() []string {
+	var names []string
+	for _, mount := range s.Volumes {
+		if mount.Type == VolumeTypeVolume && mount.Source != "" {
+			names = append(names, mount.Source)
+		}
+	}
+	return names
+}
+
+// DependencyNames returns the names of the services this service depends on
+func (s *Service) DependencyNames() []string {
+	names := make([]string, 0, len(s.DependsOn))
+	for _, dep := range s.DependsOn {
+		names = append(names, dep.Service)
+	}
+	return names
+}
+
+// String formats a port in compose short syntax, e.g. "5433:5432/tcp"
+func (p Port) String() string {
+	proto := p.Protocol
+	if proto == "" {
+		proto = "tcp"
+	}
+	switch {
+	case p.Published == 0:
+		return fmt.Sprintf("%d/%s", p.Target, proto)
+	case p.HostIP != "":
+		return fmt.Sprintf("%s:%d:%d/%s", p.HostIP, p.Published, p.Target, proto)
+	default:
+		return fmt.Sprintf("%d:%d/%s", p.Published, p.Target, proto)
+	}
+}
+
+// String formats a mount in compose short syntax, e.g. "redis_data:/data"
+func (v VolumeMount) String() string {
+	s := v.Target
+	if v.Source != "" {
+		s = v.Source + ":" + v.Target
+	}
+	if v.ReadOnly {
+		s += ":ro"
+	}
+	return s
+}
+
+// String formats a health check as its command
+func (h *Healthcheck) String() string {
+	if h == nil || h.Disable || len(h.Test) == 0 {
+		return ""
+	}
+
+	test := h.Test
+	switch test[0] {
+	case "NONE":
+		return ""
+	case "CMD", "CMD-SHELL":
+		test = test[1:]
+	}
+	return strings.Join(test, " ")
+}
@@ -0,0 +1,131 @@
+package compose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OverrideFileName is the name of the override file kept next to the compose file
+const OverrideFileName = "docker-compose.override.yml"
+
+// OverridePath returns the override file belonging to a compose file
+func OverridePath(composePath string) string {
+	return filepath.Join(filepath.Dir(composePath), OverrideFileName)
+}
+
+// LoadWithOverride loads a compose file and applies the port mappings of its override file,
+// when one exists. Only ports are taken from the override file.
+func LoadWithOverride(path string) (*Project, error) {
+	project, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overridePath := OverridePath(path)
+	if _, err := os.Stat(overridePath); errors.Is(err, fs.ErrNotExist) {
+		return project, nil
+	}
+
+	override, err := Load(overridePath)
+	if err != nil {
+		return nil, err
+	}
+	project.ApplyPortOverrides(override)
+
+	return project, nil
+}
+
+// ApplyPortOverrides replaces the ports of every service that declares ports in override
+func (p *Project) ApplyPortOverrides(override *Project) {
+	for _, service := range override.Services {
+		if target, ok := p.Service(service.Name); ok && len(service.Ports) > 0 {
+			target.Ports = service.Ports
+		}
+	}
+}
+
+// WritePortOverrides sets the ports of the given services in an override file, keeping
+// anything else the file already contains. Port lists are tagged !override so Compose
+// replaces the ports of the base file instead of appending to them.
+func WritePortOverrides(path string, ports map[string][]Port) error {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update %s: expected a mapping at the top level", path)
+	}
+
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := mappingValue(root, "services")
+	for _, name := range names {
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!override"}
+		for _, port := range ports[name] {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: port.String(), Style: yaml.DoubleQuotedStyle})
+		}
+		setMappingValue(mappingValue(services, name), "ports", list)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// mappingValue returns the mapping stored under key, creating it when missing
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.MappingNode {
+			return node.Content[i+1]
+		}
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(node, key, value)
+	return value
+}
+
+// setMappingValue stores value under key, replacing any existing entry
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
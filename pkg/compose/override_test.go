@@ -0,0 +1,78 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritePortOverrides(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "docker-compose.dependencies.yml")
+	if err := os.WriteFile(composePath, []byte(dependenciesFile), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	// Existing user settings in the override file must survive
+	overridePath := OverridePath(composePath)
+	existing := `services:
+  redis:
+    environment:
+      REDIS_ARGS: --maxmemory 64mb
+    ports:
+      - "6379:6379"
+`
+	if err := os.WriteFile(overridePath, []byte(existing), 0644); err != nil {
+		t.Fatalf("failed to write override file: %v", err)
+	}
+
+	err := WritePortOverrides(overridePath, map[string][]Port{
+		"redis":         {{Published: 6380, Target: 6379, Protocol: "tcp"}},
+		"postgres-auth": {{HostIP: "127.0.0.1", Published: 5434, Target: 5432, Protocol: "tcp"}},
+	})
+	if err != nil {
+		t.Fatalf("WritePortOverrides() unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		t.Fatalf("failed to read override file: %v", err)
+	}
+	for _, want := range []string{"REDIS_ARGS: --maxmemory 64mb", `ports: !override`, `"6380:6379/tcp"`, `"127.0.0.1:5434:5432/tcp"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("override file missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), `"6379:6379"`) {
+		t.Errorf("override file still contains the old redis port:\n%s", data)
+	}
+
+	project, err := LoadWithOverride(composePath)
+	if err != nil {
+		t.Fatalf("LoadWithOverride() unexpected error: %v", err)
+	}
+	redis, _ := project.Service("redis")
+	if got := redis.PublishedPorts(); len(got) != 1 || got[0].Published != 6380 {
+		t.Errorf("redis ports = %v, want 6380:6379", got)
+	}
+	kafka, _ := project.Service("kafka")
+	if got := kafka.PublishedPorts(); len(got) != 1 || got[0].Published != 9092 {
+		t.Errorf("kafka ports = %v, want them unchanged", got)
+	}
+}
+
+func TestLoadWithOverrideWithoutOverrideFile(t *testing.T) {
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(dependenciesFile), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	project, err := LoadWithOverride(composePath)
+	if err != nil {
+		t.Fatalf("LoadWithOverride() unexpected error: %v", err)
+	}
+	if len(project.Services) != 4 {
+		t.Errorf("LoadWithOverride() = %d services, want 4", len(project.Services))
+	}
+}
@@ -0,0 +1,563 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type rawProject struct {
+	Services yaml.Node `yaml:"services"`
+	Volumes  yaml.Node `yaml:"volumes"`
+	Networks yaml.Node `yaml:"networks"`
+}
+
+type rawService struct {
+	Image         string          `yaml:"image"`
+	ContainerName string          `yaml:"container_name"`
+	Command       yaml.Node       `yaml:"command"`
+	Environment   yaml.Node       `yaml:"environment"`
+	Ports         []yaml.Node     `yaml:"ports"`
+	Volumes       []yaml.Node     `yaml:"volumes"`
+	Networks      yaml.Node       `yaml:"networks"`
+	DependsOn     yaml.Node       `yaml:"depends_on"`
+	Healthcheck   *rawHealthcheck `yaml:"healthcheck"`
+}
+
+type rawHealthcheck struct {
+	Test        yaml.Node `yaml:"test"`
+	Interval    string    `yaml:"interval"`
+	Timeout     string    `yaml:"timeout"`
+	Retries     int       `yaml:"retries"`
+	StartPeriod string    `yaml:"start_period"`
+	Disable     bool      `yaml:"disable"`
+}
+
+// Load reads and parses a compose file
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file %s: %w", path, err)
+	}
+
+	project, err := Parse(data, os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compose file %s: %w", path, err)
+	}
+	project.Path = path
+
+	return project, nil
+}
+
+// Parse parses compose file contents. Variables in values (${VAR}, ${VAR:-default}, $VAR)
+// are resolved with lookupEnv.
+func Parse(data []byte, lookupEnv func(string) (string, bool)) (*Project, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("compose file is empty")
+	}
+	if err := interpolateNode(&doc, lookupEnv); err != nil {
+		return nil, err
+	}
+
+	var raw rawProject
+	if err := doc.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	project := &Project{}
+
+	err := forEachMapping(&raw.Services, func(name string, value *yaml.Node) error {
+		service, err := parseService(name, value)
+		if err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+		project.Services = append(project.Services, service)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEachMapping(&raw.Volumes, func(name string, value *yaml.Node) error {
+		project.Volumes = append(project.Volumes, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = forEachMapping(&raw.Networks, func(key string, value *yaml.Node) error {
+		network := Network{Key: key}
+		if value.Kind == yaml.MappingNode {
+			if err := value.Decode(&network); err != nil {
+				return fmt.Errorf("network %s: %w", key, err)
+			}
+			network.Key = key
+		}
+		project.Networks = append(project.Networks, network)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, service := range project.Services {
+		for _, dep := range service.DependsOn {
+			if _, ok := project.Service(dep.Service); !ok {
+				return nil, fmt.Errorf("service %s depends on undefined service %s", service.Name, dep.Service)
+			}
+		}
+	}
+
+	return project, nil
+}
+
+func parseService(name string, node *yaml.Node) (*Service, error) {
+	var raw rawService
+	if err := node.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	service := &Service{
+		Name:          name,
+		Image:         raw.Image,
+		ContainerName: raw.ContainerName,
+		Command:       stringOrList(&raw.Command),
+	}
+
+	env, err := parseEnvironment(&raw.Environment)
+	if err != nil {
+		return nil, fmt.Errorf("environment: %w", err)
+	}
+	service.Environment = env
+
+	for _, p := range raw.Ports {
+		ports, err := parsePort(&p)
+		if err != nil {
+			return nil, fmt.Errorf("ports: %w", err)
+		}
+		service.Ports = append(service.Ports, ports...)
+	}
+
+	for _, v := range raw.Volumes {
+		mount, err := parseVolumeMount(&v)
+		if err != nil {
+			return nil, fmt.Errorf("volumes: %w", err)
+		}
+		service.Volumes = append(service.Volumes, mount)
+	}
+
+	service.Networks, err = keysOrList(&raw.Networks)
+	if err != nil {
+		return nil, fmt.Errorf("networks: %w", err)
+	}
+
+	service.DependsOn, err = parseDependsOn(&raw.DependsOn)
+	if err != nil {
+		return nil, fmt.Errorf("depends_on: %w", err)
+	}
+
+	if raw.Healthcheck != nil {
+		service.Healthcheck = &Healthcheck{
+			Test:        stringOrList(&raw.Healthcheck.Test),
+			Interval:    raw.Healthcheck.Interval,
+			Timeout:     raw.Healthcheck.Timeout,
+			Retries:     raw.Healthcheck.Retries,
+			StartPeriod: raw.Healthcheck.StartPeriod,
+			Disable:     raw.Healthcheck.Disable,
+		}
+		// A plain string test is run through the container's shell
+		if raw.Healthcheck.Test.Kind == yaml.ScalarNode && raw.Healthcheck.Test.Value != "" {
+			service.Healthcheck.Test = []string{"CMD-SHELL", raw.Healthcheck.Test.Value}
+		}
+	}
+
+	return service, nil
+}
+
+// parseEnvironment accepts both the mapping form and the list of KEY=VALUE form
+func parseEnvironment(node *yaml.Node) (map[string]string, error) {
+	node = deref(node)
+	env := make(map[string]string)
+
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.MappingNode:
+		err := forEachMapping(node, func(key string, value *yaml.Node) error {
+			if value.Tag != "!!null" {
+				env[key] = value.Value
+			} else {
+				env[key] = ""
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, _ := strings.Cut(deref(item).Value, "=")
+			env[key] = value
+		}
+	default:
+		return nil, fmt.Errorf("line %d: expected a mapping or a list", node.Line)
+	}
+
+	return env, nil
+}
+
+// parsePort parses short ("[host_ip:][published:]target[/protocol]", with optional ranges)
+// and long port syntax
+func parsePort(node *yaml.Node) ([]Port, error) {
+	node = deref(node)
+	if node.Kind == yaml.MappingNode {
+		var long struct {
+			Target    int    `yaml:"target"`
+			Published string `yaml:"published"`
+			HostIP    string `yaml:"host_ip"`
+			Protocol  string `yaml:"protocol"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return nil, err
+		}
+		port := Port{Target: long.Target, HostIP: long.HostIP, Protocol: long.Protocol}
+		if long.Published != "" {
+			published, err := strconv.Atoi(long.Published)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid published port %q", node.Line, long.Published)
+			}
+			port.Published = published
+		}
+		if port.Protocol == "" {
+			port.Protocol = "tcp"
+		}
+		return []Port{port}, nil
+	}
+
+	spec := node.Value
+	protocol := "tcp"
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		spec, protocol = spec[:i], spec[i+1:]
+	}
+
+	var hostIP, published, target string
+	parts := strings.Split(spec, ":")
+	switch {
+	case len(parts) == 1:
+		target = parts[0]
+	case len(parts) == 2:
+		published, target = parts[0], parts[1]
+	default:
+		hostIP = strings.Trim(strings.Join(parts[:len(parts)-2], ":"), "[]")
+		published, target = parts[len(parts)-2], parts[len(parts)-1]
+	}
+
+	targets, err := parsePortRange(target)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: invalid port %q: %w", node.Line, node.Value, err)
+	}
+	publishedPorts := make([]int, len(targets))
+	if published != "" {
+		publishedPorts, err = parsePortRange(published)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid port %q: %w", node.Line, node.Value, err)
+		}
+		if len(publishedPorts) != len(targets) {
+			return nil, fmt.Errorf("line %d: invalid port %q: published and target ranges differ in size", node.Line, node.Value)
+		}
+	}
+
+	ports := make([]Port, len(targets))
+	for i := range targets {
+		ports[i] = Port{HostIP: hostIP, Published: publishedPorts[i], Target: targets[i], Protocol: protocol}
+	}
+	return ports, nil
+}
+
+// parsePortRange parses "8080" or "8080-8082"
+func parsePortRange(spec string) ([]int, error) {
+	start, end, isRange := strings.Cut(spec, "-")
+
+	first, err := strconv.Atoi(start)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return []int{first}, nil
+	}
+
+	last, err := strconv.Atoi(end)
+	if err != nil {
+		return nil, err
+	}
+	if last < first {
+		return nil, fmt.Errorf("range end %d is before start %d", last, first)
+	}
+
+	ports := make([]int, 0, last-first+1)
+	for port := first; port <= last; port++ {
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// parseVolumeMount parses short ("[source:]target[:mode]") and long volume syntax
+func parseVolumeMount(node *yaml.Node) (VolumeMount, error) {
+	node = deref(node)
+	if node.Kind == yaml.MappingNode {
+		var long struct {
+			Type     string `yaml:"type"`
+			Source   string `yaml:"source"`
+			Target   string `yaml:"target"`
+			ReadOnly bool   `yaml:"read_only"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return VolumeMount{}, err
+		}
+		return VolumeMount{Type: long.Type, Source: long.Source, Target: long.Target, ReadOnly: long.ReadOnly}, nil
+	}
+
+	parts := strings.Split(node.Value, ":")
+	mount := VolumeMount{Type: VolumeTypeVolume}
+
+	switch len(parts) {
+	case 1:
+		mount.Target = parts[0]
+		return mount, nil
+	case 2, 3:
+		mount.Source, mount.Target = parts[0], parts[1]
+		if len(parts) == 3 {
+			mount.ReadOnly = strings.Contains(parts[2], "ro")
+		}
+	default:
+		return VolumeMount{}, fmt.Errorf("line %d: invalid volume %q", node.Line, node.Value)
+	}
+
+	if strings.HasPrefix(mount.Source, "/") || strings.HasPrefix(mount.Source, ".") || strings.HasPrefix(mount.Source, "~") {
+		mount.Type = VolumeTypeBind
+	}
+	return mount, nil
+}
+
+// parseDependsOn accepts both the list form and the mapping form with conditions
+func parseDependsOn(node *yaml.Node) ([]Dependency, error) {
+	node = deref(node)
+	var deps []Dependency
+
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			deps = append(deps, Dependency{Service: item.Value, Condition: ConditionStarted})
+		}
+	case yaml.MappingNode:
+		err := forEachMapping(node, func(name string, value *yaml.Node) error {
+			dep := Dependency{Service: name, Condition: ConditionStarted}
+			if value.Kind == yaml.MappingNode {
+				var long struct {
+					Condition string `yaml:"condition"`
+				}
+				if err := value.Decode(&long); err != nil {
+					return err
+				}
+				if long.Condition != "" {
+					dep.Condition = long.Condition
+				}
+			}
+			deps = append(deps, dep)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("line %d: expected a mapping or a list", node.Line)
+	}
+
+	return deps, nil
+}
+
+// stringOrList returns a scalar split on whitespace, or the items of a sequence
+func stringOrList(node *yaml.Node) []string {
+	node = deref(node)
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return strings.Fields(node.Value)
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			items = append(items, item.Value)
+		}
+		return items
+	default:
+		return nil
+	}
+}
+
+// keysOrList returns the keys of a mapping or the items of a sequence
+func keysOrList(node *yaml.Node) ([]string, error) {
+	node = deref(node)
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.SequenceNode:
+		return stringOrList(node), nil
+	case yaml.MappingNode:
+		var keys []string
+		err := forEachMapping(node, func(key string, value *yaml.Node) error {
+			keys = append(keys, key)
+			return nil
+		})
+		return keys, err
+	default:
+		return nil, fmt.Errorf("line %d: expected a mapping or a list", node.Line)
+	}
+}
+
+// forEachMapping calls fn for each key/value pair of a mapping node in document order.
+// A missing (zero) node is treated as an empty mapping.
+// Merge keys ("<<: *anchor") are expanded in place.
+func forEachMapping(node *yaml.Node, fn func(key string, value *yaml.Node) error) error {
+	node = deref(node)
+	if node.Kind == 0 || (node.Kind == yaml.ScalarNode && node.Tag == "!!null") {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], deref(node.Content[i+1])
+
+		if key.Tag == "!!merge" {
+			merged := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				merged = value.Content
+			}
+			for _, m := range merged {
+				if err := forEachMapping(m, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := fn(key.Value, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deref follows YAML aliases to the anchored node
+func deref(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// interpolateNode resolves variables in every scalar value of the document
+func interpolateNode(node *yaml.Node, lookupEnv func(string) (string, bool)) error {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "$") {
+		value, err := interpolate(node.Value, lookupEnv)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+		return nil
+	}
+
+	for i, child := range node.Content {
+		// Only values are interpolated, never mapping keys
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		if err := interpolateNode(child, lookupEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interpolate resolves $VAR, ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?message}
+// and ${VAR?message}; "$$" is a literal dollar sign
+func interpolate(value string, lookupEnv func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 >= len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		next := value[i+1]
+		switch {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable in %q", value)
+			}
+			resolved, err := resolveVariable(value[i+2:i+end], lookupEnv)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(resolved)
+			i += end
+		case isVariableChar(next, true):
+			j := i + 1
+			for j < len(value) && isVariableChar(value[j], j == i+1) {
+				j++
+			}
+			resolved, _ := lookupEnv(value[i+1 : j])
+			b.WriteString(resolved)
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// resolveVariable resolves the inside of ${...}
+func resolveVariable(expr string, lookupEnv func(string) (string, bool)) (string, error) {
+	for _, op := range []string{":-", ":?", "-", "?"} {
+		name, arg, found := strings.Cut(expr, op)
+		if !found {
+			continue
+		}
+
+		value, ok := lookupEnv(name)
+		unset := !ok || (strings.HasPrefix(op, ":") && value == "")
+		if !unset {
+			return value, nil
+		}
+		if strings.HasSuffix(op, "?") {
+			if arg == "" {
+				arg = "is not set"
+			}
+			return "", fmt.Errorf("required variable %s %s", name, arg)
+		}
+		return arg, nil
+	}
+
+	value, _ := lookupEnv(expr)
+	return value, nil
+}
+
+func isVariableChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
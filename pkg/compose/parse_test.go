@@ -0,0 +1,420 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dependenciesFile = `
+version: '3.8'
+
+services:
+  postgres-auth:
+    image: postgres:15-alpine
+    container_name: postgres-auth
+    environment:
+      POSTGRES_DB: auth_db
+      POSTGRES_USER: postgres
+      POSTGRES_PASSWORD: postgres
+    ports:
+      - "5433:5432"
+    volumes:
+      - postgres_auth_data:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres"]
+      interval: 10s
+      timeout: 5s
+      retries: 5
+    networks:
+      - microservices
+
+  zookeeper:
+    image: confluentinc/cp-zookeeper:7.4.0
+    ports:
+      - "2181:2181"
+
+  kafka:
+    image: confluentinc/cp-kafka:7.4.0
+    container_name: kafka
+    depends_on:
+      zookeeper:
+        condition: service_healthy
+    ports:
+      - "9092:9092"
+    volumes:
+      - kafka_data:/var/lib/kafka/data
+
+  redis:
+    image: redis:7-alpine
+    command: redis-server --appendonly yes
+    ports:
+      - "6379:6379"
+
+volumes:
+  postgres_auth_data:
+  kafka_data:
+
+networks:
+  microservices:
+    driver: bridge
+    name: microservices-network
+`
+
+func TestParse(t *testing.T) {
+	project, err := Parse([]byte(dependenciesFile), noEnv)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	if got, want := project.ServiceNames(), []string{"postgres-auth", "zookeeper", "kafka", "redis"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceNames() = %v, want %v (file order)", got, want)
+	}
+
+	postgres, ok := project.Service("postgres-auth")
+	if !ok {
+		t.Fatalf("Service(postgres-auth) not found")
+	}
+	if postgres.Image != "postgres:15-alpine" || postgres.ContainerName != "postgres-auth" {
+		t.Errorf("postgres-auth image/container = %q/%q", postgres.Image, postgres.ContainerName)
+	}
+	if postgres.Environment["POSTGRES_DB"] != "auth_db" {
+		t.Errorf("postgres-auth POSTGRES_DB = %q, want auth_db", postgres.Environment["POSTGRES_DB"])
+	}
+	if want := []Port{{Published: 5433, Target: 5432, Protocol: "tcp"}}; !reflect.DeepEqual(postgres.Ports, want) {
+		t.Errorf("postgres-auth Ports = %+v, want %+v", postgres.Ports, want)
+	}
+	if want := []string{"postgres_auth_data"}; !reflect.DeepEqual(postgres.NamedVolumes(), want) {
+		t.Errorf("postgres-auth NamedVolumes() = %v, want %v", postgres.NamedVolumes(), want)
+	}
+	if postgres.Healthcheck == nil || postgres.Healthcheck.String() != "pg_isready -U postgres" || postgres.Healthcheck.Retries != 5 {
+		t.Errorf("postgres-auth Healthcheck = %+v", postgres.Healthcheck)
+	}
+	if want := []string{"microservices"}; !reflect.DeepEqual(postgres.Networks, want) {
+		t.Errorf("postgres-auth Networks = %v, want %v", postgres.Networks, want)
+	}
+
+	kafka, _ := project.Service("kafka")
+	if want := []Dependency{{Service: "zookeeper", Condition: ConditionHealthy}}; !reflect.DeepEqual(kafka.DependsOn, want) {
+		t.Errorf("kafka DependsOn = %+v, want %+v", kafka.DependsOn, want)
+	}
+
+	redis, _ := project.Service("redis")
+	if want := []string{"redis-server", "--appendonly", "yes"}; !reflect.DeepEqual(redis.Command, want) {
+		t.Errorf("redis Command = %v, want %v", redis.Command, want)
+	}
+
+	if want := []string{"postgres_auth_data", "kafka_data"}; !reflect.DeepEqual(project.Volumes, want) {
+		t.Errorf("Volumes = %v, want %v", project.Volumes, want)
+	}
+	if want := []Network{{Key: "microservices", Name: "microservices-network", Driver: "bridge"}}; !reflect.DeepEqual(project.Networks, want) {
+		t.Errorf("Networks = %+v, want %+v", project.Networks, want)
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports string
+		want  []Port
+	}{
+		{
+			name:  "should parse published and target",
+			ports: `["5433:5432"]`,
+			want:  []Port{{Published: 5433, Target: 5432, Protocol: "tcp"}},
+		},
+		{
+			name:  "should parse an unpublished port",
+			ports: `["8080"]`,
+			want:  []Port{{Target: 8080, Protocol: "tcp"}},
+		},
+		{
+			name:  "should parse host ip and protocol",
+			ports: `["127.0.0.1:5353:53/udp"]`,
+			want:  []Port{{HostIP: "127.0.0.1", Published: 5353, Target: 53, Protocol: "udp"}},
+		},
+		{
+			name:  "should expand port ranges",
+			ports: `["9000-9001:8000-8001"]`,
+			want: []Port{
+				{Published: 9000, Target: 8000, Protocol: "tcp"},
+				{Published: 9001, Target: 8001, Protocol: "tcp"},
+			},
+		},
+		{
+			name:  "should parse long syntax",
+			ports: `[{target: 80, published: 8080, host_ip: 0.0.0.0}]`,
+			want:  []Port{{HostIP: "0.0.0.0", Published: 8080, Target: 80, Protocol: "tcp"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, err := Parse([]byte("services:\n  web:\n    image: nginx\n    ports: "+tt.ports+"\n"), noEnv)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if got := project.Services[0].Ports; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Ports = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAlternativeSyntax(t *testing.T) {
+	data := `
+x-env: &default-env
+  LOG_LEVEL: debug
+
+services:
+  api:
+    image: api:latest
+    environment:
+      <<: *default-env
+      PORT: "8080"
+    depends_on:
+      - db
+    networks:
+      backend:
+        aliases: [api]
+    volumes:
+      - ./config:/etc/api:ro
+      - type: volume
+        source: api_cache
+        target: /cache
+      - /tmp/scratch
+    healthcheck:
+      test: curl -f http://localhost:8080/health
+  db:
+    image: postgres
+    environment:
+      - POSTGRES_PASSWORD=secret
+      - EMPTY
+`
+	project, err := Parse([]byte(data), noEnv)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	api, _ := project.Service("api")
+	if want := map[string]string{"LOG_LEVEL": "debug", "PORT": "8080"}; !reflect.DeepEqual(api.Environment, want) {
+		t.Errorf("api Environment = %v, want %v", api.Environment, want)
+	}
+	if want := []Dependency{{Service: "db", Condition: ConditionStarted}}; !reflect.DeepEqual(api.DependsOn, want) {
+		t.Errorf("api DependsOn = %+v, want %+v", api.DependsOn, want)
+	}
+	if want := []string{"backend"}; !reflect.DeepEqual(api.Networks, want) {
+		t.Errorf("api Networks = %v, want %v", api.Networks, want)
+	}
+	wantVolumes := []VolumeMount{
+		{Type: VolumeTypeBind, Source: "./config", Target: "/etc/api", ReadOnly: true},
+		{Type: VolumeTypeVolume, Source: "api_cache", Target: "/cache"},
+		{Type: VolumeTypeVolume, Target: "/tmp/scratch"},
+	}
+	if !reflect.DeepEqual(api.Volumes, wantVolumes) {
+		t.Errorf("api Volumes = %+v, want %+v", api.Volumes, wantVolumes)
+	}
+	if want := []string{"api_cache"}; !reflect.DeepEqual(api.NamedVolumes(), want) {
+		t.Errorf("api NamedVolumes() = %v, want %v", api.NamedVolumes(), want)
+	}
+	if want := []string{"CMD-SHELL", "curl -f http://localhost:8080/health"}; !reflect.DeepEqual(api.Healthcheck.Test, want) {
+		t.Errorf("api Healthcheck.Test = %v, want %v", api.Healthcheck.Test, want)
+	}
+
+	db, _ := project.Service("db")
+	if want := map[string]string{"POSTGRES_PASSWORD": "secret", "EMPTY": ""}; !reflect.DeepEqual(db.Environment, want) {
+		t.Errorf("db Environment = %v, want %v", db.Environment, want)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"HOST_PORT": "15432", "EMPTY": "", "USER": "admin"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "should resolve braced variables", value: "${HOST_PORT}:5432", want: "15432:5432"},
+		{name: "should resolve bare variables", value: "$USER@db", want: "admin@db"},
+		{name: "should use default when unset", value: "${MISSING:-5432}", want: "5432"},
+		{name: "should use default when empty with colon", value: "${EMPTY:-fallback}", want: "fallback"},
+		{name: "should keep empty value without colon", value: "${EMPTY-fallback}", want: ""},
+		{name: "should unescape double dollar", value: "$$HOME", want: "$HOME"},
+		{name: "should fail for required unset variable", value: "${MISSING:?must be set}", wantErr: true},
+		{name: "should fail for unterminated variable", value: "${HOST_PORT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpolate(tt.value, lookup)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("interpolate() expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpolate() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("interpolate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInterpolatesValues(t *testing.T) {
+	data := "services:\n  db:\n    image: postgres:${PG_VERSION:-15}\n    ports:\n      - \"${DB_PORT}:5432\"\n"
+	lookup := func(name string) (string, bool) {
+		if name == "DB_PORT" {
+			return "6543", true
+		}
+		return "", false
+	}
+
+	project, err := Parse([]byte(data), lookup)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	db := project.Services[0]
+	if db.Image != "postgres:15" {
+		t.Errorf("Image = %q, want postgres:15", db.Image)
+	}
+	if db.Ports[0].Published != 6543 {
+		t.Errorf("Published = %d, want 6543", db.Ports[0].Published)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "should reject an empty file",
+			data:    "",
+			wantErr: "empty",
+		},
+		{
+			name:    "should reject dependencies on undefined services",
+			data:    "services:\n  api:\n    image: api\n    depends_on: [db]\n",
+			wantErr: "undefined service db",
+		},
+		{
+			name:    "should reject invalid ports",
+			data:    "services:\n  api:\n    image: api\n    ports: [\"abc:80\"]\n",
+			wantErr: "invalid port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), noEnv)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(path, []byte(dependenciesFile), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	project, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if project.Path != path {
+		t.Errorf("Path = %q, want %q", project.Path, path)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Errorf("Load() expected error for missing file, got nil")
+	}
+}
+
+func TestSelectServices(t *testing.T) {
+	project, err := Parse([]byte(dependenciesFile), noEnv)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	all, err := project.SelectServices(nil)
+	if err != nil || len(all) != 4 {
+		t.Errorf("SelectServices(nil) = %d services, %v; want 4", len(all), err)
+	}
+
+	some, err := project.SelectServices([]string{"redis", "kafka"})
+	if err != nil || len(some) != 2 || some[0].Name != "redis" {
+		t.Errorf("SelectServices(redis, kafka) = %v, %v", some, err)
+	}
+
+	if _, err := project.SelectServices([]string{"mysql"}); err == nil || !strings.Contains(err.Error(), "unknown service") {
+		t.Errorf("SelectServices(mysql) error = %v, want unknown service", err)
+	}
+}
+
+func TestWithDependencies(t *testing.T) {
+	project, err := Parse([]byte(`
+services:
+  api:
+    image: api
+    depends_on: [kafka, postgres]
+  kafka:
+    image: kafka
+    depends_on: [zookeeper]
+  zookeeper:
+    image: zookeeper
+  postgres:
+    image: postgres
+  redis:
+    image: redis
+`), noEnv)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{names: []string{"kafka"}, want: []string{"kafka", "zookeeper"}},
+		{names: []string{"api", "redis"}, want: []string{"api", "kafka", "zookeeper", "postgres", "redis"}},
+		{names: nil, want: []string{"api", "kafka", "zookeeper", "postgres", "redis"}},
+	}
+
+	for _, tt := range tests {
+		services, err := project.WithDependencies(tt.names)
+		if err != nil {
+			t.Fatalf("WithDependencies(%v) unexpected error: %v", tt.names, err)
+		}
+		var got []string
+		for _, service := range services {
+			got = append(got, service.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WithDependencies(%v) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+
+	if _, err := project.WithDependencies([]string{"mysql"}); err == nil {
+		t.Errorf("WithDependencies(mysql) expected error, got nil")
+	}
+}
+
+// noEnv is a lookup function with no variables set
+func noEnv(string) (string, bool) {
+	return "", false
+}
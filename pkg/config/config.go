@@ -4,20 +4,137 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type Repository struct {
-	Path string `json:"path"`
-	Name string `json:"name"`
+	Path      string            `json:"path"`
+	Name      string            `json:"name"`
+	URL       string            `json:"url,omitempty"`      // Where the repository is cloned from, recorded by "config import"
+	Revision  string            `json:"revision,omitempty"` // Branch, tag or commit an imported manifest pins, for reference
+	Groups    []string          `json:"groups,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"` // Compose services the repository needs to run
+	Env       map[string]string `json:"env,omitempty"`       // Variable name -> compose service for "deps env"
+	Run       *RunConfig        `json:"run,omitempty"`
+
+	DivergencePolicy string `json:"divergencePolicy,omitempty"` // What sync does when the branch has commits origin does not have
+	Submodules       string `json:"submodules,omitempty"`       // How sync updates submodules: "recursive" (default), "top-level" or "off"
+}
+
+// RunConfig describes how "run" starts the service of a repository as a local process
+type RunConfig struct {
+	Command      string            `json:"command"`                // Run through sh -c
+	Env          map[string]string `json:"env,omitempty"`          // Added to the inherited environment
+	WorkingDir   string            `json:"workingDir,omitempty"`   // Relative to the repository path
+	Port         int               `json:"port,omitempty"`         // Exported as PORT and probed when there is no readiness URL
+	ReadinessURL string            `json:"readinessUrl,omitempty"` // Ready once it answers with a non-error status
+	DependsOn    []string          `json:"dependsOn,omitempty"`    // Repositories that must be ready first
+}
+
+// Webhook describes an HTTP endpoint notified when a sync completes
+type Webhook struct {
+	Name           string `json:"name,omitempty"`
+	URL            string `json:"url"`
+	Format         string `json:"format,omitempty"`         // "json" (default) or "slack"
+	On             string `json:"on,omitempty"`             // "failure" (default) or "always"
+	TimeoutSeconds int    `json:"timeoutSeconds,omitempty"` // Per-attempt timeout, defaults to 10
+	Retries        int    `json:"retries,omitempty"`        // Extra attempts after the first one fails
+}
+
+// Webhook formats and triggers
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+	WebhookOnFailure   = "failure"
+	WebhookOnAlways    = "always"
+)
+
+// Policies sync accepts for a branch that has commits origin does not have
+const (
+	DivergenceSkip   = "skip"                          // Leave the repository alone and report the commits
+	DivergenceBackup = "reset-hard-with-backup-branch" // Keep the commits on backup/<branch>-<date> and reset to origin
+	DivergenceRebase = "rebase"                        // Replay the commits on top of origin
+)
+
+// DivergencePolicies lists the accepted divergence policies
+var DivergencePolicies = []string{DivergenceSkip, DivergenceBackup, DivergenceRebase}
+
+// How sync updates the submodules of a repository
+const (
+	SubmodulesRecursive = "recursive" // Initialize and update submodules and their submodules
+	SubmodulesTopLevel  = "top-level" // Initialize and update only the repository's own submodules
+	SubmodulesOff       = "off"       // Leave submodules alone
+)
+
+// SubmoduleModes lists the accepted values of a repository's submodules setting
+var SubmoduleModes = []string{SubmodulesRecursive, SubmodulesTopLevel, SubmodulesOff}
+
+// Git backends selectable with gitBackend
+const (
+	GitBackendExec  = "exec"   // Runs the git binary for every query
+	GitBackendGoGit = "go-git" // Answers read-only queries in process with go-git
+)
+
+// GitBackends lists the accepted values of gitBackend
+var GitBackends = []string{GitBackendExec, GitBackendGoGit}
+
+// DefaultComposeFile is the compose file describing the dependency stack
+const DefaultComposeFile = "docker-compose.dependencies.yml"
+
+// DefaultStateDir returns $XDG_STATE_HOME/go-cli, falling back to ~/.local/state/go-cli
+func DefaultStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "go-cli")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "go-cli")
+	}
+	return ".go-cli"
 }
 
 type Config struct {
 	Repositories []Repository `json:"repositories"`
 	GitBranch    string       `json:"gitBranch,omitempty"`
+	Webhooks     []Webhook    `json:"webhooks,omitempty"`
+	ComposeFile  string       `json:"composeFile,omitempty"`
+	StateDir     string       `json:"stateDir,omitempty"` // Where local state such as volume snapshots is kept
+
+	ProtectedBranches []string `json:"protectedBranches,omitempty"` // Glob patterns "prune" never deletes, e.g. "release/*"
+	GitBackend        string   `json:"gitBackend,omitempty"`        // How read-only git queries run: "exec" (default) or "go-git"
 }
 
 func LoadFromFile(configFile string) (*Config, error) {
+	config, err := ReadFromFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set default branch if not specified
+	if config.GitBranch == "" {
+		config.GitBranch = "main"
+	}
+
+	if config.ComposeFile == "" {
+		config.ComposeFile = DefaultComposeFile
+	}
+
+	if config.StateDir == "" {
+		config.StateDir = DefaultStateDir()
+	}
+
+	for i := range config.Webhooks {
+		config.Webhooks[i].applyDefaults()
+	}
+
+	return config, nil
+}
+
+// ReadFromFile reads the config as written, without applying defaults, so that it can be
+// saved back with SaveToFile without gaining settings the user never wrote
+func ReadFromFile(configFile string) (*Config, error) {
 	if configFile == "" {
 		configFile = "config.json"
 	}
@@ -31,13 +148,16 @@ func LoadFromFile(configFile string) (*Config, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file %s: %w", configFile, err)
 	}
+	return &config, nil
+}
 
-	// Set default branch if not specified
-	if config.GitBranch == "" {
-		config.GitBranch = "main"
+// SaveToFile writes the config as indented JSON
+func (c *Config) SaveToFile(configFile string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
 	}
-
-	return &config, nil
+	return os.WriteFile(configFile, append(data, '\n'), 0644)
 }
 
 func (c *Config) Validate() error {
@@ -61,11 +181,263 @@ func (c *Config) Validate() error {
 		if !isRepository(repo.Path) {
 			return fmt.Errorf("repository %s: path %s is not a git repository", repo.Name, repo.Path)
 		}
+		if repo.DivergencePolicy != "" && !containsString(DivergencePolicies, repo.DivergencePolicy) {
+			return fmt.Errorf("repository %s: unknown divergence policy %q (expected one of %s)",
+				repo.Name, repo.DivergencePolicy, strings.Join(DivergencePolicies, ", "))
+		}
+		if repo.Submodules != "" && !containsString(SubmoduleModes, repo.Submodules) {
+			return fmt.Errorf("repository %s: unknown submodules mode %q (expected one of %s)",
+				repo.Name, repo.Submodules, strings.Join(SubmoduleModes, ", "))
+		}
+	}
+
+	if c.GitBackend != "" && !containsString(GitBackends, c.GitBackend) {
+		return fmt.Errorf("unknown git backend %q (expected one of %s)", c.GitBackend, strings.Join(GitBackends, ", "))
+	}
+
+	for _, pattern := range c.ProtectedBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("protected branch pattern %q: %w", pattern, err)
+		}
+	}
+
+	if err := c.validateRun(); err != nil {
+		return err
+	}
+
+	for i, webhook := range c.Webhooks {
+		if err := webhook.validate(); err != nil {
+			return fmt.Errorf("webhook %d: %w", i, err)
+		}
 	}
 
 	return nil
 }
 
+// SelectRepositories returns the configured repositories filtered by name and group.
+// An empty only list selects every repository; groups narrow the selection to repositories
+// in at least one of the given groups, and exclude removes repositories by name.
+func (c *Config) SelectRepositories(only, exclude, groups []string) ([]Repository, error) {
+	names := make(map[string]bool, len(c.Repositories))
+	for _, repo := range c.Repositories {
+		names[repo.Name] = true
+	}
+	for _, name := range append(append([]string{}, only...), exclude...) {
+		if !names[name] {
+			return nil, fmt.Errorf("unknown repository %q", name)
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, group := range c.GroupNames() {
+		known[group] = true
+	}
+	for _, group := range groups {
+		if !known[group] {
+			return nil, fmt.Errorf("unknown group %q", group)
+		}
+	}
+
+	var selected []Repository
+	for _, repo := range c.Repositories {
+		if len(only) > 0 && !containsString(only, repo.Name) {
+			continue
+		}
+		if containsString(exclude, repo.Name) {
+			continue
+		}
+		if len(groups) > 0 && !repo.InAnyGroup(groups) {
+			continue
+		}
+		selected = append(selected, repo)
+	}
+
+	return selected, nil
+}
+
+// RepositoryNames returns the names of all configured repositories in config order
+func (c *Config) RepositoryNames() []string {
+	names := make([]string, 0, len(c.Repositories))
+	for _, repo := range c.Repositories {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+// GroupNames returns the sorted, de-duplicated groups used by the configured repositories
+func (c *Config) GroupNames() []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, repo := range c.Repositories {
+		for _, group := range repo.Groups {
+			if !seen[group] {
+				seen[group] = true
+				groups = append(groups, group)
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// DependencyServices returns the union of the compose services the repositories depend on,
+// in order of first appearance
+func DependencyServices(repos []Repository) []string {
+	seen := make(map[string]bool)
+	var services []string
+	for _, repo := range repos {
+		for _, service := range repo.DependsOn {
+			if !seen[service] {
+				seen[service] = true
+				services = append(services, service)
+			}
+		}
+	}
+	return services
+}
+
+// WithRunDependencies returns the named repositories together with the repositories their
+// run sections depend on, directly or transitively, ordered so that every repository comes
+// after its dependencies. Every repository with a run section is returned when names is empty.
+func (c *Config) WithRunDependencies(names []string) ([]Repository, error) {
+	byName := make(map[string]Repository, len(c.Repositories))
+	for _, repo := range c.Repositories {
+		byName[repo.Name] = repo
+	}
+
+	if len(names) == 0 {
+		for _, repo := range c.Repositories {
+			if repo.Run != nil {
+				names = append(names, repo.Name)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var ordered []Repository
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		repo, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown repository %q", name)
+		}
+		if repo.Run == nil {
+			return fmt.Errorf("repository %s has no run section", name)
+		}
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("run dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, dep := range repo.Run.DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, repo)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// InAnyGroup reports whether the repository belongs to at least one of the given groups
+func (r Repository) InAnyGroup(groups []string) bool {
+	for _, group := range r.Groups {
+		if containsString(groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRun checks the run sections and the repositories they depend on
+func (c *Config) validateRun() error {
+	names := make(map[string]bool, len(c.Repositories))
+	for _, repo := range c.Repositories {
+		if repo.Run != nil {
+			names[repo.Name] = true
+		}
+	}
+
+	for _, repo := range c.Repositories {
+		if repo.Run == nil {
+			continue
+		}
+		if strings.TrimSpace(repo.Run.Command) == "" {
+			return fmt.Errorf("repository %s: run command is required", repo.Name)
+		}
+		if repo.Run.Port < 0 || repo.Run.Port > 65535 {
+			return fmt.Errorf("repository %s: run port %d is out of range", repo.Name, repo.Run.Port)
+		}
+		if url := repo.Run.ReadinessURL; url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("repository %s: readinessUrl %s must start with http:// or https://", repo.Name, url)
+		}
+		for _, dep := range repo.Run.DependsOn {
+			if !names[dep] {
+				return fmt.Errorf("repository %s: run depends on %q, which is not a repository with a run section", repo.Name, dep)
+			}
+		}
+	}
+
+	// Report cycles at load time rather than when the services are started
+	_, err := c.WithRunDependencies(nil)
+	return err
+}
+
+func (w *Webhook) applyDefaults() {
+	if w.Format == "" {
+		w.Format = WebhookFormatJSON
+	}
+	if w.On == "" {
+		w.On = WebhookOnFailure
+	}
+	if w.TimeoutSeconds == 0 {
+		w.TimeoutSeconds = 10
+	}
+}
+
+func (w Webhook) validate() error {
+	if w.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+		return fmt.Errorf("url %s must start with http:// or https://", w.URL)
+	}
+	if w.Format != "" && w.Format != WebhookFormatJSON && w.Format != WebhookFormatSlack {
+		return fmt.Errorf("unknown format %q (expected %q or %q)", w.Format, WebhookFormatJSON, WebhookFormatSlack)
+	}
+	if w.On != "" && w.On != WebhookOnFailure && w.On != WebhookOnAlways {
+		return fmt.Errorf("unknown trigger %q (expected %q or %q)", w.On, WebhookOnFailure, WebhookOnAlways)
+	}
+	if w.TimeoutSeconds < 0 || w.Retries < 0 {
+		return fmt.Errorf("timeoutSeconds and retries must not be negative")
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func isDirectory(path string) bool {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -139,6 +140,51 @@ func TestLoadFromFileDefaultPath(t *testing.T) {
 	}
 }
 
+func TestLoadFromFileWebhookDefaults(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configContent := `{
+		"repositories": [{"path": "/path/to/repo", "name": "repo"}],
+		"webhooks": [
+			{"url": "https://example.com/hook"},
+			{"url": "https://hooks.slack.com/services/x", "format": "slack", "on": "always", "timeoutSeconds": 3, "retries": 2}
+		]
+	}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to create test config file: %v", err)
+	}
+
+	config, err := LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromFile() unexpected error: %v", err)
+	}
+
+	want := []Webhook{
+		{URL: "https://example.com/hook", Format: WebhookFormatJSON, On: WebhookOnFailure, TimeoutSeconds: 10},
+		{URL: "https://hooks.slack.com/services/x", Format: WebhookFormatSlack, On: WebhookOnAlways, TimeoutSeconds: 3, Retries: 2},
+	}
+	if len(config.Webhooks) != len(want) {
+		t.Fatalf("LoadFromFile() webhook count = %d, want %d", len(config.Webhooks), len(want))
+	}
+	for i := range want {
+		if config.Webhooks[i] != want[i] {
+			t.Errorf("LoadFromFile() webhook %d = %+v, want %+v", i, config.Webhooks[i], want[i])
+		}
+	}
+}
+
+func TestDefaultStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	if got := DefaultStateDir(); got != filepath.Join("/tmp/state", "go-cli") {
+		t.Errorf("DefaultStateDir() = %q, want /tmp/state/go-cli", got)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/dev")
+	if got := DefaultStateDir(); got != filepath.Join("/home/dev", ".local", "state", "go-cli") {
+		t.Errorf("DefaultStateDir() = %q, want /home/dev/.local/state/go-cli", got)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	// Create a temporary git repository for testing
 	tmpDir := t.TempDir()
@@ -206,6 +252,50 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "name is required",
 		},
+		{
+			name: "should validate successfully when divergence policy is known",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo", DivergencePolicy: "rebase"},
+				},
+				GitBranch: "main",
+			},
+			wantErr: false,
+		},
+		{
+			name: "should return error when submodules mode is unknown",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo", Submodules: "shallow"},
+				},
+				GitBranch: "main",
+			},
+			wantErr: true,
+			errMsg:  "unknown submodules mode",
+		},
+		{
+			name: "should return error when git backend is unknown",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo"},
+				},
+				GitBranch:  "main",
+				GitBackend: "libgit2",
+			},
+			wantErr: true,
+			errMsg:  "unknown git backend",
+		},
+		{
+			name: "should return error when divergence policy is unknown",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo", DivergencePolicy: "merge"},
+				},
+				GitBranch: "main",
+			},
+			wantErr: true,
+			errMsg:  "unknown divergence policy",
+		},
 		{
 			name: "should return error when repository path does not exist",
 			config: &Config{
@@ -239,6 +329,92 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "is not a git repository",
 		},
+		{
+			name: "should return error when webhook url is missing",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo"},
+				},
+				Webhooks: []Webhook{{Format: WebhookFormatSlack}},
+			},
+			wantErr: true,
+			errMsg:  "url is required",
+		},
+		{
+			name: "should return error when webhook format is unknown",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo"},
+				},
+				Webhooks: []Webhook{{URL: "https://example.com/hook", Format: "xml"}},
+			},
+			wantErr: true,
+			errMsg:  "unknown format",
+		},
+		{
+			name: "should return error when webhook trigger is unknown",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo"},
+				},
+				Webhooks: []Webhook{{URL: "https://example.com/hook", On: "sometimes"}},
+			},
+			wantErr: true,
+			errMsg:  "unknown trigger",
+		},
+		{
+			name: "should return error when a protected branch pattern is malformed",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo"},
+				},
+				ProtectedBranches: []string{"release/[0-9"},
+			},
+			wantErr: true,
+			errMsg:  "protected branch pattern",
+		},
+		{
+			name: "should return error when run command is missing",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo", Run: &RunConfig{Port: 8080}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "run command is required",
+		},
+		{
+			name: "should return error when run depends on a repository without a run section",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo", Run: &RunConfig{Command: "go run .", DependsOn: []string{"other"}}},
+					{Path: gitRepo, Name: "other"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "not a repository with a run section",
+		},
+		{
+			name: "should return error when run dependencies form a cycle",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "a", Run: &RunConfig{Command: "go run .", DependsOn: []string{"b"}}},
+					{Path: gitRepo, Name: "b", Run: &RunConfig{Command: "go run .", DependsOn: []string{"a"}}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "run dependency cycle: a -> b -> a",
+		},
+		{
+			name: "should return error when readiness url is not http",
+			config: &Config{
+				Repositories: []Repository{
+					{Path: gitRepo, Name: "valid-repo", Run: &RunConfig{Command: "go run .", ReadinessURL: "localhost:8080"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "must start with http://",
+		},
 		{
 			name: "should return error when one of multiple repositories is invalid",
 			config: &Config{
@@ -381,4 +557,169 @@ func indexOf(s, substr string) int {
 		}
 	}
 	return -1
-}
\ No newline at end of file
+}
+func TestSelectRepositories(t *testing.T) {
+	config := &Config{
+		Repositories: []Repository{
+			{Name: "auth", Path: "/src/auth", Groups: []string{"backend", "core"}},
+			{Name: "billing", Path: "/src/billing", Groups: []string{"backend"}},
+			{Name: "web", Path: "/src/web", Groups: []string{"frontend"}},
+			{Name: "docs", Path: "/src/docs"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		only      []string
+		exclude   []string
+		groups    []string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "should select every repository without filters",
+			wantNames: []string{"auth", "billing", "web", "docs"},
+		},
+		{
+			name:      "should select only the named repositories",
+			only:      []string{"web", "auth"},
+			wantNames: []string{"auth", "web"},
+		},
+		{
+			name:      "should drop excluded repositories",
+			exclude:   []string{"docs"},
+			wantNames: []string{"auth", "billing", "web"},
+		},
+		{
+			name:      "should select repositories in any of the groups",
+			groups:    []string{"core", "frontend"},
+			wantNames: []string{"auth", "web"},
+		},
+		{
+			name:      "should combine group and exclude filters",
+			groups:    []string{"backend"},
+			exclude:   []string{"auth"},
+			wantNames: []string{"billing"},
+		},
+		{
+			name:    "should return error for unknown repository",
+			only:    []string{"payments"},
+			wantErr: "unknown repository",
+		},
+		{
+			name:    "should return error for unknown group",
+			groups:  []string{"mobile"},
+			wantErr: "unknown group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, err := config.SelectRepositories(tt.only, tt.exclude, tt.groups)
+
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("SelectRepositories() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectRepositories() unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, repo := range repos {
+				names = append(names, repo.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("SelectRepositories() = %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("SelectRepositories() = %v, want %v", names, tt.wantNames)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGroupNames(t *testing.T) {
+	config := &Config{
+		Repositories: []Repository{
+			{Name: "auth", Groups: []string{"core", "backend"}},
+			{Name: "billing", Groups: []string{"backend"}},
+			{Name: "docs"},
+		},
+	}
+
+	groups := config.GroupNames()
+	want := []string{"backend", "core"}
+	if len(groups) != len(want) || groups[0] != want[0] || groups[1] != want[1] {
+		t.Errorf("GroupNames() = %v, want %v", groups, want)
+	}
+}
+
+func TestDependencyServices(t *testing.T) {
+	repos := []Repository{
+		{Name: "auth", DependsOn: []string{"postgres-auth", "redis"}},
+		{Name: "docs"},
+		{Name: "orders", DependsOn: []string{"kafka", "redis", "mongodb-main"}},
+	}
+
+	services := DependencyServices(repos)
+	want := []string{"postgres-auth", "redis", "kafka", "mongodb-main"}
+	if strings.Join(services, ",") != strings.Join(want, ",") {
+		t.Errorf("DependencyServices() = %v, want %v", services, want)
+	}
+
+	if services := DependencyServices(repos[1:2]); len(services) != 0 {
+		t.Errorf("DependencyServices(docs) = %v, want none", services)
+	}
+}
+
+func TestWithRunDependencies(t *testing.T) {
+	cfg := &Config{Repositories: []Repository{
+		{Name: "gateway", Run: &RunConfig{Command: "go run .", DependsOn: []string{"orders", "auth"}}},
+		{Name: "orders", Run: &RunConfig{Command: "go run .", DependsOn: []string{"auth"}}},
+		{Name: "docs"},
+		{Name: "auth", Run: &RunConfig{Command: "go run ."}},
+		{Name: "billing", Run: &RunConfig{Command: "go run ."}},
+	}}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    string
+		wantErr string
+	}{
+		{name: "should order every runnable repository after its dependencies", want: "auth,orders,gateway,billing"},
+		{name: "should include transitive dependencies", names: []string{"gateway"}, want: "auth,orders,gateway"},
+		{name: "should keep independent repositories alone", names: []string{"billing"}, want: "billing"},
+		{name: "should reject repositories without a run section", names: []string{"docs"}, wantErr: "has no run section"},
+		{name: "should reject unknown repositories", names: []string{"missing"}, wantErr: "unknown repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, err := cfg.WithRunDependencies(tt.names)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Errorf("WithRunDependencies() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WithRunDependencies() unexpected error: %v", err)
+			}
+
+			names := make([]string, 0, len(repos))
+			for _, repo := range repos {
+				names = append(names, repo.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("WithRunDependencies() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest formats "config import" reads
+const (
+	ImportRepoManifest = "repo-manifest" // Android repo tool manifest XML
+	ImportGitmodules   = "gitmodules"    // .gitmodules of a superproject
+	ImportMani         = "mani"          // mani.yaml
+	ImportMrconfig     = "mrconfig"      // myrepos .mrconfig
+)
+
+// ImportFormats lists the supported manifest formats
+var ImportFormats = []string{ImportRepoManifest, ImportGitmodules, ImportMani, ImportMrconfig}
+
+// Kinds of ImportChange
+const (
+	ImportAdded     = "added"
+	ImportUpdated   = "updated"   // Same repository; groups, URL or revision filled in
+	ImportUnchanged = "unchanged" // Already configured identically
+	ImportConflict  = "conflict"  // Kept as configured; the imported entry was dropped
+)
+
+// ImportChange describes what merging an imported repository did
+type ImportChange struct {
+	Repository Repository // The imported entry
+	Kind       string
+	Reason     string // Why an entry conflicts
+}
+
+// ImportRepositories reads the repositories described by a manifest. Paths in the manifest
+// are resolved against root, or the home directory for "~/" paths, and made absolute, so they
+// point at the checkouts wherever config.json is read from. Repositories sharing a name within
+// the manifest are named after their whole path instead.
+func ImportRepositories(format, file, root string) ([]Repository, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var repos []Repository
+	switch format {
+	case ImportRepoManifest:
+		repos, err = parseRepoManifest(data)
+	case ImportGitmodules:
+		repos, err = parseGitmodules(data)
+	case ImportMani:
+		repos, err = parseMani(data)
+	case ImportMrconfig:
+		repos, err = parseMrconfig(data)
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q (expected one of %s)", format, strings.Join(ImportFormats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	uniqueNames(repos)
+
+	for i, repo := range repos {
+		if strings.HasPrefix(repo.Path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				repos[i].Path = filepath.Join(home, repo.Path[2:])
+				continue
+			}
+		}
+		if filepath.IsAbs(repo.Path) {
+			repos[i].Path = filepath.Clean(repo.Path)
+		} else {
+			repos[i].Path = filepath.Join(root, filepath.FromSlash(repo.Path))
+		}
+	}
+	return repos, nil
+}
+
+// uniqueNames renames the repositories named after the same last path element, such as
+// "platform/build" and "tools/build", after their whole manifest path so that neither is
+// dropped as a conflict
+func uniqueNames(repos []Repository) {
+	counts := make(map[string]int, len(repos))
+	for _, repo := range repos {
+		counts[repo.Name]++
+	}
+	for i, repo := range repos {
+		if counts[repo.Name] > 1 {
+			repos[i].Name = strings.TrimPrefix(path.Clean(filepath.ToSlash(repo.Path)), "~/")
+		}
+	}
+}
+
+// MergeRepositories adds imported repositories to the config. A repository is matched by
+// name; a match with another path or URL, or a new repository at a configured path, is a
+// conflict and leaves the config untouched. Matches gain the imported groups, and the URL and
+// revision when they had none.
+func (c *Config) MergeRepositories(imported []Repository) []ImportChange {
+	changes := make([]ImportChange, 0, len(imported))
+	for _, repo := range imported {
+		existing := c.findRepository(repo.Name)
+		if existing == nil {
+			if other := c.findRepositoryByPath(repo.Path); other != nil {
+				changes = append(changes, ImportChange{Repository: repo, Kind: ImportConflict,
+					Reason: fmt.Sprintf("path %s is already configured as %s", repo.Path, other.Name)})
+				continue
+			}
+			c.Repositories = append(c.Repositories, repo)
+			changes = append(changes, ImportChange{Repository: repo, Kind: ImportAdded})
+			continue
+		}
+
+		if !samePath(existing.Path, repo.Path) {
+			changes = append(changes, ImportChange{Repository: repo, Kind: ImportConflict,
+				Reason: fmt.Sprintf("configured with path %s, imported with %s", existing.Path, repo.Path)})
+			continue
+		}
+		if existing.URL != "" && repo.URL != "" && existing.URL != repo.URL {
+			changes = append(changes, ImportChange{Repository: repo, Kind: ImportConflict,
+				Reason: fmt.Sprintf("configured with URL %s, imported with %s", existing.URL, repo.URL)})
+			continue
+		}
+
+		updated := false
+		if existing.URL == "" && repo.URL != "" {
+			existing.URL = repo.URL
+			updated = true
+		}
+		if existing.Revision == "" && repo.Revision != "" {
+			existing.Revision = repo.Revision
+			updated = true
+		}
+		for _, group := range repo.Groups {
+			if !containsString(existing.Groups, group) {
+				existing.Groups = append(existing.Groups, group)
+				updated = true
+			}
+		}
+
+		kind := ImportUnchanged
+		if updated {
+			kind = ImportUpdated
+		}
+		changes = append(changes, ImportChange{Repository: repo, Kind: kind})
+	}
+	return changes
+}
+
+// findRepository returns the configured repository with the given name, or nil
+func (c *Config) findRepository(name string) *Repository {
+	for i := range c.Repositories {
+		if c.Repositories[i].Name == name {
+			return &c.Repositories[i]
+		}
+	}
+	return nil
+}
+
+// findRepositoryByPath returns the configured repository checked out at path, or nil
+func (c *Config) findRepositoryByPath(repoPath string) *Repository {
+	for i := range c.Repositories {
+		if samePath(c.Repositories[i].Path, repoPath) {
+			return &c.Repositories[i]
+		}
+	}
+	return nil
+}
+
+// samePath reports whether two paths, relative ones taken from the working directory, are the same
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// repoManifest is the subset of the repo tool's manifest format that describes projects
+type repoManifest struct {
+	Remotes []struct {
+		Name     string `xml:"name,attr"`
+		Fetch    string `xml:"fetch,attr"`
+		Revision string `xml:"revision,attr"`
+	} `xml:"remote"`
+	Default struct {
+		Remote   string `xml:"remote,attr"`
+		Revision string `xml:"revision,attr"`
+	} `xml:"default"`
+	Projects []struct {
+		Name     string `xml:"name,attr"`
+		Path     string `xml:"path,attr"`
+		Remote   string `xml:"remote,attr"`
+		Revision string `xml:"revision,attr"`
+		Groups   string `xml:"groups,attr"`
+	} `xml:"project"`
+}
+
+// parseRepoManifest converts <project> elements. The URL joins the fetch prefix of the
+// project's remote with its name; the revision falls back to the remote's and the default.
+func parseRepoManifest(data []byte) ([]Repository, error) {
+	var manifest repoManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	var repos []Repository
+	for _, project := range manifest.Projects {
+		if project.Name == "" {
+			return nil, fmt.Errorf("project without a name")
+		}
+
+		repoPath := project.Path
+		if repoPath == "" {
+			repoPath = project.Name
+		}
+		repo := Repository{Name: path.Base(repoPath), Path: repoPath, Revision: project.Revision}
+
+		remoteName := project.Remote
+		if remoteName == "" {
+			remoteName = manifest.Default.Remote
+		}
+		for _, remote := range manifest.Remotes {
+			if remote.Name != remoteName {
+				continue
+			}
+			if remote.Fetch != "" {
+				repo.URL = strings.TrimSuffix(remote.Fetch, "/") + "/" + project.Name
+			}
+			if repo.Revision == "" {
+				repo.Revision = remote.Revision
+			}
+		}
+		if repo.Revision == "" {
+			repo.Revision = manifest.Default.Revision
+		}
+
+		// Groups are separated by commas or whitespace; "all" and "default" are implicit
+		for _, group := range strings.FieldsFunc(project.Groups, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			if group != "all" && group != "default" && !containsString(repo.Groups, group) {
+				repo.Groups = append(repo.Groups, group)
+			}
+		}
+
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// parseGitmodules converts the [submodule "name"] sections of a .gitmodules file
+func parseGitmodules(data []byte) ([]Repository, error) {
+	sections, err := parseIniSections(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repository
+	for _, section := range sections {
+		name := strings.TrimPrefix(section.name, "submodule ")
+		if name == section.name {
+			continue
+		}
+		name = strings.Trim(name, `"`)
+
+		repoPath := section.values["path"]
+		if repoPath == "" {
+			return nil, fmt.Errorf("submodule %q has no path", name)
+		}
+		repos = append(repos, Repository{
+			Name:     path.Base(repoPath),
+			Path:     repoPath,
+			URL:      section.values["url"],
+			Revision: section.values["branch"],
+		})
+	}
+	return repos, nil
+}
+
+// maniConfig is the subset of mani.yaml that describes projects
+type maniConfig struct {
+	Projects map[string]struct {
+		Path   string   `yaml:"path"`
+		URL    string   `yaml:"url"`
+		Branch string   `yaml:"branch"`
+		Tags   []string `yaml:"tags"`
+	} `yaml:"projects"`
+}
+
+// parseMani converts mani projects, sorted by name; tags become groups. A project without a
+// path lives in a directory named after it.
+func parseMani(data []byte) ([]Repository, error) {
+	var config maniConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(config.Projects))
+	for name := range config.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	repos := make([]Repository, 0, len(names))
+	for _, name := range names {
+		project := config.Projects[name]
+		repoPath := project.Path
+		if repoPath == "" {
+			repoPath = name
+		}
+		repos = append(repos, Repository{
+			Name:     name,
+			Path:     repoPath,
+			URL:      project.URL,
+			Revision: project.Branch,
+			Groups:   project.Tags,
+		})
+	}
+	return repos, nil
+}
+
+// parseMrconfig converts the sections of a myrepos config, whose names are checkout paths.
+// The URL and branch are taken from a "git clone" checkout command when there is one.
+func parseMrconfig(data []byte) ([]Repository, error) {
+	sections, err := parseIniSections(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repository
+	for _, section := range sections {
+		// [DEFAULT] holds settings shared by all repositories
+		if section.name == "DEFAULT" {
+			continue
+		}
+
+		repo := Repository{Name: path.Base(section.name), Path: section.name}
+		repo.URL, repo.Revision = parseCloneCommand(section.values["checkout"])
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// cloneOptionsWithValue are the git clone options whose value is a separate argument
+var cloneOptionsWithValue = map[string]bool{
+	"--depth": true, "-o": true, "--origin": true, "-c": true, "--config": true, "-j": true, "--jobs": true,
+	"-u": true, "--upload-pack": true, "--reference": true, "--template": true, "--separate-git-dir": true,
+	"--filter": true, "--shallow-since": true, "--shallow-exclude": true,
+}
+
+// parseCloneCommand extracts the URL and branch from a "git clone [-b branch] <url> [dir]" command
+func parseCloneCommand(command string) (url, branch string) {
+	fields := strings.Fields(command)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] != "git" || fields[i+1] != "clone" {
+			continue
+		}
+
+		var positional []string
+		for j := i + 2; j < len(fields); j++ {
+			field := strings.Trim(fields[j], `'"`)
+			switch {
+			case (field == "-b" || field == "--branch") && j+1 < len(fields):
+				j++
+				branch = strings.Trim(fields[j], `'"`)
+			case strings.HasPrefix(field, "--branch="):
+				branch = strings.TrimPrefix(field, "--branch=")
+			case cloneOptionsWithValue[field]:
+				j++
+			case strings.HasPrefix(field, "-"):
+			case field == "&&" || field == ";":
+				j = len(fields)
+			default:
+				positional = append(positional, field)
+			}
+		}
+		if len(positional) > 0 {
+			url = positional[0]
+		}
+		return url, branch
+	}
+	return "", ""
+}
+
+// iniSection is a section of a git-config style file
+type iniSection struct {
+	name   string
+	values map[string]string
+}
+
+// parseIniSections reads a git-config style file: [section] headers followed by key = value
+// lines. Comments start with # or ;, and a trailing backslash continues a value.
+func parseIniSections(data []byte) ([]iniSection, error) {
+	var sections []iniSection
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	var continued string
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if continued != "" {
+			line = continued + " " + line
+			continued = ""
+		}
+		if strings.HasSuffix(line, `\`) {
+			continued = strings.TrimSuffix(line, `\`)
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNumber)
+			}
+			sections = append(sections, iniSection{name: strings.TrimSpace(line[1 : len(line)-1]), values: map[string]string{}})
+			continue
+		}
+
+		if len(sections) == 0 {
+			return nil, fmt.Errorf("line %d: setting outside of a section", lineNumber)
+		}
+		key, value, _ := strings.Cut(line, "=")
+		sections[len(sections)-1].values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return sections, scanner.Err()
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportRepositories(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []Repository
+		wantErr string
+	}{
+		{
+			name:   "should convert repo tool manifests",
+			format: ImportRepoManifest,
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<manifest>
+  <remote name="origin" fetch="https://git.example.com/" />
+  <remote name="mirror" fetch="ssh://mirror.example.com" revision="stable" />
+  <default remote="origin" revision="main" />
+  <project name="platform/orders" path="services/orders" groups="backend,default core" />
+  <project name="platform/auth" remote="mirror" />
+  <project name="tools/cli" revision="v1.2.0" groups="all" />
+</manifest>`,
+			want: []Repository{
+				{Name: "orders", Path: "services/orders", URL: "https://git.example.com/platform/orders", Revision: "main", Groups: []string{"backend", "core"}},
+				{Name: "auth", Path: "platform/auth", URL: "ssh://mirror.example.com/platform/auth", Revision: "stable"},
+				{Name: "cli", Path: "tools/cli", URL: "https://git.example.com/tools/cli", Revision: "v1.2.0"},
+			},
+		},
+		{
+			name:   "should name repositories after their path when names collide",
+			format: ImportRepoManifest,
+			content: `<manifest>
+  <remote name="origin" fetch="https://git.example.com" />
+  <default remote="origin" revision="main" />
+  <project name="platform/build" />
+  <project name="tools/build" />
+  <project name="platform/orders" path="services/orders" />
+</manifest>`,
+			want: []Repository{
+				{Name: "platform/build", Path: "platform/build", URL: "https://git.example.com/platform/build", Revision: "main"},
+				{Name: "tools/build", Path: "tools/build", URL: "https://git.example.com/tools/build", Revision: "main"},
+				{Name: "orders", Path: "services/orders", URL: "https://git.example.com/platform/orders", Revision: "main"},
+			},
+		},
+		{
+			name:   "should name submodules after their path when names collide",
+			format: ImportGitmodules,
+			content: `[submodule "a"]
+	path = libs/shared
+[submodule "b"]
+	path = vendor/shared/
+`,
+			want: []Repository{
+				{Name: "libs/shared", Path: "libs/shared"},
+				{Name: "vendor/shared", Path: "vendor/shared/"},
+			},
+		},
+		{
+			name:   "should convert gitmodules",
+			format: ImportGitmodules,
+			content: `[submodule "libs/shared"]
+	path = libs/shared
+	url = git@example.com:org/shared.git
+	branch = develop
+# A comment
+[submodule "orders"]
+	path = services/orders
+	url = ../orders.git
+`,
+			want: []Repository{
+				{Name: "shared", Path: "libs/shared", URL: "git@example.com:org/shared.git", Revision: "develop"},
+				{Name: "orders", Path: "services/orders", URL: "../orders.git"},
+			},
+		},
+		{
+			name:   "should convert mani projects with tags as groups",
+			format: ImportMani,
+			content: `projects:
+  orders:
+    path: services/orders
+    url: git@example.com:org/orders.git
+    tags: [backend]
+  auth:
+    url: git@example.com:org/auth.git
+    branch: develop
+tasks:
+  status:
+    cmd: git status
+`,
+			want: []Repository{
+				{Name: "auth", Path: "auth", URL: "git@example.com:org/auth.git", Revision: "develop"},
+				{Name: "orders", Path: "services/orders", URL: "git@example.com:org/orders.git", Groups: []string{"backend"}},
+			},
+		},
+		{
+			name:   "should convert myrepos sections",
+			format: ImportMrconfig,
+			content: `[DEFAULT]
+git_gc = git gc "$@"
+
+[services/orders]
+checkout = git clone 'git@example.com:org/orders.git' 'orders'
+
+[services/auth]
+checkout = git clone --depth 1 -b develop \
+	git@example.com:org/auth.git auth
+`,
+			want: []Repository{
+				{Name: "orders", Path: "services/orders", URL: "git@example.com:org/orders.git"},
+				{Name: "auth", Path: "services/auth", URL: "git@example.com:org/auth.git", Revision: "develop"},
+			},
+		},
+		{
+			name:    "should reject unknown formats",
+			format:  "meta",
+			wantErr: "unknown manifest format",
+		},
+		{
+			name:    "should reject submodules without a path",
+			format:  ImportGitmodules,
+			content: "[submodule \"x\"]\n\turl = x.git\n",
+			wantErr: "has no path",
+		},
+		{
+			name:    "should reject invalid XML",
+			format:  ImportRepoManifest,
+			content: "<manifest><project",
+			wantErr: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, "manifest")
+			if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write manifest: %v", err)
+			}
+
+			got, err := ImportRepositories(tt.format, file, "/src")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ImportRepositories() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportRepositories() unexpected error: %v", err)
+			}
+
+			for i := range tt.want {
+				tt.want[i].Path = filepath.Join("/src", tt.want[i].Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ImportRepositories() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportRepositoriesRelativeRoot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".gitmodules")
+	content := "[submodule \"shared\"]\n\tpath = libs/shared\n[submodule \"tools\"]\n\tpath = /opt/tools/../cli\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+
+	got, err := ImportRepositories(ImportGitmodules, file, "src")
+	if err != nil {
+		t.Fatalf("ImportRepositories() unexpected error: %v", err)
+	}
+	want := []string{filepath.Join(wd, "src", "libs", "shared"), "/opt/cli"}
+	if len(got) != len(want) {
+		t.Fatalf("ImportRepositories() = %+v, want %d repositories", got, len(want))
+	}
+	for i, repo := range got {
+		if repo.Path != want[i] {
+			t.Errorf("ImportRepositories() path = %s, want %s", repo.Path, want[i])
+		}
+	}
+}
+
+func TestMergeRepositories(t *testing.T) {
+	cfg := &Config{Repositories: []Repository{
+		{Name: "orders", Path: "/src/orders", Groups: []string{"backend"}},
+		{Name: "auth", Path: "/src/auth", URL: "git@example.com:org/auth.git"},
+		{Name: "gateway", Path: "/src/gateway"},
+	}}
+
+	changes := cfg.MergeRepositories([]Repository{
+		{Name: "orders", Path: "/src/orders/", URL: "git@example.com:org/orders.git", Groups: []string{"backend", "core"}},
+		{Name: "auth", Path: "/src/auth", URL: "git@example.com:other/auth.git"},
+		{Name: "gateway", Path: "/src/gateway"},
+		{Name: "payments", Path: "/src/payments"},
+		{Name: "edge", Path: "/src/gateway"},
+		{Name: "orders", Path: "/elsewhere/orders"},
+	})
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Repository.Name+": "+change.Kind)
+	}
+	want := []string{
+		"orders: " + ImportUpdated,
+		"auth: " + ImportConflict,
+		"gateway: " + ImportUnchanged,
+		"payments: " + ImportAdded,
+		"edge: " + ImportConflict,
+		"orders: " + ImportConflict,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeRepositories() = %q, want %q", got, want)
+	}
+
+	wantRepos := []Repository{
+		{Name: "orders", Path: "/src/orders", URL: "git@example.com:org/orders.git", Groups: []string{"backend", "core"}},
+		{Name: "auth", Path: "/src/auth", URL: "git@example.com:org/auth.git"},
+		{Name: "gateway", Path: "/src/gateway"},
+		{Name: "payments", Path: "/src/payments"},
+	}
+	if !reflect.DeepEqual(cfg.Repositories, wantRepos) {
+		t.Errorf("Repositories after merge =\n%+v\nwant\n%+v", cfg.Repositories, wantRepos)
+	}
+	if !strings.Contains(changes[4].Reason, "already configured as gateway") {
+		t.Errorf("conflict reason = %q, want the configured repository named", changes[4].Reason)
+	}
+}
+
+func TestReadFromFileKeepsSettingsUnset(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"repositories": [{"name": "orders", "path": "/src/orders"}]}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := ReadFromFile(file)
+	if err != nil {
+		t.Fatalf("ReadFromFile() unexpected error: %v", err)
+	}
+	if err := cfg.SaveToFile(file); err != nil {
+		t.Fatalf("SaveToFile() unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	if strings.Contains(string(data), "gitBranch") || strings.Contains(string(data), "stateDir") {
+		t.Errorf("saved config gained defaults:\n%s", data)
+	}
+}
@@ -2,6 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/fatih/color"
 )
@@ -56,3 +59,16 @@ func (c *CliOutput) Plain(format string, args ...interface{}) {
 func (c *CliOutput) Printf(format string, args ...interface{}) {
 	fmt.Printf(format, args...)
 }
+
+// Table prints rows as aligned columns under a header
+func (c *CliOutput) Table(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	w.Flush()
+}